@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -14,6 +15,7 @@ import (
 	"github.com/mmrzaf/sdgen/internal/app"
 	"github.com/mmrzaf/sdgen/internal/config"
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/importer"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/runs"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/scenarios"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/targets"
@@ -76,11 +78,20 @@ func scenarioCmd() *cobra.Command {
 	}
 	list.Flags().StringVar(&format, "format", "table", "Output format (table|json)")
 
+	var source bool
 	show := &cobra.Command{
 		Use:  "show <id>",
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			repo := scenarios.NewFileRepository(scenariosDir)
+			if source {
+				src, err := repo.GetSource(args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(src))
+				return nil
+			}
 			sc, err := repo.Get(args[0])
 			if err != nil {
 				return err
@@ -90,6 +101,7 @@ func scenarioCmd() *cobra.Command {
 			return nil
 		},
 	}
+	show.Flags().BoolVar(&source, "source", false, "Print the scenario file as written, before imports, column templates and extends are resolved")
 
 	validate := &cobra.Command{
 		Use:  "validate <id|path>",
@@ -109,15 +121,182 @@ func scenarioCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := repo.LoadDictionaries(sc); err != nil {
+				return err
+			}
 			val := validation.NewValidator(registry.DefaultGeneratorRegistry())
 			return val.ValidateScenario(sc)
 		},
 	}
 
-	cmd.AddCommand(list, show, validate)
+	var (
+		srcTargetID  string
+		srcTargetDSN string
+		srcTargetDB  string
+		srcSchema    string
+		outID        string
+		outName      string
+		defaultRows  int64
+		outPath      string
+		tableList    []string
+		sampleRows   int
+		maxCard      int
+	)
+	// sourceTarget resolves the postgres target a scenario is read from.
+	sourceTarget := func() (*domain.TargetConfig, error) {
+		var t *domain.TargetConfig
+		switch {
+		case srcTargetDSN != "":
+			t = &domain.TargetConfig{Name: "inline-target", Kind: "postgres", DSN: srcTargetDSN}
+		case srcTargetID != "":
+			runRepo := runs.NewPostgresRepository(sdgenDBDSN)
+			if err := runRepo.Init(); err != nil {
+				return nil, err
+			}
+			var err error
+			t, err = targets.NewPostgresRepository(runRepo.DB()).Get(srcTargetID)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("either --target-id or --target required")
+		}
+		if srcTargetDB != "" {
+			t.Database = srcTargetDB
+		}
+		return t, nil
+	}
+	sourceFlags := func(c *cobra.Command) {
+		c.Flags().StringVar(&srcTargetID, "target-id", "", "Target ID (postgres)")
+		c.Flags().StringVar(&srcTargetDSN, "target", "", "Inline postgres DSN (not stored)")
+		c.Flags().StringVar(&srcTargetDB, "target-db", "", "Database override")
+		c.Flags().StringVar(&srcSchema, "schema", "", "Schema to inspect (default: target schema or public)")
+		c.Flags().StringVar(&outID, "id", "", "Scenario id (default: schema name)")
+		c.Flags().StringVar(&outName, "name", "", "Scenario name (default: id)")
+		c.Flags().Int64Var(&defaultRows, "default-rows", 1000, "Rows for tables that are empty")
+		c.Flags().StringVar(&outPath, "out", "", "Write the scenario YAML to this file instead of stdout")
+	}
+
+	infer := &cobra.Command{
+		Use:   "infer",
+		Short: "Generate a scenario from an existing postgres schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := sourceTarget()
+			if err != nil {
+				return err
+			}
+			sc, warnings, err := app.InferScenario(t, srcSchema, importer.Options{ID: outID, Name: outName, DefaultRows: defaultRows})
+			printWarnings(warnings)
+			if err != nil {
+				return err
+			}
+			return writeScenario(sc, outPath)
+		},
+	}
+	sourceFlags(infer)
+
+	profile := &cobra.Command{
+		Use:   "profile",
+		Short: "Generate a scenario whose generators are fitted to sampled postgres data",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := sourceTarget()
+			if err != nil {
+				return err
+			}
+			sc, warnings, err := app.ProfileScenario(t, srcSchema, tableList,
+				importer.Options{ID: outID, Name: outName, DefaultRows: defaultRows},
+				importer.ProfileOptions{SampleRows: sampleRows, MaxCardinality: maxCard})
+			printWarnings(warnings)
+			if err != nil {
+				return err
+			}
+			return writeScenario(sc, outPath)
+		},
+	}
+	sourceFlags(profile)
+	profile.Flags().StringSliceVar(&tableList, "table", nil, "Only profile these tables (repeatable)")
+	profile.Flags().IntVar(&sampleRows, "sample-rows", 10000, "Rows sampled per table")
+	profile.Flags().IntVar(&maxCard, "max-cardinality", 20, "Most distinct text values copied into a choice generator")
+
+	var dialect string
+	importDDL := &cobra.Command{
+		Use:   "import-ddl <schema.sql>",
+		Short: "Generate a scenario from CREATE TABLE statements (postgres or mysql)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			id := outID
+			if id == "" {
+				id = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+			sc, warnings, err := app.ImportDDL(string(b), dialect, importer.Options{ID: id, Name: outName, DefaultRows: defaultRows})
+			printWarnings(warnings)
+			if err != nil {
+				return err
+			}
+			return writeScenario(sc, outPath)
+		},
+	}
+	importDDL.Flags().StringVar(&dialect, "dialect", "", "SQL dialect: postgres or mysql (default: detect)")
+	importDDL.Flags().StringVar(&outID, "id", "", "Scenario id (default: file name)")
+	importDDL.Flags().StringVar(&outName, "name", "", "Scenario name (default: id)")
+	importDDL.Flags().Int64Var(&defaultRows, "default-rows", 1000, "Rows per table")
+	importDDL.Flags().StringVar(&outPath, "out", "", "Write the scenario YAML to this file instead of stdout")
+
+	var componentList []string
+	importOpenAPI := &cobra.Command{
+		Use:   "import-openapi <spec.yaml>",
+		Short: "Generate a scenario from OpenAPI / JSON Schema component schemas",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			id := outID
+			if id == "" {
+				id = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+			sc, warnings, err := app.ImportOpenAPI(b, componentList, importer.Options{ID: id, Name: outName, DefaultRows: defaultRows})
+			printWarnings(warnings)
+			if err != nil {
+				return err
+			}
+			return writeScenario(sc, outPath)
+		},
+	}
+	importOpenAPI.Flags().StringSliceVar(&componentList, "components", nil, "Component schemas to import, e.g. User,Order (default: all object schemas)")
+	importOpenAPI.Flags().StringVar(&outID, "id", "", "Scenario id (default: file name)")
+	importOpenAPI.Flags().StringVar(&outName, "name", "", "Scenario name (default: id)")
+	importOpenAPI.Flags().Int64Var(&defaultRows, "default-rows", 1000, "Rows per table")
+	importOpenAPI.Flags().StringVar(&outPath, "out", "", "Write the scenario YAML to this file instead of stdout")
+
+	cmd.AddCommand(list, show, validate, infer, profile, importDDL, importOpenAPI)
 	return cmd
 }
 
+// writeScenario prints a scenario as YAML, or writes it to path when set.
+func writeScenario(sc *domain.Scenario, path string) error {
+	b, err := yaml.Marshal(sc)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		fmt.Print(string(b))
+		return nil
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func printWarnings(warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+}
+
 func targetCmd() *cobra.Command {
 	cmd := &cobra.Command{Use: "target", Short: "Manage targets (DB-backed)"}
 	var format string
@@ -188,6 +367,7 @@ func targetCmd() *cobra.Command {
 		password string
 		sslmode  string
 		scheme   string
+		options  []string
 	)
 
 	add := &cobra.Command{
@@ -206,7 +386,11 @@ func targetCmd() *cobra.Command {
 					return err
 				}
 			}
-			t := &domain.TargetConfig{ID: id, Name: name, Kind: kind, DSN: resolvedDSN, Database: database, Schema: schema}
+			opts, err := parseTargetOptions(options)
+			if err != nil {
+				return err
+			}
+			t := &domain.TargetConfig{ID: id, Name: name, Kind: kind, DSN: resolvedDSN, Database: database, Schema: schema, Options: opts}
 			if err := val.ValidateTarget(t); err != nil {
 				return err
 			}
@@ -219,16 +403,17 @@ func targetCmd() *cobra.Command {
 	}
 	add.Flags().StringVar(&id, "id", "", "Target id (optional)")
 	add.Flags().StringVar(&name, "name", "", "Target name")
-	add.Flags().StringVar(&kind, "kind", "", "Target kind (postgres|elasticsearch)")
+	add.Flags().StringVar(&kind, "kind", "", "Target kind (postgres|elasticsearch|opensearch|clickhouse|kafka|mongodb|redis|http)")
 	add.Flags().StringVar(&dsn, "dsn", "", "Target DSN")
-	add.Flags().StringVar(&database, "database", "", "Default database name for postgres targets")
+	add.Flags().StringVar(&database, "database", "", "Default database name for postgres/clickhouse/mongodb targets")
 	add.Flags().StringVar(&schema, "schema", "", "Schema (postgres)")
 	add.Flags().StringVar(&host, "host", "", "Host used to build DSN when --dsn is omitted")
 	add.Flags().IntVar(&port, "port", 0, "Port used to build DSN when --dsn is omitted")
 	add.Flags().StringVar(&user, "user", "", "Username used to build DSN when --dsn is omitted")
 	add.Flags().StringVar(&password, "password", "", "Password used to build DSN when --dsn is omitted")
 	add.Flags().StringVar(&sslmode, "sslmode", "disable", "Postgres sslmode used to build DSN")
-	add.Flags().StringVar(&scheme, "scheme", "", "Scheme used to build DSN (http/https for elasticsearch)")
+	add.Flags().StringVar(&scheme, "scheme", "", "Scheme used to build DSN (http/https for elasticsearch/clickhouse)")
+	add.Flags().StringArrayVar(&options, "option", nil, "Target option key=value (repeatable)")
 	_ = add.MarkFlagRequired("name")
 	_ = add.MarkFlagRequired("kind")
 
@@ -249,7 +434,11 @@ func targetCmd() *cobra.Command {
 					return err
 				}
 			}
-			t := &domain.TargetConfig{ID: args[0], Name: name, Kind: kind, DSN: resolvedDSN, Database: database, Schema: schema}
+			opts, err := parseTargetOptions(options)
+			if err != nil {
+				return err
+			}
+			t := &domain.TargetConfig{ID: args[0], Name: name, Kind: kind, DSN: resolvedDSN, Database: database, Schema: schema, Options: opts}
 			if err := val.ValidateTarget(t); err != nil {
 				return err
 			}
@@ -257,16 +446,17 @@ func targetCmd() *cobra.Command {
 		},
 	}
 	update.Flags().StringVar(&name, "name", "", "Target name")
-	update.Flags().StringVar(&kind, "kind", "", "Target kind (postgres|elasticsearch)")
+	update.Flags().StringVar(&kind, "kind", "", "Target kind (postgres|elasticsearch|opensearch|clickhouse|kafka|mongodb|redis|http)")
 	update.Flags().StringVar(&dsn, "dsn", "", "Target DSN")
-	update.Flags().StringVar(&database, "database", "", "Default database name for postgres targets")
+	update.Flags().StringVar(&database, "database", "", "Default database name for postgres/clickhouse/mongodb targets")
 	update.Flags().StringVar(&schema, "schema", "", "Schema (postgres)")
 	update.Flags().StringVar(&host, "host", "", "Host used to build DSN when --dsn is omitted")
 	update.Flags().IntVar(&port, "port", 0, "Port used to build DSN when --dsn is omitted")
 	update.Flags().StringVar(&user, "user", "", "Username used to build DSN when --dsn is omitted")
 	update.Flags().StringVar(&password, "password", "", "Password used to build DSN when --dsn is omitted")
 	update.Flags().StringVar(&sslmode, "sslmode", "disable", "Postgres sslmode used to build DSN")
-	update.Flags().StringVar(&scheme, "scheme", "", "Scheme used to build DSN (http/https for elasticsearch)")
+	update.Flags().StringVar(&scheme, "scheme", "", "Scheme used to build DSN (http/https for elasticsearch/clickhouse)")
+	update.Flags().StringArrayVar(&options, "option", nil, "Target option key=value (repeatable)")
 	_ = update.MarkFlagRequired("name")
 	_ = update.MarkFlagRequired("kind")
 
@@ -319,14 +509,19 @@ func runCmd() *cobra.Command {
 		targetKind   string
 		targetDB     string
 		targetSchema string
+		targetOpts   []string
 
 		mode    string
+		profile string
 		scale   float64
 		ecList  []string
+		prList  []string
 		esList  []string
 		include []string
 		exclude []string
 
+		maxRejected int64
+
 		seed     int64
 		hasSeed  bool
 		hasScale bool
@@ -349,7 +544,7 @@ func runCmd() *cobra.Command {
 			targetRepo := targets.NewPostgresRepository(runRepo.DB())
 			svc := app.NewRunService(scRepo, targetRepo, runRepo, registry.DefaultGeneratorRegistry(), logger, batchSize)
 
-			req := &domain.RunRequest{Mode: mode}
+			req := &domain.RunRequest{Mode: mode, Profile: profile}
 
 			if scenario == "" {
 				return fmt.Errorf("--scenario is required")
@@ -368,11 +563,16 @@ func runCmd() *cobra.Command {
 				if targetKind == "" {
 					return fmt.Errorf("--target-kind required with --target")
 				}
+				opts, err := parseTargetOptions(targetOpts)
+				if err != nil {
+					return err
+				}
 				req.Target = &domain.TargetConfig{
-					Name:   "inline-target",
-					Kind:   targetKind,
-					DSN:    targetDSN,
-					Schema: targetSchema,
+					Name:    "inline-target",
+					Kind:    targetKind,
+					DSN:     targetDSN,
+					Schema:  targetSchema,
+					Options: opts,
 				}
 			} else if targetID != "" {
 				req.TargetID = targetID
@@ -418,12 +618,23 @@ func runCmd() *cobra.Command {
 					req.EntityScales[parts[0]] = f
 				}
 			}
+			if len(prList) > 0 {
+				req.Params = map[string]interface{}{}
+				for _, kv := range prList {
+					parts := strings.SplitN(kv, "=", 2)
+					if len(parts) != 2 || parts[0] == "" {
+						return fmt.Errorf("invalid --param: %s", kv)
+					}
+					req.Params[parts[0]] = parts[1]
+				}
+			}
 			if len(include) > 0 {
 				req.IncludeEntities = append([]string(nil), include...)
 			}
 			if len(exclude) > 0 {
 				req.ExcludeEntities = append([]string(nil), exclude...)
 			}
+			req.MaxRejectedDocs = maxRejected
 
 			if doPlan {
 				plan, err := svc.PlanRun(req)
@@ -470,16 +681,20 @@ func runCmd() *cobra.Command {
 
 	start.Flags().StringVar(&targetID, "target-id", "", "Target ID")
 	start.Flags().StringVar(&targetDSN, "target", "", "Inline target DSN (not stored)")
-	start.Flags().StringVar(&targetKind, "target-kind", "", "Inline target kind (postgres|elasticsearch)")
+	start.Flags().StringVar(&targetKind, "target-kind", "", "Inline target kind (postgres|elasticsearch|opensearch|clickhouse|kafka|mongodb|redis|http)")
 	start.Flags().StringVar(&targetDB, "target-db", "", "Target database override for this run (postgres)")
 	start.Flags().StringVar(&targetSchema, "target-schema", "", "Inline target schema (postgres)")
+	start.Flags().StringArrayVar(&targetOpts, "target-option", nil, "Inline target option key=value (repeatable)")
 
 	start.Flags().StringVar(&mode, "mode", "", "Mode (create|truncate|append)")
+	start.Flags().StringVar(&profile, "profile", "", "Scenario profile to apply before the flags below")
 	start.Flags().Float64Var(&scale, "scale", 1.0, "Scale factor")
 	start.Flags().StringSliceVar(&ecList, "entity-count", nil, "Override entity count entity=N (repeatable)")
 	start.Flags().StringSliceVar(&esList, "entity-scale", nil, "Per-entity scale entity=F (repeatable)")
+	start.Flags().StringArrayVar(&prList, "param", nil, "Scenario parameter name=value (repeatable)")
 	start.Flags().StringSliceVar(&include, "include-entity", nil, "Include only these entities (repeatable)")
 	start.Flags().StringSliceVar(&exclude, "exclude-entity", nil, "Exclude these entities (repeatable)")
+	start.Flags().Int64Var(&maxRejected, "max-rejected-docs", 0, "Rows a target may reject individually before the run fails")
 	start.Flags().BoolVar(&doPlan, "plan", false, "Plan only (do not execute)")
 	start.Flags().BoolVar(&wait, "wait", true, "Wait for terminal run status before returning")
 
@@ -571,7 +786,7 @@ func buildDSNFromParts(kind, host string, port int, user, password, database, ss
 		}
 		return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
 			url.QueryEscape(user), url.QueryEscape(password), host, port, url.PathEscape(database), url.QueryEscape(sslmode)), nil
-	case "elasticsearch":
+	case "elasticsearch", "opensearch":
 		if host == "" {
 			host = "localhost"
 		}
@@ -590,7 +805,95 @@ func buildDSNFromParts(kind, host string, port int, user, password, database, ss
 			auth += "@"
 		}
 		return fmt.Sprintf("%s://%s%s:%d", scheme, auth, host, port), nil
+	case "clickhouse":
+		if host == "" {
+			host = "localhost"
+		}
+		if port == 0 {
+			port = 8123
+		}
+		if scheme == "" {
+			scheme = "http"
+		}
+		auth := ""
+		if user != "" {
+			auth = url.QueryEscape(user)
+			if password != "" {
+				auth += ":" + url.QueryEscape(password)
+			}
+			auth += "@"
+		}
+		return fmt.Sprintf("%s://%s%s:%d", scheme, auth, host, port), nil
+	case "kafka":
+		if host == "" {
+			host = "localhost"
+		}
+		if port == 0 {
+			port = 9092
+		}
+		auth := ""
+		if user != "" {
+			auth = url.QueryEscape(user) + ":" + url.QueryEscape(password) + "@"
+		}
+		return fmt.Sprintf("kafka://%s%s:%d", auth, host, port), nil
+	case "mongodb":
+		if host == "" {
+			host = "localhost"
+		}
+		if port == 0 {
+			port = 27017
+		}
+		auth := ""
+		if user != "" {
+			auth = url.QueryEscape(user) + ":" + url.QueryEscape(password) + "@"
+		}
+		return fmt.Sprintf("mongodb://%s%s:%d/%s", auth, host, port, url.PathEscape(database)), nil
+	case "redis":
+		if host == "" {
+			host = "localhost"
+		}
+		if port == 0 {
+			port = 6379
+		}
+		if scheme == "" {
+			scheme = "redis"
+		}
+		auth := ""
+		if user != "" || password != "" {
+			auth = url.QueryEscape(user) + ":" + url.QueryEscape(password) + "@"
+		}
+		return fmt.Sprintf("%s://%s%s:%d", scheme, auth, host, port), nil
+	case "http":
+		if host == "" {
+			host = "localhost"
+		}
+		if scheme == "" {
+			scheme = "http"
+		}
+		auth := ""
+		if user != "" {
+			auth = url.QueryEscape(user) + ":" + url.QueryEscape(password) + "@"
+		}
+		if port == 0 {
+			return fmt.Sprintf("%s://%s%s", scheme, auth, host), nil
+		}
+		return fmt.Sprintf("%s://%s%s:%d", scheme, auth, host, port), nil
 	default:
 		return "", fmt.Errorf("unsupported target kind for DSN builder: %s", kind)
 	}
 }
+
+func parseTargetOptions(kvs []string) (map[string]string, error) {
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid --option: %s", kv)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
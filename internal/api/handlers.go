@@ -95,6 +95,9 @@ func (h *Handler) UpdateTarget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	t.ID = id
+	if existing, err := h.targetRepo.Get(id); err == nil {
+		targets.RestoreRedactedOptions(t.Options, existing.Options)
+	}
 	if err := h.runService.Validator().ValidateTarget(&t); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -1,11 +1,14 @@
 package app
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/importer"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/runs"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/scenarios"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/targets"
@@ -226,6 +229,60 @@ func TestPlanRun_IncludeExcludeEntities(t *testing.T) {
 	}
 }
 
+func TestPlanRun_AppliesProfileBeforeRequestOverrides(t *testing.T) {
+	svc, targetRepo := newIntegrationService(t)
+	tgt := &domain.TargetConfig{Name: "pg4", Kind: "postgres", DSN: testTargetPostgresDSN(t), Schema: "public"}
+	if err := targetRepo.Create(tgt); err != nil {
+		t.Fatal(err)
+	}
+
+	idCol := []domain.Column{
+		{Name: "id", Type: domain.ColumnTypeInt, Generator: domain.GeneratorSpec{Type: "uniform_int", Params: map[string]interface{}{"min": 1, "max": 999999}}},
+	}
+	profileScale := 0.1
+	req := &domain.RunRequest{
+		Scenario: &domain.Scenario{
+			ID:      "inline4",
+			Name:    "s4",
+			Version: "1",
+			Entities: []domain.Entity{
+				{Name: "users", TargetTable: "users", Rows: 100, Columns: idCol},
+				{Name: "events", TargetTable: "events", Rows: 1000, Columns: idCol},
+				{Name: "audit", TargetTable: "audit", Rows: 50, Columns: idCol},
+			},
+			Profiles: map[string]domain.ScenarioProfile{
+				"ci": {
+					Scale:           &profileScale,
+					EntityCounts:    map[string]int64{"users": 7, "events": 20},
+					ExcludeEntities: []string{"audit"},
+				},
+			},
+		},
+		TargetID:     tgt.ID,
+		Mode:         "create",
+		Profile:      "ci",
+		EntityCounts: map[string]int64{"events": 30},
+	}
+	plan, err := svc.PlanRun(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Profile != "ci" || plan.Scale != profileScale {
+		t.Fatalf("expected ci profile at scale %v, got %q at %v", profileScale, plan.Profile, plan.Scale)
+	}
+	if plan.ResolvedCounts["users"] != 7 || plan.ResolvedCounts["events"] != 30 {
+		t.Fatalf("request counts should merge over profile counts, got %#v", plan.ResolvedCounts)
+	}
+	if _, ok := plan.ResolvedCounts["audit"]; ok {
+		t.Fatalf("profile exclude not applied: %#v", plan.ResolvedCounts)
+	}
+
+	req.Profile = "perf"
+	if _, err := svc.PlanRun(req); err == nil {
+		t.Fatal("expected unknown profile error")
+	}
+}
+
 func TestStartRun_CompletesSuccess_Postgres(t *testing.T) {
 	svc, _ := newIntegrationService(t)
 
@@ -281,3 +338,176 @@ func TestStartRun_CompletesSuccess_Postgres(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 	}
 }
+
+func TestInferScenario_Postgres(t *testing.T) {
+	dsn := testTargetPostgresDSN(t)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`DROP SCHEMA IF EXISTS sdgen_infer CASCADE`,
+		`DROP SCHEMA IF EXISTS sdgen_infer_other CASCADE`,
+		`CREATE SCHEMA sdgen_infer`,
+		`CREATE SCHEMA sdgen_infer_other`,
+		`CREATE TYPE sdgen_infer.tier AS ENUM ('free', 'pro')`,
+		`CREATE TYPE sdgen_infer_other.tier AS ENUM ('gold')`,
+		`CREATE TABLE sdgen_infer.accounts (account_id uuid PRIMARY KEY, tier sdgen_infer.tier NOT NULL, email text UNIQUE)`,
+		`CREATE TABLE sdgen_infer.events (
+			event_id bigint PRIMARY KEY,
+			account_id uuid NOT NULL REFERENCES sdgen_infer.accounts(account_id),
+			kind varchar(20) CHECK (kind IN ('login', 'logout')),
+			created_at timestamptz NOT NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer db.Exec(`DROP SCHEMA IF EXISTS sdgen_infer CASCADE`)
+	defer db.Exec(`DROP SCHEMA IF EXISTS sdgen_infer_other CASCADE`)
+
+	sc, _, err := InferScenario(&domain.TargetConfig{Name: "pg", Kind: "postgres", DSN: dsn}, "sdgen_infer", importer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.ID != "sdgen_infer" || len(sc.Entities) != 2 {
+		t.Fatalf("unexpected scenario %+v", sc)
+	}
+	gens := map[string]string{}
+	for _, e := range sc.Entities {
+		for _, c := range e.Columns {
+			gens[e.Name+"."+c.Name] = c.Generator.Type
+			if e.Name == "accounts" && c.Name == "tier" {
+				// the same-named enum of another schema must not be merged in
+				if got := fmt.Sprint(c.Generator.Params["values"]); got != "[free pro]" {
+					t.Errorf("accounts.tier: expected values [free pro], got %s", got)
+				}
+			}
+		}
+	}
+	want := map[string]string{
+		"accounts.account_id": "uuid4",
+		"accounts.tier":       "choice",
+		"accounts.email":      "uuid4",
+		"events.account_id":   "fk",
+		"events.kind":         "choice",
+		"events.created_at":   "time_series",
+	}
+	for key, typ := range want {
+		if gens[key] != typ {
+			t.Errorf("%s: expected %s, got %s", key, typ, gens[key])
+		}
+	}
+}
+
+func TestProfileScenario_Postgres(t *testing.T) {
+	dsn := testTargetPostgresDSN(t)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`DROP SCHEMA IF EXISTS sdgen_profile CASCADE`,
+		`CREATE SCHEMA sdgen_profile`,
+		`CREATE TABLE sdgen_profile.tickets (id bigint PRIMARY KEY, priority text, score double precision, note text)`,
+		`INSERT INTO sdgen_profile.tickets
+			SELECT g, CASE WHEN g % 4 = 0 THEN NULL WHEN g % 2 = 0 THEN 'high' ELSE 'low' END, g::float, md5(g::text)
+			FROM generate_series(1, 200) g`,
+		`ANALYZE sdgen_profile.tickets`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer db.Exec(`DROP SCHEMA IF EXISTS sdgen_profile CASCADE`)
+
+	sc, _, err := ProfileScenario(&domain.TargetConfig{Name: "pg", Kind: "postgres", DSN: dsn}, "sdgen_profile", nil,
+		importer.Options{}, importer.ProfileOptions{MaxCardinality: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := map[string]domain.GeneratorSpec{}
+	for _, c := range sc.Entities[0].Columns {
+		cols[c.Name] = c.Generator
+	}
+	if p := cols["priority"]; p.Type != "choice" || p.Params["null_rate"] != 0.25 {
+		t.Fatalf("unexpected priority generator %+v", p)
+	}
+	if s := cols["score"]; s.Type != "uniform_float" || s.Params["min"] != 1.0 || s.Params["max"] != 200.0 {
+		t.Fatalf("unexpected score generator %+v", s)
+	}
+	for _, v := range cols["note"].Params["values"].([]interface{}) {
+		if len(v.(string)) == 32 {
+			t.Fatalf("expected high-cardinality note values not to be copied, got %v", cols["note"])
+		}
+	}
+}
+
+func TestStartRun_TextSequenceContinuesPastExistingMax_Postgres(t *testing.T) {
+	svc, _ := newIntegrationService(t)
+	dsn := testTargetPostgresDSN(t)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`DROP TABLE IF EXISTS public.invoices`,
+		`CREATE TABLE public.invoices (code varchar(255) NOT NULL)`,
+		`INSERT INTO public.invoices VALUES ('INV-8'), ('INV-9'), ('INV-10')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer db.Exec(`DROP TABLE IF EXISTS public.invoices`)
+
+	req := &domain.RunRequest{
+		Scenario: &domain.Scenario{
+			ID:      "inline-seq",
+			Name:    "seq-scenario",
+			Version: "1",
+			Entities: []domain.Entity{{
+				Name:        "invoices",
+				TargetTable: "invoices",
+				Rows:        2,
+				Columns: []domain.Column{{Name: "code", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{
+					Type: "sequence", Params: map[string]interface{}{"format": "INV-%d", "offset_from": "existing_max"},
+				}}},
+			}},
+		},
+		Target: &domain.TargetConfig{Name: "inline-pg", Kind: "postgres", DSN: dsn, Schema: "public"},
+		Mode:   "append",
+	}
+	run, err := svc.StartRun(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(8 * time.Second)
+	for {
+		cur, err := svc.GetRun(run.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cur.Status == domain.RunStatusSuccess {
+			break
+		}
+		if cur.Status == domain.RunStatusFailed {
+			t.Fatalf("run failed: %s", cur.Error)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not complete by deadline, last status=%s", cur.Status)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM public.invoices WHERE code IN ('INV-11', 'INV-12')`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected INV-11 and INV-12 after INV-10, got %d of them", n)
+	}
+}
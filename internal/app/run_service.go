@@ -14,8 +14,6 @@ import (
 	"github.com/mmrzaf/sdgen/internal/infra/repos/runs"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/scenarios"
 	"github.com/mmrzaf/sdgen/internal/infra/repos/targets"
-	esTarget "github.com/mmrzaf/sdgen/internal/infra/targets/elasticsearch"
-	pgTarget "github.com/mmrzaf/sdgen/internal/infra/targets/postgres"
 	"github.com/mmrzaf/sdgen/internal/logging"
 	"github.com/mmrzaf/sdgen/internal/registry"
 	"github.com/mmrzaf/sdgen/internal/validation"
@@ -63,9 +61,13 @@ func (s *RunService) StartRun(req *domain.RunRequest) (*domain.Run, error) {
 		"scenario_id":         req.ScenarioID,
 		"target_id":           req.TargetID,
 		"mode":                req.Mode,
+		"profile":             req.Profile,
 		"has_inline_scenario": req.Scenario != nil,
 		"has_inline_target":   req.Target != nil,
 	})
+	if err := s.loadInlineDictionaries(req); err != nil {
+		return nil, err
+	}
 	if err := s.validator.ValidateRunRequest(req); err != nil {
 		s.logger.Warnw("start_run.validation_failed", map[string]any{"error": err.Error()})
 		return nil, err
@@ -87,6 +89,9 @@ func (s *RunService) StartRun(req *domain.RunRequest) (*domain.Run, error) {
 	if err := s.validator.ValidateTarget(target); err != nil {
 		return nil, err
 	}
+	if err := s.validator.ValidateTargetMode(target, req.Mode); err != nil {
+		return nil, err
+	}
 
 	seed := s.resolveSeed(req, scenario)
 	mode := req.Mode
@@ -96,7 +101,7 @@ func (s *RunService) StartRun(req *domain.RunRequest) (*domain.Run, error) {
 		return nil, err
 	}
 
-	cfgHash, err := hashing.HashRunConfig(resolvedScenario, target, mode, plan.Scale, plan.ResolvedCounts, seed)
+	cfgHash, err := hashing.HashRunConfig(resolvedScenario, target, mode, plan.Scale, plan.ResolvedCounts, seed, plan.Params)
 	if err != nil {
 		return nil, err
 	}
@@ -139,11 +144,14 @@ func (s *RunService) StartRun(req *domain.RunRequest) (*domain.Run, error) {
 		"warning_count":  len(plan.Warnings),
 	})
 
-	go s.executeRun(run, resolvedScenario, target, mode)
+	go s.executeRun(run, resolvedScenario, target, mode, req.MaxRejectedDocs)
 	return run, nil
 }
 
 func (s *RunService) PlanRun(req *domain.RunRequest) (*domain.RunPlan, error) {
+	if err := s.loadInlineDictionaries(req); err != nil {
+		return nil, err
+	}
 	if err := s.validator.ValidateRunRequest(req); err != nil {
 		s.logger.Warnw("plan_run.validation_failed", map[string]any{"error": err.Error()})
 		return nil, err
@@ -165,6 +173,9 @@ func (s *RunService) PlanRun(req *domain.RunRequest) (*domain.RunPlan, error) {
 	if err := s.validator.ValidateTarget(target); err != nil {
 		return nil, err
 	}
+	if err := s.validator.ValidateTargetMode(target, req.Mode); err != nil {
+		return nil, err
+	}
 
 	plan, _, err := s.buildPlanAndResolvedScenario(scenario, req)
 	if err != nil {
@@ -232,11 +243,28 @@ func (s *RunService) ListRunLogs(id string, limit int) ([]*domain.RunLog, error)
 	return s.runRepo.ListRunLogs(id, limit)
 }
 
+// loadInlineDictionaries reads the dictionary files of an inline scenario from
+// the scenarios directory, as loadScenario does for stored ones, so that the
+// request validates.
+func (s *RunService) loadInlineDictionaries(req *domain.RunRequest) error {
+	if req.Scenario == nil {
+		return nil
+	}
+	return s.scenarioRepo.LoadDictionaries(req.Scenario)
+}
+
 func (s *RunService) loadScenario(req *domain.RunRequest) (*domain.Scenario, error) {
 	if req.Scenario != nil {
 		return req.Scenario, nil
 	}
-	return s.scenarioRepo.Get(req.ScenarioID)
+	scenario, err := s.scenarioRepo.Get(req.ScenarioID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.scenarioRepo.LoadDictionaries(scenario); err != nil {
+		return nil, err
+	}
+	return scenario, nil
 }
 
 func (s *RunService) loadTarget(req *domain.RunRequest) (*domain.TargetConfig, error) {
@@ -256,7 +284,55 @@ func (s *RunService) resolveSeed(req *domain.RunRequest, scenario *domain.Scenar
 	return time.Now().UnixNano()
 }
 
+// applyProfile returns the request with the named scenario profile folded in.
+// The request's own settings win: its scale and include list replace the
+// profile's, its entity_counts and entity_scales are merged over the
+// profile's, and its excludes are added to the profile's.
+func applyProfile(scenario *domain.Scenario, req *domain.RunRequest) (*domain.RunRequest, error) {
+	if req.Profile == "" {
+		return req, nil
+	}
+	profile, ok := scenario.Profiles[req.Profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %s", req.Profile)
+	}
+	out := *req
+	if out.Scale == nil {
+		out.Scale = profile.Scale
+	}
+	if len(profile.EntityCounts) > 0 {
+		out.EntityCounts = make(map[string]int64, len(profile.EntityCounts)+len(req.EntityCounts))
+		for k, v := range profile.EntityCounts {
+			out.EntityCounts[k] = v
+		}
+		for k, v := range req.EntityCounts {
+			out.EntityCounts[k] = v
+		}
+	}
+	if len(profile.EntityScales) > 0 {
+		out.EntityScales = make(map[string]float64, len(profile.EntityScales)+len(req.EntityScales))
+		for k, v := range profile.EntityScales {
+			out.EntityScales[k] = v
+		}
+		for k, v := range req.EntityScales {
+			out.EntityScales[k] = v
+		}
+	}
+	if len(out.IncludeEntities) == 0 {
+		out.IncludeEntities = profile.IncludeEntities
+	}
+	if len(profile.ExcludeEntities) > 0 {
+		out.ExcludeEntities = append(append([]string(nil), profile.ExcludeEntities...), req.ExcludeEntities...)
+	}
+	return &out, nil
+}
+
 func (s *RunService) buildPlanAndResolvedScenario(scenario *domain.Scenario, req *domain.RunRequest) (*domain.RunPlan, *domain.Scenario, error) {
+	req, err := applyProfile(scenario, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	scale := 1.0
 	if req.Scale != nil {
 		scale = *req.Scale
@@ -272,11 +348,18 @@ func (s *RunService) buildPlanAndResolvedScenario(scenario *domain.Scenario, req
 		excludeSet[name] = struct{}{}
 	}
 
-	// Copy scenario so we never mutate the file-backed source.
-	resolved := *scenario
+	// Substitute parameters into a copy so we never mutate the file-backed source.
+	withParams, params, err := validation.ApplyParameters(scenario, req.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved := *withParams
+	// Profiles may name entities filtered out below; the resolved scenario
+	// no longer needs them.
+	resolved.Profiles = nil
 	resolved.Entities = make([]domain.Entity, 0, len(scenario.Entities))
 	present := make(map[string]struct{}, len(scenario.Entities))
-	for _, entity := range scenario.Entities {
+	for _, entity := range withParams.Entities {
 		present[entity.Name] = struct{}{}
 		if len(includeSet) > 0 {
 			if _, ok := includeSet[entity.Name]; !ok {
@@ -362,12 +445,14 @@ func (s *RunService) buildPlanAndResolvedScenario(scenario *domain.Scenario, req
 		ResolvedCounts: resolvedCounts,
 		ExecutionOrder: executionOrder,
 		Warnings:       warnings,
+		Params:         params,
+		Profile:        req.Profile,
 	}
 
 	return plan, &resolved, nil
 }
 
-func (s *RunService) executeRun(run *domain.Run, scenario *domain.Scenario, targetCfg *domain.TargetConfig, mode string) {
+func (s *RunService) executeRun(run *domain.Run, scenario *domain.Scenario, targetCfg *domain.TargetConfig, mode string, maxRejected int64) {
 	started := time.Now()
 	s.logger.Infow("run_execution.started", map[string]any{
 		"run_id":      run.ID,
@@ -376,22 +461,23 @@ func (s *RunService) executeRun(run *domain.Run, scenario *domain.Scenario, targ
 		"entities":    len(scenario.Entities),
 	})
 	// Build concrete exec.Target
-	var tgt exec.Target
-	switch targetCfg.Kind {
-	case "postgres":
-		schema := targetCfg.Schema
-		if schema == "" {
-			schema = "public"
-		}
-		tgt = pgTarget.NewPostgresTarget(targetCfg.DSN, schema)
-	case "elasticsearch":
-		tgt = esTarget.NewElasticsearchTarget(targetCfg.DSN)
-	default:
-		_ = s.runRepo.UpdateStatus(run.ID, domain.RunStatusFailed, fmt.Sprintf("unsupported target kind: %s", targetCfg.Kind), nil)
-		s.logger.Errorw("run_execution.failed", map[string]any{"run_id": run.ID, "error": fmt.Sprintf("unsupported target kind: %s", targetCfg.Kind)})
+	tgt, _, err := buildTarget(targetCfg)
+	if err != nil {
+		_ = s.runRepo.UpdateStatus(run.ID, domain.RunStatusFailed, err.Error(), nil)
+		s.logger.Errorw("run_execution.failed", map[string]any{"run_id": run.ID, "error": err.Error()})
 		return
 	}
 
+	runLog := func(level, message string) {
+		_ = s.runRepo.AppendRunLog(run.ID, level, message)
+	}
+	if pw, ok := tgt.(exec.PartialWriter); ok {
+		pw.SetRejectPolicy(maxRejected, runLog)
+	}
+	if rl, ok := tgt.(exec.RunLogger); ok {
+		rl.SetRunLog(runLog)
+	}
+
 	executor := exec.NewExecutor(s.genRegistry, s.batchSize)
 
 	rowsGenerated := int64(0)
@@ -421,12 +507,16 @@ func (s *RunService) executeRun(run *domain.Run, scenario *domain.Scenario, targ
 
 	_ = s.runRepo.UpdateStatus(run.ID, domain.RunStatusSuccess, "", stats)
 	_ = s.runRepo.UpdateProgress(run.ID, run.ProgressRowsTotal, run.ProgressRowsTotal, run.ProgressEntitiesTotal, run.ProgressEntitiesTotal, "")
+	if stats.RejectedRows > 0 {
+		_ = s.runRepo.AppendRunLog(run.ID, "warn", fmt.Sprintf("run completed with rejected rows: rejected_rows=%d max_rejected_docs=%d", stats.RejectedRows, maxRejected))
+	}
 	_ = s.runRepo.AppendRunLog(run.ID, "info", fmt.Sprintf("run completed successfully: total_rows=%d", stats.TotalRows))
 	s.logger.Infow("run_execution.completed", map[string]any{
-		"run_id":      run.ID,
-		"total_rows":  stats.TotalRows,
-		"entities":    stats.EntitiesGenerated,
-		"duration_ms": time.Since(started).Milliseconds(),
+		"run_id":        run.ID,
+		"total_rows":    stats.TotalRows,
+		"rejected_rows": stats.RejectedRows,
+		"entities":      stats.EntitiesGenerated,
+		"duration_ms":   time.Since(started).Milliseconds(),
 	})
 }
 
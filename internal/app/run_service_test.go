@@ -0,0 +1,43 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/infra/repos/scenarios"
+	"github.com/mmrzaf/sdgen/internal/logging"
+	"github.com/mmrzaf/sdgen/internal/registry"
+)
+
+func TestPlanRun_LoadsInlineScenarioDictionaries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cities.csv"), []byte("city\nOslo\nLima\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewRunService(scenarios.NewFileRepository(dir), nil, nil, registry.DefaultGeneratorRegistry(), logging.NewLogger("error"), 100)
+	req := &domain.RunRequest{
+		Scenario: &domain.Scenario{
+			ID:   "inline",
+			Name: "inline",
+			Entities: []domain.Entity{{
+				Name:        "people",
+				TargetTable: "people",
+				Rows:        5,
+				Columns: []domain.Column{
+					{Name: "city", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{Type: "dictionary", Params: map[string]interface{}{"file": "cities.csv"}}},
+				},
+			}},
+		},
+		Target: &domain.TargetConfig{Name: "pg", Kind: "postgres", DSN: "postgres://u:p@localhost:5432/db"},
+		Mode:   "create",
+	}
+	plan, err := svc.PlanRun(req)
+	if err != nil {
+		t.Fatalf("expected the inline scenario's dictionary to be loaded, got %v", err)
+	}
+	if plan.ResolvedCounts["people"] != 5 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/importer"
+	"github.com/mmrzaf/sdgen/internal/registry"
+	"github.com/mmrzaf/sdgen/internal/validation"
+)
+
+// InferScenario reverse-engineers a scenario from the live schema of a
+// postgres target. The schema argument overrides the target's schema. The
+// returned warnings list tables, columns and keys that were left out.
+func InferScenario(t *domain.TargetConfig, schema string, opts importer.Options) (*domain.Scenario, []string, error) {
+	return scenarioFromPostgres(t, schema, nil, opts, nil)
+}
+
+// ProfileScenario infers the schema like InferScenario and additionally
+// samples the listed tables (all when empty) to fit generator params.
+func ProfileScenario(t *domain.TargetConfig, schema string, tables []string, opts importer.Options, profile importer.ProfileOptions) (*domain.Scenario, []string, error) {
+	return scenarioFromPostgres(t, schema, tables, opts, &profile)
+}
+
+func scenarioFromPostgres(t *domain.TargetConfig, schema string, only []string, opts importer.Options, profile *importer.ProfileOptions) (*domain.Scenario, []string, error) {
+	if t == nil {
+		return nil, nil, fmt.Errorf("target is required")
+	}
+	if t.Kind != "postgres" {
+		return nil, nil, fmt.Errorf("scenario inference is not supported for %s targets", t.Kind)
+	}
+	resolved := resolveTargetForRun(t, "")
+	if schema == "" {
+		schema = resolved.Schema
+	}
+	if schema == "" {
+		schema = "public"
+	}
+	tables, err := importer.InspectPostgres(resolved.DSN, schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inspect schema: %w", err)
+	}
+	if len(only) > 0 {
+		tables, err = selectTables(tables, only)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if profile != nil {
+		if err := importer.ProfilePostgres(resolved.DSN, schema, tables, *profile); err != nil {
+			return nil, nil, fmt.Errorf("profile schema: %w", err)
+		}
+	}
+	if opts.ID == "" {
+		opts.ID = schema
+	}
+	if opts.Description == "" {
+		verb := "Inferred"
+		if profile != nil {
+			verb = "Profiled"
+		}
+		opts.Description = fmt.Sprintf("%s from %s target %s", verb, t.Kind, t.Name)
+	}
+	return buildImportedScenario(tables, opts, nil)
+}
+
+// ImportDDL builds a scenario from CREATE TABLE statements in a SQL script.
+// dialect is postgres, mysql or empty to detect it from the script.
+func ImportDDL(script, dialect string, opts importer.Options) (*domain.Scenario, []string, error) {
+	tables, warnings, err := importer.ParseDDL(script, dialect)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("parse DDL: %w", err)
+	}
+	if opts.ID == "" {
+		return nil, warnings, fmt.Errorf("scenario id is required")
+	}
+	if opts.Description == "" {
+		opts.Description = "Imported from SQL DDL"
+	}
+	return buildImportedScenario(tables, opts, warnings)
+}
+
+// ImportOpenAPI builds a scenario from the object schemas of an OpenAPI or
+// JSON Schema document. components selects schemas by name (all when empty).
+func ImportOpenAPI(doc []byte, components []string, opts importer.Options) (*domain.Scenario, []string, error) {
+	tables, warnings, err := importer.ParseOpenAPI(doc, components)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	if opts.ID == "" {
+		return nil, warnings, fmt.Errorf("scenario id is required")
+	}
+	if opts.Description == "" {
+		opts.Description = "Imported from OpenAPI component schemas"
+	}
+	return buildImportedScenario(tables, opts, warnings)
+}
+
+// buildImportedScenario maps tables to a scenario and validates it so
+// importers never emit a scenario the repository would reject.
+func buildImportedScenario(tables []importer.Table, opts importer.Options, warnings []string) (*domain.Scenario, []string, error) {
+	sc, more := importer.BuildScenario(tables, opts)
+	warnings = append(warnings, more...)
+	if err := validation.NewValidator(registry.DefaultGeneratorRegistry()).ValidateScenario(sc); err != nil {
+		return nil, warnings, fmt.Errorf("generated scenario is invalid: %w", err)
+	}
+	return sc, warnings, nil
+}
+
+func selectTables(tables []importer.Table, names []string) ([]importer.Table, error) {
+	byName := make(map[string]importer.Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	out := make([]importer.Table, 0, len(names))
+	for _, name := range names {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("table not found: %s", name)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
@@ -9,8 +9,13 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/mmrzaf/sdgen/internal/domain"
 	"github.com/mmrzaf/sdgen/internal/exec"
+	chTarget "github.com/mmrzaf/sdgen/internal/infra/targets/clickhouse"
 	esTarget "github.com/mmrzaf/sdgen/internal/infra/targets/elasticsearch"
+	httpTarget "github.com/mmrzaf/sdgen/internal/infra/targets/httpsink"
+	kafkaTarget "github.com/mmrzaf/sdgen/internal/infra/targets/kafka"
+	mongoTarget "github.com/mmrzaf/sdgen/internal/infra/targets/mongodb"
 	pgTarget "github.com/mmrzaf/sdgen/internal/infra/targets/postgres"
+	redisTarget "github.com/mmrzaf/sdgen/internal/infra/targets/redis"
 	"github.com/mmrzaf/sdgen/internal/validation"
 )
 
@@ -31,7 +36,7 @@ func CheckTarget(t *domain.TargetConfig) (*domain.TargetCheck, error) {
 
 	start := time.Now()
 	effective := resolveTargetForRun(t, "")
-	tgt, verFn, err := buildCheckTarget(effective)
+	tgt, verFn, err := buildTarget(effective)
 	if err != nil {
 		check.OK = false
 		check.Error = "unsupported target kind"
@@ -52,11 +57,17 @@ func CheckTarget(t *domain.TargetConfig) (*domain.TargetCheck, error) {
 			check.ServerVer = ver
 		}
 	}
-	check.Capabilities = probeCapabilities(tgt)
+	if c, ok := tgt.(interface {
+		Capabilities() domain.TargetCapabilities
+	}); ok {
+		check.Capabilities = c.Capabilities()
+	} else {
+		check.Capabilities = probeCapabilities(tgt)
+	}
 	return check, nil
 }
 
-func buildCheckTarget(t *domain.TargetConfig) (exec.Target, func() (string, error), error) {
+func buildTarget(t *domain.TargetConfig) (exec.Target, func() (string, error), error) {
 	switch t.Kind {
 	case "postgres":
 		schema := t.Schema
@@ -66,9 +77,29 @@ func buildCheckTarget(t *domain.TargetConfig) (exec.Target, func() (string, erro
 		return pgTarget.NewPostgresTarget(t.DSN, schema), func() (string, error) {
 			return queryServerVersion("postgres", t.DSN, "SHOW server_version")
 		}, nil
-	case "elasticsearch":
-		return esTarget.NewElasticsearchTarget(t.DSN), func() (string, error) {
-			return esTarget.GetServerVersion(t.DSN)
+	case "elasticsearch", "opensearch":
+		return esTarget.NewElasticsearchTarget(t.DSN, t.Options), func() (string, error) {
+			return esTarget.GetServerVersion(t.DSN, t.Options)
+		}, nil
+	case "clickhouse":
+		return chTarget.NewClickHouseTarget(t.DSN, t.Database, t.Options), func() (string, error) {
+			return chTarget.GetServerVersion(t.DSN)
+		}, nil
+	case "kafka":
+		return kafkaTarget.NewKafkaTarget(t.DSN, t.Options), func() (string, error) {
+			return kafkaTarget.GetServerVersion(t.DSN, t.Options)
+		}, nil
+	case "mongodb":
+		return mongoTarget.NewMongoTarget(t.DSN, t.Database, t.Options), func() (string, error) {
+			return mongoTarget.GetServerVersion(t.DSN, t.Database)
+		}, nil
+	case "redis":
+		return redisTarget.NewRedisTarget(t.DSN, t.Options), func() (string, error) {
+			return redisTarget.GetServerVersion(t.DSN)
+		}, nil
+	case "http":
+		return httpTarget.NewHTTPTarget(t.DSN, t.Options), func() (string, error) {
+			return httpTarget.GetServerVersion(t.DSN, t.Options)
 		}, nil
 	default:
 		return nil, nil, fmt.Errorf("unsupported target kind: %s", t.Kind)
@@ -12,6 +12,23 @@ type Scenario struct {
 	Description string   `json:"description" yaml:"description"`
 	Seed        *int64   `json:"seed,omitempty" yaml:"seed,omitempty"`
 	Entities    []Entity `json:"entities" yaml:"entities"`
+
+	// Parameters maps names to typed defaults referenced as ${name} in
+	// generator params; runs may override them via RunRequest.Params.
+	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+
+	// Profiles are named size presets selected with RunRequest.Profile.
+	Profiles map[string]ScenarioProfile `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// ScenarioProfile holds run-size settings applied before a run request's own
+// overrides.
+type ScenarioProfile struct {
+	Scale           *float64           `json:"scale,omitempty" yaml:"scale,omitempty"`
+	EntityCounts    map[string]int64   `json:"entity_counts,omitempty" yaml:"entity_counts,omitempty"`
+	EntityScales    map[string]float64 `json:"entity_scales,omitempty" yaml:"entity_scales,omitempty"`
+	IncludeEntities []string           `json:"include_entities,omitempty" yaml:"include_entities,omitempty"`
+	ExcludeEntities []string           `json:"exclude_entities,omitempty" yaml:"exclude_entities,omitempty"`
 }
 
 type Entity struct {
@@ -19,6 +36,19 @@ type Entity struct {
 	TargetTable string   `json:"target_table" yaml:"target_table"`
 	Rows        int64    `json:"rows" yaml:"rows"`
 	Columns     []Column `json:"columns" yaml:"columns"`
+
+	// Options carries target-specific per-entity settings (e.g. order_by for
+	// clickhouse). Keys unknown to the selected target are ignored.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+
+	// ColumnGroups generate several numeric columns jointly (e.g. with a
+	// correlation matrix). Their columns declare no generator of their own.
+	ColumnGroups []ColumnGroup `json:"column_groups,omitempty" yaml:"column_groups,omitempty"`
+}
+
+type ColumnGroup struct {
+	Columns   []string      `json:"columns" yaml:"columns"`
+	Generator GeneratorSpec `json:"generator" yaml:"generator"`
 }
 
 type Column struct {
@@ -108,6 +138,7 @@ type RunStats struct {
 	TotalRows         int64            `json:"total_rows"`
 	DurationSeconds   float64          `json:"duration_seconds"`
 	EntityStats       []EntityRunStats `json:"entity_stats"`
+	RejectedRows      int64            `json:"rejected_rows,omitempty"`
 }
 
 type EntityRunStats struct {
@@ -146,6 +177,11 @@ type RunPlan struct {
 	ResolvedCounts map[string]int64 `json:"resolved_counts"`
 	Scale          float64          `json:"scale"`
 	Warnings       []string         `json:"warnings,omitempty"`
+
+	// Params are the scenario parameter values used by the run.
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Profile is the scenario profile applied, if any.
+	Profile string `json:"profile,omitempty"`
 }
 
 type RunRequest struct {
@@ -161,6 +197,18 @@ type RunRequest struct {
 	IncludeEntities []string           `json:"include_entities,omitempty"`
 	ExcludeEntities []string           `json:"exclude_entities,omitempty"`
 	Mode            string             `json:"mode,omitempty"`
+
+	// Profile selects one of the scenario's profiles; the request's own
+	// scale, counts and entity filters are applied on top of it.
+	Profile string `json:"profile,omitempty"`
+
+	// Params overrides scenario parameters; values are converted to the type
+	// of each parameter's default, so strings are accepted for all types.
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// MaxRejectedDocs is how many rows a target may reject individually (e.g.
+	// elasticsearch bulk item failures) before the run fails. Default 0.
+	MaxRejectedDocs int64 `json:"max_rejected_docs,omitempty"`
 }
 
 const (
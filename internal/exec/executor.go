@@ -1,16 +1,22 @@
 package exec
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"time"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/expr"
 	"github.com/mmrzaf/sdgen/internal/generators"
 	"github.com/mmrzaf/sdgen/internal/registry"
 	"github.com/mmrzaf/sdgen/internal/validation"
 )
 
+// Target writes generated rows. InsertBatch may replace values in rows in
+// place (e.g. with server-assigned IDs); the replaced values are the ones
+// later FK columns reference.
 type Target interface {
 	Connect() error
 	Close() error
@@ -19,9 +25,64 @@ type Target interface {
 	InsertBatch(tableName string, columns []string, rows [][]interface{}) error
 }
 
+// EntityBinder is implemented by targets that need the full entity definition
+// before rows are written, independent of table mode (e.g. to derive keys or
+// encodings that InsertBatch cannot infer from column names alone).
+type EntityBinder interface {
+	BindEntity(entity *domain.Entity) error
+}
+
+// PartialWriter is implemented by targets whose server may reject individual
+// rows of an accepted batch. Rejections within the budget are reported via log
+// instead of failing InsertBatch; Rejected returns the running total.
+type PartialWriter interface {
+	SetRejectPolicy(maxRejected int64, log func(level, message string))
+	Rejected() int64
+}
+
+// RunLogger is implemented by targets that report notable events, such as a
+// throttling delay they shortened, to the run log.
+type RunLogger interface {
+	SetRunLog(log func(level, message string))
+}
+
+// Flusher is implemented by targets that buffer or write asynchronously.
+// Flush is called after an entity's last batch and must return once every
+// row handed to InsertBatch is written.
+type Flusher interface {
+	Flush() error
+}
+
+// MaxQuerier is implemented by targets that can report the largest value
+// stored in a column, which sequence columns with offset_from: existing_max
+// continue after in append mode. With byLength the column holds text and
+// longer values rank higher, so "INV-10" is above "INV-9". A nil value means
+// the column holds none.
+type MaxQuerier interface {
+	MaxValue(tableName, column string, byLength bool) (interface{}, error)
+}
+
 type Executor struct {
 	genRegistry *registry.GeneratorRegistry
 	batchSize   int
+
+	// exprEnv and programs hold the current entity's column types and the
+	// expressions compiled against them.
+	exprEnv  map[string]expr.Type
+	programs map[string]*expr.Program
+	// dictionaries holds the current entity's dictionaries converted to
+	// their column types.
+	dictionaries map[string]*generators.Dictionary
+	// patterns holds parsed patterns by source; seen holds the values of
+	// the current entity's unique pattern columns.
+	patterns map[string]*generators.Pattern
+	seen     map[string]map[string]struct{}
+	// sequences holds the current entity's parsed sequences, top-level ones
+	// by column name and nested ones by their params.
+	sequences map[string]*generators.Sequence
+	// mixtures holds the current entity's parsed mixture components, keyed
+	// by the first element of their components list, which all rows share.
+	mixtures map[*interface{}][]generators.MixtureComponent
 }
 
 type ProgressEvent struct {
@@ -95,12 +156,44 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 		default:
 			return nil, fmt.Errorf("unknown table mode: %s", mode)
 		}
+		if binder, ok := target.(EntityBinder); ok {
+			if err := binder.BindEntity(entity); err != nil {
+				return nil, fmt.Errorf("failed to bind entity '%s': %w", entity.Name, err)
+			}
+		}
 
 		columnNames := make([]string, len(entity.Columns))
 		for i, col := range entity.Columns {
 			columnNames[i] = col.Name
 		}
 
+		colOrder, err := validation.ColumnOrder(entity)
+		if err != nil {
+			return nil, fmt.Errorf("entity '%s': %w", entity.Name, err)
+		}
+		// Row values are only tracked for entities with row-dependent columns.
+		var rowValues map[string]interface{}
+		for _, col := range entity.Columns {
+			if deps, _ := generators.RowDependencies(col.Generator); len(deps) > 0 {
+				rowValues = make(map[string]interface{}, len(entity.Columns))
+				break
+			}
+		}
+		e.exprEnv = expr.EntityEnv(entity)
+		e.programs = make(map[string]*expr.Program)
+		e.dictionaries = make(map[string]*generators.Dictionary)
+		e.patterns = make(map[string]*generators.Pattern)
+		e.seen = make(map[string]map[string]struct{})
+		e.mixtures = make(map[*interface{}][]generators.MixtureComponent)
+		if e.sequences, err = entitySequences(entity, target, mode); err != nil {
+			return nil, fmt.Errorf("entity '%s': %w", entity.Name, err)
+		}
+
+		groups, grouped, err := prepareColumnGroups(entity)
+		if err != nil {
+			return nil, fmt.Errorf("entity '%s': %w", entity.Name, err)
+		}
+
 		fkColumnIndices := make(map[int]bool)
 		for i, col := range entity.Columns {
 			if col.Generator.Type == "fk" {
@@ -121,18 +214,36 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 			ctx := generators.GeneratorContext{
 				RowIndex:     rowIdx,
 				EntityValues: entityValues,
+				Row:          rowValues,
+			}
+
+			// Column groups are sampled first; their columns read nothing else.
+			for _, g := range groups {
+				for k, v := range g.sampler.Sample(rng) {
+					colIdx := g.columns[k]
+					var val interface{} = v
+					if t := entity.Columns[colIdx].Type; t == domain.ColumnTypeInt || t == domain.ColumnTypeBigInt {
+						val = int64(math.Round(v))
+					}
+					row[colIdx] = val
+					if rowValues != nil {
+						rowValues[entity.Columns[colIdx].Name] = val
+					}
+				}
 			}
 
-			for colIdx, col := range entity.Columns {
+			for _, colIdx := range colOrder {
+				if grouped[colIdx] {
+					continue
+				}
+				col := entity.Columns[colIdx]
 				val, err := e.generateValue(rng, col, ctx)
 				if err != nil {
 					return nil, fmt.Errorf("entity '%s', column '%s', row %d: %w", entity.Name, col.Name, rowIdx, err)
 				}
 				row[colIdx] = val
-
-				if !fkColumnIndices[colIdx] {
-					key := entity.Name + "." + col.Name
-					entityValues[key] = append(entityValues[key], val)
+				if rowValues != nil {
+					rowValues[col.Name] = val
 				}
 			}
 
@@ -142,6 +253,7 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 				if err := target.InsertBatch(entity.TargetTable, columnNames, batch); err != nil {
 					return nil, fmt.Errorf("failed to insert batch for entity '%s': %w", entity.Name, err)
 				}
+				recordValues(entityValues, entity, batch, fkColumnIndices)
 				if onProgress != nil {
 					onProgress(ProgressEvent{
 						EntityName:    entity.Name,
@@ -159,6 +271,7 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 			if err := target.InsertBatch(entity.TargetTable, columnNames, batch); err != nil {
 				return nil, fmt.Errorf("failed to insert final batch for entity '%s': %w", entity.Name, err)
 			}
+			recordValues(entityValues, entity, batch, fkColumnIndices)
 			if onProgress != nil {
 				onProgress(ProgressEvent{
 					EntityName:    entity.Name,
@@ -169,6 +282,11 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 				})
 			}
 		}
+		if flusher, ok := target.(Flusher); ok {
+			if err := flusher.Flush(); err != nil {
+				return nil, fmt.Errorf("failed to flush writes for entity '%s': %w", entity.Name, err)
+			}
+		}
 
 		duration := time.Since(startTime)
 		stats.EntityStats = append(stats.EntityStats, domain.EntityRunStats{
@@ -190,15 +308,77 @@ func (e *Executor) Execute(scenario *domain.Scenario, target Target, seed int64,
 	}
 
 	stats.EntitiesGenerated = len(order)
+	if pw, ok := target.(PartialWriter); ok {
+		stats.RejectedRows = pw.Rejected()
+	}
 	return stats, nil
 }
 
+type columnGroup struct {
+	columns []int
+	sampler *generators.Correlated
+}
+
+// prepareColumnGroups builds the samplers of an entity's column groups and
+// marks the column indices they fill.
+func prepareColumnGroups(entity *domain.Entity) ([]columnGroup, map[int]bool, error) {
+	index := make(map[string]int, len(entity.Columns))
+	for i, col := range entity.Columns {
+		index[col.Name] = i
+	}
+	groups := make([]columnGroup, 0, len(entity.ColumnGroups))
+	grouped := make(map[int]bool)
+	for i, g := range entity.ColumnGroups {
+		sampler, err := generators.NewCorrelated(g.Generator, len(g.Columns))
+		if err != nil {
+			return nil, nil, fmt.Errorf("column group %d: %w", i+1, err)
+		}
+		cg := columnGroup{sampler: sampler}
+		for _, name := range g.Columns {
+			idx, ok := index[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("column group %d: unknown column '%s'", i+1, name)
+			}
+			cg.columns = append(cg.columns, idx)
+			grouped[idx] = true
+		}
+		groups = append(groups, cg)
+	}
+	return groups, grouped, nil
+}
+
+// recordValues stores written values for later FK lookups. It runs after
+// InsertBatch so that values a target replaced in place (e.g. server-assigned
+// IDs) are what dependent entities reference.
+func recordValues(entityValues map[string][]interface{}, entity *domain.Entity, batch [][]interface{}, fkColumnIndices map[int]bool) {
+	for colIdx, col := range entity.Columns {
+		if fkColumnIndices[colIdx] {
+			continue
+		}
+		key := entity.Name + "." + col.Name
+		for _, row := range batch {
+			if row[colIdx] == nil {
+				continue
+			}
+			entityValues[key] = append(entityValues[key], row[colIdx])
+		}
+	}
+}
+
 func (e *Executor) generateValue(rng *rand.Rand, col domain.Column, ctx generators.GeneratorContext) (interface{}, error) {
 	gen, err := e.genRegistry.Get(col.Generator.Type)
 	if err != nil {
 		return nil, err
 	}
 
+	// null_rate is accepted by every generator on nullable columns. The RNG is
+	// only consulted when it is set, so existing scenarios keep their output.
+	if col.Nullable {
+		if rate := generators.NullRate(col.Generator); rate > 0 && rng.Float64() < rate {
+			return nil, nil
+		}
+	}
+
 	switch col.Generator.Type {
 	case "const":
 		constGen := gen.(*generators.ConstGenerator)
@@ -221,7 +401,191 @@ func (e *Executor) generateValue(rng *rand.Rand, col domain.Column, ctx generato
 	case "fk":
 		fkGen := gen.(*generators.FKGenerator)
 		return fkGen.GenerateWithContext(rng, col.Generator.Params, ctx)
+	case "expr":
+		prog, err := e.program(col.Generator)
+		if err != nil {
+			return nil, err
+		}
+		val, err := prog.Eval(ctx.Row)
+		if err == nil && val == nil && !col.Nullable {
+			err = errors.New("expression produced null for a non-nullable column")
+		}
+		return val, err
+	case "dictionary":
+		dict, err := e.dictionary(col)
+		if err != nil {
+			return nil, err
+		}
+		return dict.Sample(rng), nil
+	case "pattern":
+		return e.patternValue(rng, col)
+	case "sequence":
+		seq, err := e.sequence(col)
+		if err != nil {
+			return nil, err
+		}
+		return seq.Value(ctx.RowIndex, col.Type), nil
+	case "histogram":
+		histGen := gen.(*generators.HistogramGenerator)
+		return histGen.GenerateWithParams(rng, col.Generator.Params, col.Type)
+	case "switch":
+		swGen := gen.(*generators.SwitchGenerator)
+		spec, ok, err := swGen.Select(col.Generator.Params, ctx.Row)
+		if err != nil || !ok {
+			return nil, err
+		}
+		nested := col
+		nested.Generator = spec
+		return e.generateValue(rng, nested, ctx)
+	case "mixture":
+		components, err := e.mixture(col.Generator)
+		if err != nil {
+			return nil, err
+		}
+		nested := col
+		nested.Generator = gen.(*generators.MixtureGenerator).Select(rng, components)
+		return e.generateValue(rng, nested, ctx)
 	default:
+		if dist, ok := gen.(*generators.DistributionGenerator); ok {
+			return dist.GenerateWithParams(rng, col.Generator.Params, col.Type)
+		}
 		return gen.Generate(rng, ctx)
 	}
 }
+
+// program returns the compiled expression of an expr spec, compiling it
+// against the current entity's columns on first use.
+func (e *Executor) program(spec domain.GeneratorSpec) (*expr.Program, error) {
+	src, err := generators.ExprSource(spec)
+	if err != nil {
+		return nil, err
+	}
+	if prog, ok := e.programs[src]; ok {
+		return prog, nil
+	}
+	prog, err := expr.Compile(src, e.exprEnv)
+	if err != nil {
+		return nil, err
+	}
+	e.programs[src] = prog
+	return prog, nil
+}
+
+// entitySequences parses the entity's sequence columns. In append mode
+// those with offset_from: existing_max continue after the largest value the
+// target already holds; otherwise sequences start at 'start'.
+func entitySequences(entity *domain.Entity, target Target, mode string) (map[string]*generators.Sequence, error) {
+	out := make(map[string]*generators.Sequence)
+	for _, col := range entity.Columns {
+		if col.Generator.Type != "sequence" {
+			continue
+		}
+		seq, err := generators.ParseSequence(col.Generator)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+		out[col.Name] = seq
+		if mode != domain.TableModeAppend || seq.OffsetFrom != generators.OffsetExistingMax {
+			continue
+		}
+		q, ok := target.(MaxQuerier)
+		if !ok {
+			return nil, fmt.Errorf("column '%s': target cannot report existing values for offset_from: %s", col.Name, generators.OffsetExistingMax)
+		}
+		textual := col.Type == domain.ColumnTypeString || col.Type == domain.ColumnTypeText
+		stored, err := q.MaxValue(entity.TargetTable, col.Name, textual)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': failed to read existing max: %w", col.Name, err)
+		}
+		if err := seq.ContinueAfter(stored); err != nil {
+			return nil, fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// sequence returns the parsed sequence of a column. Sequences nested in
+// switch or mixture generators, whose columns have no top-level entry, are
+// parsed on first use and cached by their params.
+func (e *Executor) sequence(col domain.Column) (*generators.Sequence, error) {
+	if seq, ok := e.sequences[col.Name]; ok {
+		return seq, nil
+	}
+	key := fmt.Sprintf("%s|%v", col.Name, col.Generator.Params)
+	if seq, ok := e.sequences[key]; ok {
+		return seq, nil
+	}
+	seq, err := generators.ParseSequence(col.Generator)
+	if err != nil {
+		return nil, err
+	}
+	e.sequences[key] = seq
+	return seq, nil
+}
+
+// mixture returns the parsed components of a mixture spec, parsing each
+// mixture of the current entity once.
+func (e *Executor) mixture(spec domain.GeneratorSpec) ([]generators.MixtureComponent, error) {
+	raw, _ := spec.Params["components"].([]interface{})
+	if len(raw) > 0 {
+		if components, ok := e.mixtures[&raw[0]]; ok {
+			return components, nil
+		}
+	}
+	components, err := generators.ParseMixture(spec)
+	if err != nil {
+		return nil, err
+	}
+	e.mixtures[&raw[0]] = components
+	return components, nil
+}
+
+// maxUniqueAttempts bounds the redraws for an unseen value of a unique
+// pattern column. Validation requires twice as many possible values as rows,
+// so each draw is new with probability >= 1/2.
+const maxUniqueAttempts = 1000
+
+// patternValue generates a pattern column's value, redrawing values already
+// produced for the column when it is unique.
+func (e *Executor) patternValue(rng *rand.Rand, col domain.Column) (interface{}, error) {
+	src, _ := col.Generator.Params["pattern"].(string)
+	p, ok := e.patterns[src]
+	if !ok {
+		var err error
+		if p, err = generators.PatternOf(col.Generator); err != nil {
+			return nil, err
+		}
+		e.patterns[src] = p
+	}
+	if !generators.PatternUnique(col.Generator) {
+		return p.Generate(rng), nil
+	}
+	seen := e.seen[col.Name]
+	if seen == nil {
+		seen = make(map[string]struct{})
+		e.seen[col.Name] = seen
+	}
+	for i := 0; i < maxUniqueAttempts; i++ {
+		v := p.Generate(rng)
+		if _, dup := seen[v]; !dup {
+			seen[v] = struct{}{}
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("no unseen value for pattern %q after %d attempts", src, maxUniqueAttempts)
+}
+
+// dictionary returns the prepared dictionary of a dictionary column,
+// converting its values on first use.
+func (e *Executor) dictionary(col domain.Column) (*generators.Dictionary, error) {
+	key := generators.DictionaryKey(col.Generator.Params, col.Type)
+	if dict, ok := e.dictionaries[key]; ok {
+		return dict, nil
+	}
+	dict, err := generators.NewDictionary(col.Generator.Params, col.Type)
+	if err != nil {
+		return nil, err
+	}
+	e.dictionaries[key] = dict
+	return dict, nil
+}
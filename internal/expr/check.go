@@ -0,0 +1,134 @@
+package expr
+
+func check(n node, env map[string]Type) error {
+	switch x := n.(type) {
+	case *literal:
+		return nil
+	case *ident:
+		t, ok := env[x.name]
+		if !ok {
+			return errorf(x.p, "unknown column %q", x.name)
+		}
+		x.t = t
+		return nil
+	case *unary:
+		if err := check(x.x, env); err != nil {
+			return err
+		}
+		xt := x.x.typ()
+		switch {
+		case x.op == "-" && (xt.numeric() || xt == TypeNull):
+			x.t = xt
+		case x.op == "!" && (xt == TypeBool || xt == TypeNull):
+			x.t = TypeBool
+		default:
+			return errorf(x.p, "operator %s not defined on %s", x.op, xt)
+		}
+		return nil
+	case *binary:
+		if err := check(x.x, env); err != nil {
+			return err
+		}
+		if err := check(x.y, env); err != nil {
+			return err
+		}
+		t, ok := binaryType(x.op, x.x.typ(), x.y.typ())
+		if !ok {
+			return errorf(x.p, "operator %s not defined on %s and %s", x.op, x.x.typ(), x.y.typ())
+		}
+		x.t = t
+		return nil
+	case *cond:
+		for _, c := range []node{x.c, x.a, x.b} {
+			if err := check(c, env); err != nil {
+				return err
+			}
+		}
+		if ct := x.c.typ(); ct != TypeBool && ct != TypeNull {
+			return errorf(x.c.pos(), "condition must be bool, got %s", ct)
+		}
+		t, ok := unify(x.a.typ(), x.b.typ())
+		if !ok {
+			return errorf(x.p, "branches have different types: %s and %s", x.a.typ(), x.b.typ())
+		}
+		x.t = t
+		return nil
+	case *call:
+		fn, ok := builtins[x.name]
+		if !ok {
+			return errorf(x.p, "unknown function %s", x.name)
+		}
+		args := make([]Type, len(x.args))
+		for i, a := range x.args {
+			if err := check(a, env); err != nil {
+				return err
+			}
+			args[i] = a.typ()
+		}
+		t, err := fn.check(args)
+		if err != nil {
+			return errorf(x.p, "%s: %v", x.name, err)
+		}
+		x.fn = fn
+		x.t = t
+		return nil
+	}
+	return errorf(n.pos(), "unsupported expression")
+}
+
+// unify returns the common type of two branches or arguments.
+func unify(a, b Type) (Type, bool) {
+	switch {
+	case a == b:
+		return a, true
+	case a == TypeNull:
+		return b, true
+	case b == TypeNull:
+		return a, true
+	case a.numeric() && b.numeric():
+		return TypeFloat, true
+	}
+	return 0, false
+}
+
+func binaryType(op string, a, b Type) (Type, bool) {
+	// null operands take the type of the other side
+	if a == TypeNull {
+		a = b
+	}
+	if b == TypeNull {
+		b = a
+	}
+	switch op {
+	case "&&", "||":
+		return TypeBool, (a == TypeBool || a == TypeNull) && (b == TypeBool || b == TypeNull)
+	case "==", "!=":
+		_, ok := unify(a, b)
+		return TypeBool, ok
+	case "<", "<=", ">", ">=":
+		ok := (a.numeric() && b.numeric()) || (a == b && (a == TypeString || a == TypeTime))
+		return TypeBool, ok
+	case "+":
+		if a == TypeString && b == TypeString {
+			return TypeString, true
+		}
+		return arithType(a, b)
+	case "-", "*":
+		return arithType(a, b)
+	case "/":
+		return TypeFloat, a.numeric() && b.numeric()
+	case "%":
+		return TypeInt, a == TypeInt && b == TypeInt
+	}
+	return 0, false
+}
+
+func arithType(a, b Type) (Type, bool) {
+	if !a.numeric() || !b.numeric() {
+		return 0, false
+	}
+	if a == TypeInt && b == TypeInt {
+		return TypeInt, true
+	}
+	return TypeFloat, true
+}
@@ -0,0 +1,289 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values are nil, int64, float64, string, bool or time.Time. Null propagates
+// through arithmetic, ordering and most functions; it equals only null and
+// counts as false in conditions.
+func eval(n node, row map[string]interface{}) (interface{}, error) {
+	switch x := n.(type) {
+	case *literal:
+		return x.val, nil
+	case *ident:
+		v, err := coerce(row[x.name], x.t)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", x.name, err)
+		}
+		return v, nil
+	case *unary:
+		v, err := eval(x.x, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if x.op == "!" {
+			return !v.(bool), nil
+		}
+		if i, ok := v.(int64); ok {
+			return -i, nil
+		}
+		return -v.(float64), nil
+	case *binary:
+		return evalBinary(x, row)
+	case *cond:
+		c, err := eval(x.c, row)
+		if err != nil {
+			return nil, err
+		}
+		branch := x.b
+		if truthy(c) {
+			branch = x.a
+		}
+		v, err := eval(branch, row)
+		if err != nil {
+			return nil, err
+		}
+		return conform(v, x.t), nil
+	case *call:
+		args := make([]interface{}, len(x.args))
+		for i, a := range x.args {
+			v, err := eval(a, row)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil && !x.fn.nullSafe {
+				return nil, nil
+			}
+			args[i] = v
+		}
+		v, err := x.fn.call(args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", x.name, err)
+		}
+		return conform(v, x.t), nil
+	}
+	return nil, fmt.Errorf("unsupported expression")
+}
+
+func evalBinary(x *binary, row map[string]interface{}) (interface{}, error) {
+	l, err := eval(x.x, row)
+	if err != nil {
+		return nil, err
+	}
+	// && and || short-circuit
+	switch x.op {
+	case "&&":
+		if !truthy(l) {
+			return false, nil
+		}
+		r, err := eval(x.y, row)
+		return truthy(r), err
+	case "||":
+		if truthy(l) {
+			return true, nil
+		}
+		r, err := eval(x.y, row)
+		return truthy(r), err
+	}
+	r, err := eval(x.y, row)
+	if err != nil {
+		return nil, err
+	}
+	switch x.op {
+	case "==", "!=":
+		eq := l == nil && r == nil
+		if l != nil && r != nil {
+			eq = compare(l, r) == 0
+		}
+		return eq == (x.op == "=="), nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	switch x.op {
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	case "%":
+		if r.(int64) == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return l.(int64) % r.(int64), nil
+	case "/":
+		d := toFloat(r)
+		if d == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return toFloat(l) / d, nil
+	}
+	if ls, ok := l.(string); ok {
+		return ls + r.(string), nil
+	}
+	if x.t == TypeInt {
+		a, b := l.(int64), r.(int64)
+		switch x.op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		default:
+			return a * b, nil
+		}
+	}
+	a, b := toFloat(l), toFloat(r)
+	switch x.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	default:
+		return a * b, nil
+	}
+}
+
+func truthy(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+// conform widens an int result to float where the checked type is float.
+func conform(v interface{}, t Type) interface{} {
+	if i, ok := v.(int64); ok && t == TypeFloat {
+		return float64(i)
+	}
+	return v
+}
+
+// compare orders two non-nil values of compatible types.
+func compare(a, b interface{}) int {
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	}
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	fa, fb := toFloat(a), toFloat(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+	return 0
+}
+
+// coerce converts a generated column value to the column's expression type.
+func coerce(v interface{}, t Type) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch t {
+	case TypeInt:
+		switch n := v.(type) {
+		case int64:
+			return n, nil
+		case int:
+			return int64(n), nil
+		case int32:
+			return int64(n), nil
+		case float64:
+			return int64(math.Round(n)), nil
+		case float32:
+			return int64(math.Round(float64(n))), nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %q as int", n)
+			}
+			return i, nil
+		}
+	case TypeFloat:
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case float32:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case int:
+			return float64(n), nil
+		case int32:
+			return float64(n), nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %q as float", n)
+			}
+			return f, nil
+		}
+	case TypeString:
+		switch s := v.(type) {
+		case string:
+			return s, nil
+		case []byte:
+			return string(s), nil
+		}
+		return fmt.Sprint(v), nil
+	case TypeBool:
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			p, err := strconv.ParseBool(b)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %q as bool", b)
+			}
+			return p, nil
+		}
+	case TypeTime:
+		switch ts := v.(type) {
+		case time.Time:
+			return ts, nil
+		case string:
+			p, err := time.Parse(time.RFC3339, ts)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %q as time", ts)
+			}
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot read %T as %s", v, t)
+}
@@ -0,0 +1,146 @@
+// Package expr implements the small expression language used by the expr
+// generator. Expressions read columns of the current row by name and call a
+// fixed set of builtin functions; they cannot reach anything else, so a
+// scenario expression is safe to evaluate.
+//
+// Expressions are compiled against the types of the row's columns. Compile
+// type-checks the whole expression, so a compiled Program only fails at run
+// time on bad data (division by zero, an unparseable number, ...).
+package expr
+
+import (
+	"fmt"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// Type is the static type of an expression.
+type Type int
+
+const (
+	// TypeNull is the type of the null literal; it unifies with every type.
+	TypeNull Type = iota
+	TypeInt
+	TypeFloat
+	TypeString
+	TypeBool
+	TypeTime
+	// typeAny is only used by builtin signatures that accept every type.
+	typeAny
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeNull:
+		return "null"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeString:
+		return "string"
+	case TypeBool:
+		return "bool"
+	case TypeTime:
+		return "time"
+	default:
+		return "any"
+	}
+}
+
+func (t Type) numeric() bool { return t == TypeInt || t == TypeFloat }
+
+// ColumnTypeOf returns the expression type of a scenario column type.
+func ColumnTypeOf(ct domain.ColumnType) Type {
+	switch ct {
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		return TypeInt
+	case domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+		return TypeFloat
+	case domain.ColumnTypeBool:
+		return TypeBool
+	case domain.ColumnTypeTimestamp, domain.ColumnTypeDate:
+		return TypeTime
+	default:
+		return TypeString
+	}
+}
+
+// Assignable reports whether a value of type t may be stored in a column of
+// type ct. Ints widen to float columns; null fits everywhere.
+func Assignable(t Type, ct domain.ColumnType) bool {
+	want := ColumnTypeOf(ct)
+	return t == want || t == TypeNull || (t == TypeInt && want == TypeFloat)
+}
+
+// EntityEnv returns the column types an expression in the entity may read.
+func EntityEnv(entity *domain.Entity) map[string]Type {
+	env := make(map[string]Type, len(entity.Columns))
+	for _, c := range entity.Columns {
+		env[c.Name] = ColumnTypeOf(c.Type)
+	}
+	return env
+}
+
+// Program is a parsed and type-checked expression.
+type Program struct {
+	src  string
+	root node
+}
+
+// Type returns the static type of the expression's result.
+func (p *Program) Type() Type { return p.root.typ() }
+
+// Eval evaluates the expression over a row of column values. Column values
+// are converted to the type the expression was compiled with; a missing or
+// nil column reads as null.
+func (p *Program) Eval(row map[string]interface{}) (interface{}, error) {
+	v, err := eval(p.root, row)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", p.src, err)
+	}
+	return v, nil
+}
+
+// Compile parses src and type-checks it against env, the types of the
+// columns the expression may reference.
+func Compile(src string, env map[string]Type) (*Program, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(root, env); err != nil {
+		return nil, err
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+// References parses src and returns the column names it reads, in order of
+// first use.
+func References(src string) ([]string, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	seen := map[string]bool{}
+	walk(root, func(n node) {
+		if id, ok := n.(*ident); ok && !seen[id.name] {
+			seen[id.name] = true
+			names = append(names, id.name)
+		}
+	})
+	return names, nil
+}
+
+// Error is a compile error with the byte offset it refers to.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%s (at offset %d)", e.Msg, e.Pos) }
+
+func errorf(pos int, format string, args ...interface{}) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,148 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEnv = map[string]Type{
+	"quantity":   TypeInt,
+	"unit_price": TypeFloat,
+	"first_name": TypeString,
+	"last_name":  TypeString,
+	"risk_score": TypeInt,
+	"created_at": TypeTime,
+	"active":     TypeBool,
+	"nickname":   TypeString,
+}
+
+func TestEval(t *testing.T) {
+	created := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	row := map[string]interface{}{
+		"quantity":   3, // plain int, as const/choice values decode
+		"unit_price": 2.5,
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"risk_score": 81.2, // normal on an int column yields floats
+		"created_at": created,
+		"active":     true,
+		"nickname":   nil,
+	}
+	cases := []struct {
+		src  string
+		want interface{}
+		typ  Type
+	}{
+		{"quantity * unit_price", 7.5, TypeFloat},
+		{"quantity * 2 + 1", int64(7), TypeInt},
+		{"quantity / 2", 1.5, TypeFloat},
+		{"quantity % 2", int64(1), TypeInt},
+		{`lower(first_name) + "." + lower(last_name) + "@example.com"`, "ada.lovelace@example.com", TypeString},
+		{"risk_score > 80", true, TypeBool},
+		{"risk_score > 80 ? 'high' : 'low'", "high", TypeString},
+		{"if(active && quantity >= 3, unit_price, 0)", 2.5, TypeFloat},
+		{"if(!active, 1, 2)", int64(2), TypeInt},
+		{"round(unit_price * 1.15, 2)", 2.88, TypeFloat},
+		{"round(unit_price)", int64(3), TypeInt},
+		{"max(quantity, unit_price, 1)", 3.0, TypeFloat},
+		{"year(created_at) * 100 + month(created_at)", int64(202403), TypeInt},
+		{"date_add(created_at, '7d')", created.Add(7 * 24 * time.Hour), TypeTime},
+		{"days_between(created_at, date_add(created_at, '36h'))", int64(1), TypeInt},
+		{"format_time(created_at, '2006-01-02')", "2024-03-15", TypeString},
+		{"lpad(string(quantity), 4, '0')", "0003", TypeString},
+		{"concat('INV-', quantity, '-', nickname)", "INV-3-", TypeString},
+		{"upper(substr(last_name, 0, 3))", "LOV", TypeString},
+		{"coalesce(nickname, first_name)", "Ada", TypeString},
+		{"is_null(nickname)", true, TypeBool},
+		{"nickname == null", true, TypeBool},
+		{"length(nickname)", nil, TypeInt},
+		{"upper(nickname) == 'X'", false, TypeBool},
+		{"-quantity + int('10')", int64(7), TypeInt},
+	}
+	for _, tc := range cases {
+		prog, err := Compile(tc.src, testEnv)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if prog.Type() != tc.typ {
+			t.Errorf("%s: type %s, want %s", tc.src, prog.Type(), tc.typ)
+		}
+		got, err := prog.Eval(row)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s = %#v, want %#v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := map[string]string{
+		"quantity +":                 "unexpected end of expression",
+		"unknown_col * 2":            `unknown column "unknown_col"`,
+		"first_name * 2":             "operator * not defined on string and int",
+		"first_name + quantity":      "operator + not defined on string and int",
+		"nope(1)":                    "unknown function nope",
+		"lower(quantity)":            "lower: argument 1 must be string, got int",
+		"quantity ? 1 : 2":           "condition must be bool",
+		"active ? 1 : 'x'":           "branches have different types",
+		"unit_price % 2":             "operator % not defined on float and int",
+		"'unterminated":              "unterminated string",
+		"quantity $ 2":               "unexpected character",
+		"if(active, 1)":              "if takes 3 arguments",
+		"round(1, 2, 3)":             "takes 1 or 2 arguments",
+		"created_at < quantity":      "operator < not defined on time and int",
+		"(quantity + 1":              `expected ")"`,
+		"1 2":                        `unexpected "2"`,
+		"substr(first_name, 1.5, 2)": "argument 2 must be int, got float",
+	}
+	for src, want := range cases {
+		_, err := Compile(src, testEnv)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	prog, err := Compile("unit_price / (quantity - 3)", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prog.Eval(map[string]interface{}{"unit_price": 1.0, "quantity": int64(3)}); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Fatalf("expected division by zero, got %v", err)
+	}
+	// the branch not taken is not evaluated
+	prog, err = Compile("quantity == 3 ? 0.0 : unit_price / (quantity - 3)", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := prog.Eval(map[string]interface{}{"unit_price": 1.0, "quantity": int64(3)}); err != nil || v != 0.0 {
+		t.Fatalf("expected 0, got %v, %v", v, err)
+	}
+	prog, err = Compile("lpad('x', quantity, 'ab')", testEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := prog.Eval(map[string]interface{}{"quantity": int64(4)}); err != nil || v != "ababx" {
+		t.Fatalf("expected ababx, got %v, %v", v, err)
+	}
+	if _, err := prog.Eval(map[string]interface{}{"quantity": int64(1 << 40)}); err == nil || !strings.Contains(err.Error(), "pad length") {
+		t.Fatalf("expected pad length error, got %v", err)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got, err := References("quantity * unit_price + quantity + lower(first_name) == 'x' ? 1 : 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"quantity", "unit_price", "first_name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("References = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,322 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmrzaf/sdgen/internal/timeutil"
+)
+
+// maxPadLength bounds the target length of lpad.
+const maxPadLength = 10000
+
+type builtin struct {
+	check func(args []Type) (Type, error)
+	call  func(args []interface{}) (interface{}, error)
+	// nullSafe functions receive null arguments; the others return null
+	// when any argument is null.
+	nullSafe bool
+}
+
+var builtins = map[string]*builtin{
+	// strings
+	"lower": {check: fixed(TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.ToLower(a[0].(string)), nil
+	}},
+	"upper": {check: fixed(TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.ToUpper(a[0].(string)), nil
+	}},
+	"trim": {check: fixed(TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.TrimSpace(a[0].(string)), nil
+	}},
+	"length": {check: fixed(TypeInt, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return int64(utf8.RuneCountInString(a[0].(string))), nil
+	}},
+	"substr": {check: fixed(TypeString, TypeString, TypeInt, TypeInt), call: func(a []interface{}) (interface{}, error) {
+		r := []rune(a[0].(string))
+		start := clamp(a[1].(int64), 0, int64(len(r)))
+		end := clamp(start+a[2].(int64), start, int64(len(r)))
+		return string(r[start:end]), nil
+	}},
+	"replace": {check: fixed(TypeString, TypeString, TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.ReplaceAll(a[0].(string), a[1].(string), a[2].(string)), nil
+	}},
+	"contains": {check: fixed(TypeBool, TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.Contains(a[0].(string), a[1].(string)), nil
+	}},
+	"starts_with": {check: fixed(TypeBool, TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.HasPrefix(a[0].(string), a[1].(string)), nil
+	}},
+	"ends_with": {check: fixed(TypeBool, TypeString, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return strings.HasSuffix(a[0].(string), a[1].(string)), nil
+	}},
+	"lpad": {check: fixed(TypeString, TypeString, TypeInt, TypeString), call: func(a []interface{}) (interface{}, error) {
+		s, n, pad := a[0].(string), a[1].(int64), a[2].(string)
+		if pad == "" {
+			return nil, errors.New("pad must not be empty")
+		}
+		if n > maxPadLength {
+			return nil, fmt.Errorf("pad length %d exceeds %d", n, maxPadLength)
+		}
+		missing := n - int64(utf8.RuneCountInString(s))
+		if missing <= 0 {
+			return s, nil
+		}
+		width := int64(utf8.RuneCountInString(pad))
+		return strings.Repeat(pad, int((missing+width-1)/width)) + s, nil
+	}},
+	"concat": {nullSafe: true, check: variadic(TypeString, typeAny), call: func(a []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, v := range a {
+			if v != nil {
+				sb.WriteString(format(v))
+			}
+		}
+		return sb.String(), nil
+	}},
+	"string": {check: fixed(TypeString, typeAny), call: func(a []interface{}) (interface{}, error) {
+		return format(a[0]), nil
+	}},
+
+	// numbers
+	"abs": {check: numericLike(1, 1), call: func(a []interface{}) (interface{}, error) {
+		if i, ok := a[0].(int64); ok {
+			if i < 0 {
+				return -i, nil
+			}
+			return i, nil
+		}
+		return math.Abs(a[0].(float64)), nil
+	}},
+	"min": {check: numericLike(2, -1), call: func(a []interface{}) (interface{}, error) {
+		best := a[0]
+		for _, v := range a[1:] {
+			if compare(v, best) < 0 {
+				best = v
+			}
+		}
+		return best, nil
+	}},
+	"max": {check: numericLike(2, -1), call: func(a []interface{}) (interface{}, error) {
+		best := a[0]
+		for _, v := range a[1:] {
+			if compare(v, best) > 0 {
+				best = v
+			}
+		}
+		return best, nil
+	}},
+	"round": {check: checkRound, call: func(a []interface{}) (interface{}, error) {
+		f := toFloat(a[0])
+		if len(a) == 1 {
+			return int64(math.Round(f)), nil
+		}
+		p := math.Pow(10, float64(a[1].(int64)))
+		return math.Round(f*p) / p, nil
+	}},
+	"floor": {check: fixed(TypeInt, TypeFloat), call: func(a []interface{}) (interface{}, error) {
+		return int64(math.Floor(toFloat(a[0]))), nil
+	}},
+	"ceil": {check: fixed(TypeInt, TypeFloat), call: func(a []interface{}) (interface{}, error) {
+		return int64(math.Ceil(toFloat(a[0]))), nil
+	}},
+	"sqrt": {check: fixed(TypeFloat, TypeFloat), call: func(a []interface{}) (interface{}, error) {
+		f := toFloat(a[0])
+		if f < 0 {
+			return nil, fmt.Errorf("negative argument %v", f)
+		}
+		return math.Sqrt(f), nil
+	}},
+	"pow": {check: fixed(TypeFloat, TypeFloat, TypeFloat), call: func(a []interface{}) (interface{}, error) {
+		return math.Pow(toFloat(a[0]), toFloat(a[1])), nil
+	}},
+	"int": {check: convertible(TypeInt), call: func(a []interface{}) (interface{}, error) {
+		if s, ok := a[0].(string); ok {
+			i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q", s)
+			}
+			return i, nil
+		}
+		return int64(toFloat(a[0])), nil
+	}},
+	"float": {check: convertible(TypeFloat), call: func(a []interface{}) (interface{}, error) {
+		if s, ok := a[0].(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q", s)
+			}
+			return f, nil
+		}
+		return toFloat(a[0]), nil
+	}},
+
+	// dates
+	"year":    timePart(func(t time.Time) int { return t.Year() }),
+	"month":   timePart(func(t time.Time) int { return int(t.Month()) }),
+	"day":     timePart(func(t time.Time) int { return t.Day() }),
+	"hour":    timePart(func(t time.Time) int { return t.Hour() }),
+	"minute":  timePart(func(t time.Time) int { return t.Minute() }),
+	"weekday": timePart(func(t time.Time) int { return int(t.Weekday()) }),
+	"date": {check: fixed(TypeTime, TypeTime), call: func(a []interface{}) (interface{}, error) {
+		t := a[0].(time.Time)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	}},
+	"date_add": {check: fixed(TypeTime, TypeTime, TypeString), call: func(a []interface{}) (interface{}, error) {
+		d, err := timeutil.ParseDuration(a[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		return a[0].(time.Time).Add(d), nil
+	}},
+	"days_between": {check: fixed(TypeInt, TypeTime, TypeTime), call: func(a []interface{}) (interface{}, error) {
+		return int64(math.Floor(a[1].(time.Time).Sub(a[0].(time.Time)).Hours() / 24)), nil
+	}},
+	"format_time": {check: fixed(TypeString, TypeTime, TypeString), call: func(a []interface{}) (interface{}, error) {
+		return a[0].(time.Time).Format(a[1].(string)), nil
+	}},
+
+	// nulls
+	"coalesce": {nullSafe: true, check: checkCoalesce, call: func(a []interface{}) (interface{}, error) {
+		for _, v := range a {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}},
+	"is_null": {nullSafe: true, check: fixed(TypeBool, typeAny), call: func(a []interface{}) (interface{}, error) {
+		return a[0] == nil, nil
+	}},
+}
+
+func clamp(v, lo, hi int64) int64 {
+	return max(lo, min(v, hi))
+}
+
+func format(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// accepts reports whether an argument of type arg fits a parameter; ints
+// are accepted where floats are expected.
+func accepts(param, arg Type) bool {
+	return param == typeAny || arg == TypeNull || arg == param || (param == TypeFloat && arg == TypeInt)
+}
+
+func arity(got, want int) error {
+	if want == 1 {
+		return fmt.Errorf("takes 1 argument, got %d", got)
+	}
+	return fmt.Errorf("takes %d arguments, got %d", want, got)
+}
+
+func fixed(ret Type, params ...Type) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) != len(params) {
+			return 0, arity(len(args), len(params))
+		}
+		for i, p := range params {
+			if !accepts(p, args[i]) {
+				return 0, fmt.Errorf("argument %d must be %s, got %s", i+1, p, args[i])
+			}
+		}
+		return ret, nil
+	}
+}
+
+func variadic(ret, param Type) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) == 0 {
+			return 0, errors.New("takes at least 1 argument")
+		}
+		for i, a := range args {
+			if !accepts(param, a) {
+				return 0, fmt.Errorf("argument %d must be %s, got %s", i+1, param, a)
+			}
+		}
+		return ret, nil
+	}
+}
+
+// numericLike checks numeric arguments; the result is int when every
+// argument is, float otherwise. maxArgs < 0 means no upper bound.
+func numericLike(minArgs, maxArgs int) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+			if minArgs == maxArgs {
+				return 0, arity(len(args), minArgs)
+			}
+			return 0, fmt.Errorf("takes at least %d arguments, got %d", minArgs, len(args))
+		}
+		ret := TypeInt
+		for i, a := range args {
+			switch a {
+			case TypeInt, TypeNull:
+			case TypeFloat:
+				ret = TypeFloat
+			default:
+				return 0, fmt.Errorf("argument %d must be numeric, got %s", i+1, a)
+			}
+		}
+		return ret, nil
+	}
+}
+
+func convertible(ret Type) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) != 1 {
+			return 0, arity(len(args), 1)
+		}
+		if a := args[0]; !a.numeric() && a != TypeString && a != TypeNull {
+			return 0, fmt.Errorf("argument must be numeric or string, got %s", a)
+		}
+		return ret, nil
+	}
+}
+
+func checkRound(args []Type) (Type, error) {
+	switch len(args) {
+	case 1:
+		return fixed(TypeInt, TypeFloat)(args)
+	case 2:
+		return fixed(TypeFloat, TypeFloat, TypeInt)(args)
+	}
+	return 0, fmt.Errorf("takes 1 or 2 arguments, got %d", len(args))
+}
+
+func checkCoalesce(args []Type) (Type, error) {
+	if len(args) == 0 {
+		return 0, errors.New("takes at least 1 argument")
+	}
+	t := args[0]
+	for _, a := range args[1:] {
+		u, ok := unify(t, a)
+		if !ok {
+			return 0, fmt.Errorf("arguments have different types: %s and %s", t, a)
+		}
+		t = u
+	}
+	return t, nil
+}
+
+func timePart(part func(time.Time) int) *builtin {
+	return &builtin{check: fixed(TypeInt, TypeTime), call: func(a []interface{}) (interface{}, error) {
+		return int64(part(a[0].(time.Time))), nil
+	}}
+}
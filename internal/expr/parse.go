@@ -0,0 +1,384 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+type node interface {
+	pos() int
+	typ() Type
+}
+
+type base struct {
+	p int
+	t Type
+}
+
+func (b *base) pos() int  { return b.p }
+func (b *base) typ() Type { return b.t }
+
+type literal struct {
+	base
+	val interface{}
+}
+
+type ident struct {
+	base
+	name string
+}
+
+type unary struct {
+	base
+	op string
+	x  node
+}
+
+type binary struct {
+	base
+	op   string
+	x, y node
+}
+
+// cond is both `c ? a : b` and if(c, a, b); only the chosen branch runs.
+type cond struct {
+	base
+	c, a, b node
+}
+
+type call struct {
+	base
+	name string
+	args []node
+	fn   *builtin
+}
+
+func walk(n node, f func(node)) {
+	f(n)
+	switch x := n.(type) {
+	case *unary:
+		walk(x.x, f)
+	case *binary:
+		walk(x.x, f)
+		walk(x.y, f)
+	case *cond:
+		walk(x.c, f)
+		walk(x.a, f)
+		walk(x.b, f)
+	case *call:
+		for _, a := range x.args {
+			walk(a, f)
+		}
+	}
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokInt
+	tokFloat
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string // raw text; unquoted value for strings
+	pos  int
+}
+
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			kind := tokInt
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokFloat
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				if i >= len(src) || !isDigit(src[i]) {
+					return nil, errorf(start, "malformed number")
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && isIdentChar(src[i]) {
+				return nil, errorf(start, "malformed number")
+			}
+			toks = append(toks, token{kind: kind, text: src[start:i], pos: start})
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, errorf(start, "unterminated string")
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case '\\', '\'', '"':
+						sb.WriteByte(src[i])
+					default:
+						return nil, errorf(i-1, "unknown escape \\%c", src[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			toks = append(toks, token{kind: tokString, text: sb.String(), pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentChar(src[i]) {
+				i++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, two := range twoCharOps {
+				if strings.HasPrefix(src[i:], two) {
+					op = two
+					break
+				}
+			}
+			if op == "" && strings.IndexByte("+-*/%()<>!?:,", c) >= 0 {
+				op = string(c)
+			}
+			if op == "" {
+				return nil, errorf(i, "unexpected character %q", c)
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isIdentChar(c byte) bool  { return isIdentStart(c) || isDigit(c) }
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func parse(src string) (node, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errorf(0, "empty expression")
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "unexpected %q", t.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		if t.kind == tokEOF {
+			return errorf(t.pos, "expected %q, found end of expression", op)
+		}
+		return errorf(t.pos, "expected %q, found %q", op, t.text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) ternary() (node, error) {
+	c, err := p.binaryLevel(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("?") {
+		return c, nil
+	}
+	pos := p.next().pos
+	a, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return &cond{base: base{p: pos}, c: c, a: a, b: b}, nil
+}
+
+// precedence levels, lowest first
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binaryLevel(level int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+	x, err := p.binaryLevel(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(binaryLevels[level]...) {
+		t := p.next()
+		y, err := p.binaryLevel(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{base: base{p: t.pos}, op: t.text, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.isOp("-", "!") {
+		t := p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{base: base{p: t.pos}, op: t.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, errorf(t.pos, "integer out of range: %s", t.text)
+		}
+		return &literal{base: base{p: t.pos, t: TypeInt}, val: n}, nil
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, errorf(t.pos, "malformed number: %s", t.text)
+		}
+		return &literal{base: base{p: t.pos, t: TypeFloat}, val: f}, nil
+	case tokString:
+		return &literal{base: base{p: t.pos, t: TypeString}, val: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return &literal{base: base{p: t.pos, t: TypeBool}, val: t.text == "true"}, nil
+		case "null":
+			return &literal{base: base{p: t.pos, t: TypeNull}}, nil
+		}
+		if !p.isOp("(") {
+			return &ident{base: base{p: t.pos}, name: t.text}, nil
+		}
+		p.next()
+		var args []node
+		if !p.isOp(")") {
+			for {
+				a, err := p.ternary()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, a)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if t.text == "if" {
+			if len(args) != 3 {
+				return nil, errorf(t.pos, "if takes 3 arguments, got %d", len(args))
+			}
+			return &cond{base: base{p: t.pos}, c: args[0], a: args[1], b: args[2]}, nil
+		}
+		return &call{base: base{p: t.pos}, name: t.text, args: args}, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+		return nil, errorf(t.pos, "unexpected %q", t.text)
+	default:
+		return nil, errorf(t.pos, "unexpected end of expression")
+	}
+}
@@ -0,0 +1,262 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// Column group generator types. Both sample a Gaussian copula over the
+// declared correlation matrix; multivariate_normal is the special case with
+// normal marginals given as 'mean' and 'std' lists.
+const (
+	GroupMultivariateNormal = "multivariate_normal"
+	GroupGaussianCopula     = "gaussian_copula"
+)
+
+// Correlated samples the columns of a column group jointly.
+type Correlated struct {
+	chol      [][]float64 // lower-triangular factor of the correlation matrix
+	marginals []func(z float64) float64
+}
+
+// NewCorrelated checks a column group generator for n columns and prepares
+// its sampler.
+func NewCorrelated(spec domain.GeneratorSpec, n int) (*Correlated, error) {
+	corr, err := correlationMatrix(spec.Params["correlation"], n)
+	if err != nil {
+		return nil, err
+	}
+	chol, err := choleskyPSD(corr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Correlated{chol: chol, marginals: make([]func(float64) float64, n)}
+	switch spec.Type {
+	case GroupMultivariateNormal:
+		means, err := numberList(spec.Params["mean"], "mean", n)
+		if err != nil {
+			return nil, err
+		}
+		stds, err := numberList(spec.Params["std"], "std", n)
+		if err != nil {
+			return nil, err
+		}
+		for i := range c.marginals {
+			if stds[i] < 0 {
+				return nil, fmt.Errorf("'std' must be >= 0, got %v", stds[i])
+			}
+			mean, std := means[i], stds[i]
+			c.marginals[i] = func(z float64) float64 { return mean + std*z }
+		}
+	case GroupGaussianCopula:
+		raw, ok := spec.Params["marginals"].([]interface{})
+		if !ok || len(raw) != n {
+			return nil, fmt.Errorf("gaussian_copula requires 'marginals', one per column (%d)", n)
+		}
+		for i, m := range raw {
+			f, err := newMarginal(m)
+			if err != nil {
+				return nil, fmt.Errorf("marginal %d: %w", i+1, err)
+			}
+			c.marginals[i] = f
+		}
+	default:
+		return nil, fmt.Errorf("unknown column group generator: %s", spec.Type)
+	}
+	return c, nil
+}
+
+// Sample returns one joint draw, in column order.
+func (c *Correlated) Sample(rng *rand.Rand) []float64 {
+	n := len(c.chol)
+	e := make([]float64, n)
+	for i := range e {
+		e[i] = rng.NormFloat64()
+	}
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		z := 0.0
+		for k := 0; k <= i; k++ {
+			z += c.chol[i][k] * e[k]
+		}
+		out[i] = c.marginals[i](z)
+	}
+	return out
+}
+
+// newMarginal maps a standard normal draw to a marginal distribution.
+func newMarginal(v interface{}) (func(z float64) float64, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("must be a map with a 'type'")
+	}
+	param := func(name string) (float64, error) {
+		f, ok := number(m[name])
+		if !ok {
+			return 0, fmt.Errorf("%v marginal requires a numeric '%s'", m["type"], name)
+		}
+		return f, nil
+	}
+	switch m["type"] {
+	case "normal":
+		mean, err := param("mean")
+		if err != nil {
+			return nil, err
+		}
+		std, err := param("std")
+		if err != nil {
+			return nil, err
+		}
+		if std < 0 {
+			return nil, fmt.Errorf("'std' must be >= 0, got %v", std)
+		}
+		return func(z float64) float64 { return mean + std*z }, nil
+	case "lognormal":
+		mu, err := param("mu")
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := param("sigma")
+		if err != nil {
+			return nil, err
+		}
+		if sigma < 0 {
+			return nil, fmt.Errorf("'sigma' must be >= 0, got %v", sigma)
+		}
+		return func(z float64) float64 { return math.Exp(mu + sigma*z) }, nil
+	case "uniform":
+		lo, err := param("min")
+		if err != nil {
+			return nil, err
+		}
+		hi, err := param("max")
+		if err != nil {
+			return nil, err
+		}
+		if hi <= lo {
+			return nil, fmt.Errorf("max (%v) must be greater than min (%v)", hi, lo)
+		}
+		return func(z float64) float64 { return lo + (hi-lo)*normalCDF(z) }, nil
+	case "empirical":
+		raw, ok := m["values"].([]interface{})
+		if !ok || len(raw) == 0 {
+			return nil, errors.New("empirical marginal requires a non-empty 'values' list")
+		}
+		values, err := numberList(raw, "values", len(raw))
+		if err != nil {
+			return nil, err
+		}
+		sort.Float64s(values)
+		return func(z float64) float64 {
+			i := int(normalCDF(z) * float64(len(values)))
+			return values[min(i, len(values)-1)]
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown marginal type %v (want normal, lognormal, uniform or empirical)", m["type"])
+}
+
+func normalCDF(z float64) float64 { return 0.5 * math.Erfc(-z/math.Sqrt2) }
+
+func correlationMatrix(v interface{}, n int) ([][]float64, error) {
+	rows, ok := v.([]interface{})
+	if !ok || len(rows) != n {
+		return nil, fmt.Errorf("'correlation' must be a %dx%d matrix", n, n)
+	}
+	m := make([][]float64, n)
+	for i, r := range rows {
+		row, err := numberList(r, "correlation", n)
+		if err != nil {
+			return nil, fmt.Errorf("'correlation' must be a %dx%d matrix", n, n)
+		}
+		m[i] = row
+	}
+	for i := 0; i < n; i++ {
+		if m[i][i] != 1 {
+			return nil, fmt.Errorf("correlation[%d][%d] must be 1, got %v", i, i, m[i][i])
+		}
+		for j := 0; j < i; j++ {
+			if math.Abs(m[i][j]-m[j][i]) > 1e-9 {
+				return nil, fmt.Errorf("correlation matrix must be symmetric: [%d][%d]=%v, [%d][%d]=%v", i, j, m[i][j], j, i, m[j][i])
+			}
+			if m[i][j] < -1 || m[i][j] > 1 {
+				return nil, fmt.Errorf("correlation[%d][%d] must be between -1 and 1, got %v", i, j, m[i][j])
+			}
+		}
+	}
+	return m, nil
+}
+
+// choleskyPSD factors a positive semi-definite matrix as L·Lᵀ. Zero pivots
+// (perfectly dependent columns) are allowed; a negative pivot means the
+// matrix is not positive semi-definite.
+func choleskyPSD(a [][]float64) ([][]float64, error) {
+	const eps = 1e-9
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		d := a[j][j]
+		for k := 0; k < j; k++ {
+			d -= l[j][k] * l[j][k]
+		}
+		if d < -eps {
+			return nil, errors.New("correlation matrix is not positive semi-definite")
+		}
+		if d <= eps {
+			for i := j + 1; i < n; i++ {
+				s := a[i][j]
+				for k := 0; k < j; k++ {
+					s -= l[i][k] * l[j][k]
+				}
+				if math.Abs(s) > eps {
+					return nil, errors.New("correlation matrix is not positive semi-definite")
+				}
+			}
+			continue
+		}
+		l[j][j] = math.Sqrt(d)
+		for i := j + 1; i < n; i++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			l[i][j] = s / l[j][j]
+		}
+	}
+	return l, nil
+}
+
+func numberList(v interface{}, name string, n int) ([]float64, error) {
+	raw, ok := v.([]interface{})
+	if !ok || len(raw) != n {
+		return nil, fmt.Errorf("'%s' must be a list of %d numbers", name, n)
+	}
+	out := make([]float64, n)
+	for i, x := range raw {
+		f, ok := number(x)
+		if !ok {
+			return nil, fmt.Errorf("'%s' must be a list of %d numbers", name, n)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,161 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// DictionaryGenerator samples values, optionally weighted, from a CSV, TSV or
+// JSON file under the scenarios directory:
+//
+//	type: dictionary
+//	params:
+//	  file: data/surnames.csv   # relative to SDGEN_SCENARIOS_DIR
+//	  column: surname           # default: first column ("value" for JSON objects)
+//	  weight_column: frequency  # optional
+//
+// The file is read into the 'values' and 'weights' params, with a 'digest'
+// of its contents, when a scenario is validated or run.
+type DictionaryGenerator struct{}
+
+// Dictionary is a dictionary's values converted to the column type, ready to
+// sample.
+type Dictionary struct {
+	values []interface{}
+	cum    []float64 // cumulative weights; nil when unweighted
+}
+
+func (g *DictionaryGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("dictionary generator requires params")
+}
+
+func (g *DictionaryGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	_, err := NewDictionary(spec.Params, columnType)
+	return err
+}
+
+// DictionaryKey identifies a loaded dictionary for caching its prepared
+// form.
+func DictionaryKey(params map[string]interface{}, columnType domain.ColumnType) string {
+	return fmt.Sprintf("%v|%v|%v|%s", params["file"], params["column"], params["weight_column"], columnType)
+}
+
+// NewDictionary checks a loaded dictionary and converts its values to the
+// column type.
+func NewDictionary(params map[string]interface{}, columnType domain.ColumnType) (*Dictionary, error) {
+	file, ok := params["file"].(string)
+	if !ok || file == "" {
+		return nil, errors.New("dictionary requires a 'file' param")
+	}
+	raw, ok := params["values"]
+	if !ok {
+		return nil, fmt.Errorf("dictionary file '%s' is not loaded; dictionaries are read from the scenarios directory", file)
+	}
+	values, ok := raw.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("dictionary file '%s' has no values", file)
+	}
+	d := &Dictionary{values: make([]interface{}, len(values))}
+	for i, v := range values {
+		cv, err := convertValue(v, columnType)
+		if err != nil {
+			return nil, fmt.Errorf("dictionary file '%s', entry %d: %w", file, i+1, err)
+		}
+		d.values[i] = cv
+	}
+	if raw, ok := params["weights"]; ok {
+		weights, ok := raw.([]interface{})
+		if !ok || len(weights) != len(values) {
+			return nil, errors.New("'weights' and 'values' must have the same length")
+		}
+		d.cum = make([]float64, len(weights))
+		total := 0.0
+		for i, w := range weights {
+			f, ok := number(w)
+			if !ok || f < 0 {
+				return nil, fmt.Errorf("dictionary file '%s', entry %d: weight must be a non-negative number, got %v", file, i+1, w)
+			}
+			total += f
+			d.cum[i] = total
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("dictionary file '%s': total weight is zero", file)
+		}
+	}
+	return d, nil
+}
+
+// Sample returns a random entry.
+func (d *Dictionary) Sample(rng *rand.Rand) interface{} {
+	if d.cum == nil {
+		return d.values[rng.Intn(len(d.values))]
+	}
+	r := rng.Float64() * d.cum[len(d.cum)-1]
+	i := sort.Search(len(d.cum), func(i int) bool { return d.cum[i] > r })
+	return d.values[min(i, len(d.values)-1)]
+}
+
+// convertValue converts a dictionary entry, read as text from CSV/TSV or as
+// a JSON scalar, to the column type.
+func convertValue(v interface{}, columnType domain.ColumnType) (interface{}, error) {
+	s, isString := v.(string)
+	switch columnType {
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		if isString {
+			i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", s)
+			}
+			return i, nil
+		}
+		if f, ok := number(v); ok && f == float64(int64(f)) {
+			return int64(f), nil
+		}
+		return nil, fmt.Errorf("%v is not an integer", v)
+	case domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+		if isString {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", s)
+			}
+			return f, nil
+		}
+		if f, ok := number(v); ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("%v is not a number", v)
+	case domain.ColumnTypeBool:
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a bool", s)
+			}
+			return b, nil
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("%v is not a bool", v)
+	case domain.ColumnTypeString, domain.ColumnTypeText, domain.ColumnTypeUUID:
+		if isString {
+			return s, nil
+		}
+		if v == nil {
+			return nil, errors.New("null entry")
+		}
+		if f, ok := v.(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	if v == nil {
+		return nil, errors.New("null entry")
+	}
+	return v, nil
+}
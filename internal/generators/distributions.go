@@ -0,0 +1,394 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// DistributionGenerator samples one of the statistical distributions below.
+// Every distribution also accepts optional 'min'/'max' (clamp the sample)
+// and 'decimals' (round float output). Int columns receive rounded int64
+// values, float columns float64 and, for bernoulli, bool columns a bool.
+type DistributionGenerator struct {
+	name string
+	dist *distribution
+}
+
+type distribution struct {
+	params []string
+	// ints lists params that must be whole numbers.
+	ints   []string
+	check  func(p map[string]float64) error
+	sample func(rng *rand.Rand, p map[string]float64) float64
+}
+
+var distributions = map[string]*distribution{
+	"lognormal": {
+		params: []string{"mu", "sigma"},
+		check:  positive("sigma"),
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return math.Exp(p["mu"] + p["sigma"]*rng.NormFloat64())
+		},
+	},
+	"exponential": {
+		params: []string{"rate"},
+		check:  positive("rate"),
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return rng.ExpFloat64() / p["rate"]
+		},
+	},
+	"poisson": {
+		params: []string{"lambda"},
+		check:  positive("lambda"),
+		sample: func(rng *rand.Rand, p map[string]float64) float64 { return poisson(rng, p["lambda"]) },
+	},
+	"binomial": {
+		params: []string{"n", "p"},
+		ints:   []string{"n"},
+		check: func(p map[string]float64) error {
+			if p["n"] < 0 {
+				return fmt.Errorf("'n' must be >= 0, got %v", p["n"])
+			}
+			return probability("p", p["p"])
+		},
+		sample: func(rng *rand.Rand, p map[string]float64) float64 { return binomial(rng, int64(p["n"]), p["p"]) },
+	},
+	"geometric": {
+		params: []string{"p"},
+		check: func(p map[string]float64) error {
+			if p["p"] <= 0 || p["p"] > 1 {
+				return fmt.Errorf("'p' must be in (0, 1], got %v", p["p"])
+			}
+			return nil
+		},
+		// number of trials up to and including the first success
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			if p["p"] == 1 {
+				return 1
+			}
+			return math.Max(1, math.Ceil(math.Log(1-rng.Float64())/math.Log(1-p["p"])))
+		},
+	},
+	"zipf": {
+		params: []string{"s", "n"},
+		ints:   []string{"n"},
+		check: func(p map[string]float64) error {
+			if p["s"] <= 1 {
+				return fmt.Errorf("'s' must be > 1, got %v", p["s"])
+			}
+			if p["n"] < 1 {
+				return fmt.Errorf("'n' must be >= 1, got %v", p["n"])
+			}
+			return nil
+		},
+		// rank in 1..n with P(k) proportional to k^-s
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return float64(rand.NewZipf(rng, p["s"], 1, uint64(p["n"])-1).Uint64() + 1)
+		},
+	},
+	"pareto": {
+		params: []string{"xm", "alpha"},
+		check: func(p map[string]float64) error {
+			if err := positive("xm")(p); err != nil {
+				return err
+			}
+			return positive("alpha")(p)
+		},
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return p["xm"] / math.Pow(1-rng.Float64(), 1/p["alpha"])
+		},
+	},
+	"beta": {
+		params: []string{"alpha", "beta"},
+		check: func(p map[string]float64) error {
+			if err := positive("alpha")(p); err != nil {
+				return err
+			}
+			return positive("beta")(p)
+		},
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			x := gamma(rng, p["alpha"])
+			return x / (x + gamma(rng, p["beta"]))
+		},
+	},
+	"gamma": {
+		params: []string{"shape", "scale"},
+		check: func(p map[string]float64) error {
+			if err := positive("shape")(p); err != nil {
+				return err
+			}
+			return positive("scale")(p)
+		},
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return gamma(rng, p["shape"]) * p["scale"]
+		},
+	},
+	// truncated_normal samples within [min, max] instead of clamping to it.
+	"truncated_normal": {
+		params: []string{"mean", "std", "min", "max"},
+		check:  positive("std"),
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			return truncatedNormal(rng, p["mean"], p["std"], p["min"], p["max"])
+		},
+	},
+	"bernoulli": {
+		params: []string{"p"},
+		check:  func(p map[string]float64) error { return probability("p", p["p"]) },
+		sample: func(rng *rand.Rand, p map[string]float64) float64 {
+			if rng.Float64() < p["p"] {
+				return 1
+			}
+			return 0
+		},
+	},
+}
+
+// DistributionNames returns the names of the distribution generators.
+func DistributionNames() []string {
+	names := make([]string, 0, len(distributions))
+	for name := range distributions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDistributionGenerator returns the generator for a distribution listed
+// by DistributionNames.
+func NewDistributionGenerator(name string) *DistributionGenerator {
+	d, ok := distributions[name]
+	if !ok {
+		panic("unknown distribution: " + name)
+	}
+	return &DistributionGenerator{name: name, dist: d}
+}
+
+func (g *DistributionGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, fmt.Errorf("%s generator requires params", g.name)
+}
+
+func (g *DistributionGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	switch columnType {
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt, domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+	case domain.ColumnTypeBool:
+		if g.name != "bernoulli" {
+			return fmt.Errorf("%s requires a numeric column", g.name)
+		}
+	default:
+		return fmt.Errorf("%s requires a numeric column", g.name)
+	}
+	p, err := g.params(spec.Params)
+	if err != nil {
+		return err
+	}
+	for _, name := range g.dist.ints {
+		if p[name] != math.Trunc(p[name]) {
+			return fmt.Errorf("'%s' must be a whole number, got %v", name, p[name])
+		}
+	}
+	if err := g.dist.check(p); err != nil {
+		return err
+	}
+	lo, hasMin := p["min"]
+	hi, hasMax := p["max"]
+	if hasMin && hasMax && lo > hi {
+		return fmt.Errorf("min (%v) must not be greater than max (%v)", lo, hi)
+	}
+	if g.name == "truncated_normal" && lo == hi {
+		return errors.New("truncated_normal requires min < max")
+	}
+	if d, ok := p["decimals"]; ok {
+		if d < 0 || d != math.Trunc(d) {
+			return fmt.Errorf("'decimals' must be a non-negative whole number, got %v", d)
+		}
+		if columnType != domain.ColumnTypeFloat && columnType != domain.ColumnTypeDouble {
+			return errors.New("'decimals' only applies to float columns")
+		}
+	}
+	return nil
+}
+
+// GenerateWithParams samples the distribution and converts the value to the
+// column type.
+func (g *DistributionGenerator) GenerateWithParams(rng *rand.Rand, params map[string]interface{}, columnType domain.ColumnType) (interface{}, error) {
+	p, err := g.params(params)
+	if err != nil {
+		return nil, err
+	}
+	x := g.dist.sample(rng, p)
+	if lo, ok := p["min"]; ok {
+		x = math.Max(x, lo)
+	}
+	if hi, ok := p["max"]; ok {
+		x = math.Min(x, hi)
+	}
+	switch columnType {
+	case domain.ColumnTypeBool:
+		return x != 0, nil
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		return int64(math.Round(x)), nil
+	}
+	if d, ok := p["decimals"]; ok {
+		scale := math.Pow(10, d)
+		x = math.Round(x*scale) / scale
+	}
+	return x, nil
+}
+
+// params reads the distribution's required params and the shared options.
+func (g *DistributionGenerator) params(raw map[string]interface{}) (map[string]float64, error) {
+	p := make(map[string]float64, len(g.dist.params)+3)
+	var missing []string
+	for _, name := range g.dist.params {
+		v, ok := raw[name]
+		if !ok {
+			missing = append(missing, "'"+name+"'")
+			continue
+		}
+		f, ok := number(v)
+		if !ok {
+			return nil, fmt.Errorf("'%s' must be a number", name)
+		}
+		p[name] = f
+	}
+	if len(missing) > 0 {
+		noun := "param"
+		if len(missing) > 1 {
+			noun = "params"
+		}
+		return nil, fmt.Errorf("%s requires %s %s", g.name, strings.Join(missing, " and "), noun)
+	}
+	for _, name := range []string{"min", "max", "decimals"} {
+		if _, required := p[name]; required {
+			continue
+		}
+		if v, ok := raw[name]; ok {
+			f, ok := number(v)
+			if !ok {
+				return nil, fmt.Errorf("'%s' must be a number", name)
+			}
+			p[name] = f
+		}
+	}
+	return p, nil
+}
+
+func positive(name string) func(p map[string]float64) error {
+	return func(p map[string]float64) error {
+		if p[name] <= 0 {
+			return fmt.Errorf("'%s' must be > 0, got %v", name, p[name])
+		}
+		return nil
+	}
+}
+
+func probability(name string, v float64) error {
+	if v < 0 || v > 1 {
+		return fmt.Errorf("'%s' must be between 0 and 1, got %v", name, v)
+	}
+	return nil
+}
+
+// gamma samples Gamma(shape, 1) with Marsaglia and Tsang's method.
+func gamma(rng *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return gamma(rng, shape+1) * math.Pow(rng.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// poisson uses Knuth's multiplication method for small lambda and Hörmann's
+// transformed rejection (PTRS) otherwise.
+func poisson(rng *rand.Rand, lambda float64) float64 {
+	if lambda < 10 {
+		l := math.Exp(-lambda)
+		k := 0.0
+		for p := rng.Float64(); p > l; p *= rng.Float64() {
+			k++
+		}
+		return k
+	}
+	slam := math.Sqrt(lambda)
+	loglam := math.Log(lambda)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invalpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		u := rng.Float64() - 0.5
+		v := rng.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return k
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invalpha)-math.Log(a/(us*us)+b) <= -lambda+k*loglam-lg {
+			return k
+		}
+	}
+}
+
+// binomial splits large n with beta variates (Knuth, TAOCP 3.4.1) and counts
+// Bernoulli trials once n is small.
+func binomial(rng *rand.Rand, n int64, p float64) float64 {
+	var k int64
+	for n > 64 {
+		i := n/2 + 1
+		x := gamma(rng, float64(i))
+		x /= x + gamma(rng, float64(n+1-i))
+		if x >= p {
+			n = i - 1
+			p /= x
+		} else {
+			k += i
+			n -= i
+			p = (p - x) / (1 - x)
+		}
+	}
+	for ; n > 0; n-- {
+		if rng.Float64() < p {
+			k++
+		}
+	}
+	return float64(k)
+}
+
+// truncatedNormal inverts the normal CDF over [lo, hi], sampling the tail
+// nearest the mean to keep precision.
+func truncatedNormal(rng *rand.Rand, mean, std, lo, hi float64) float64 {
+	a, b := (lo-mean)/std, (hi-mean)/std
+	flip := a > 0
+	if flip {
+		a, b = -b, -a
+	}
+	pa, pb := normalCDF(a), normalCDF(b)
+	z := math.Sqrt2 * math.Erfinv(2*(pa+rng.Float64()*(pb-pa))-1)
+	z = math.Max(a, math.Min(b, z))
+	if flip {
+		z = -z
+	}
+	return mean + std*z
+}
@@ -0,0 +1,37 @@
+package generators
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/expr"
+)
+
+// ExprGenerator computes a column from other columns of the same row. The
+// expression is type-checked against the entity's columns during scenario
+// validation and evaluated by the executor, which generates the columns it
+// reads first.
+type ExprGenerator struct{}
+
+func (g *ExprGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("expr generator requires row context")
+}
+
+func (g *ExprGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	src, err := ExprSource(spec)
+	if err != nil {
+		return err
+	}
+	_, err = expr.References(src)
+	return err
+}
+
+// ExprSource returns the expression of an expr generator spec.
+func ExprSource(spec domain.GeneratorSpec) (string, error) {
+	src, ok := spec.Params["expression"].(string)
+	if !ok || src == "" {
+		return "", errors.New("expr requires an 'expression' string param")
+	}
+	return src, nil
+}
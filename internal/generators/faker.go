@@ -1,6 +1,7 @@
 package generators
 
 import (
+	"fmt"
 	"math/rand"
 
 	"github.com/go-faker/faker/v4"
@@ -53,3 +54,18 @@ func (g *FakerDeviceNameGenerator) Generate(rng *rand.Rand, ctx GeneratorContext
 func (g *FakerDeviceNameGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
 	return nil
 }
+
+// FakerEmailGenerator builds addresses on reserved example domains so generated
+// data never points at real mailboxes.
+type FakerEmailGenerator struct{}
+
+func (g *FakerEmailGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	first := []string{"alex", "sam", "maria", "li", "noah", "emma", "omar", "yuki", "lena", "ivan", "sara", "david"}
+	last := []string{"smith", "garcia", "chen", "mueller", "rossi", "kim", "novak", "silva", "haddad", "tanaka"}
+	domains := []string{"example.com", "example.org", "example.net"}
+	return fmt.Sprintf("%s.%s%d@%s", first[rng.Intn(len(first))], last[rng.Intn(len(last))], rng.Intn(10000), domains[rng.Intn(len(domains))]), nil
+}
+
+func (g *FakerEmailGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	return nil
+}
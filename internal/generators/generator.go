@@ -1,9 +1,12 @@
 package generators
 
 import (
+	"fmt"
 	"math/rand"
+	"sort"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/expr"
 )
 
 type Generator interface {
@@ -14,4 +17,93 @@ type Generator interface {
 type GeneratorContext struct {
 	RowIndex     int64
 	EntityValues map[string][]interface{}
+	// Row holds the values generated so far for the current row, keyed by
+	// column name. It is only set for entities with row-dependent columns.
+	Row map[string]interface{}
+}
+
+// NestedSpec is a generator spec nested in another one's params.
+type NestedSpec struct {
+	Label string
+	Spec  domain.GeneratorSpec
+}
+
+// NestedSpecs returns the generator specs nested directly in spec, in a
+// stable order.
+func NestedSpecs(spec domain.GeneratorSpec) ([]NestedSpec, error) {
+	switch spec.Type {
+	case "switch":
+		sw, err := ParseSwitch(spec)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(sw.Cases))
+		for k := range sw.Cases {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]NestedSpec, 0, len(keys)+1)
+		for _, k := range keys {
+			out = append(out, NestedSpec{Label: fmt.Sprintf("case '%s'", k), Spec: sw.Cases[k]})
+		}
+		if sw.Default != nil {
+			out = append(out, NestedSpec{Label: "default", Spec: *sw.Default})
+		}
+		return out, nil
+	case "mixture":
+		components, err := ParseMixture(spec)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]NestedSpec, len(components))
+		for i, c := range components {
+			out[i] = NestedSpec{Label: fmt.Sprintf("component %d", i+1), Spec: c.Spec}
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+// RowDependencies returns the columns of the same row that a generator spec,
+// including the specs nested in it, reads; those columns are generated first.
+func RowDependencies(spec domain.GeneratorSpec) ([]string, error) {
+	var deps []string
+	switch spec.Type {
+	case "expr":
+		src, err := ExprSource(spec)
+		if err != nil {
+			return nil, err
+		}
+		if deps, err = expr.References(src); err != nil {
+			return nil, err
+		}
+	case "switch":
+		sw, err := ParseSwitch(spec)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, sw.Column)
+	}
+	nested, err := NestedSpecs(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range nested {
+		d, err := RowDependencies(n.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.Label, err)
+		}
+		deps = append(deps, d...)
+	}
+	return deps, nil
+}
+
+// NullRate returns the optional "null_rate" param shared by all generators:
+// the fraction of rows of a nullable column that are generated as NULL.
+func NullRate(spec domain.GeneratorSpec) float64 {
+	v, ok := spec.Params["null_rate"]
+	if !ok {
+		return 0
+	}
+	return toFloat64(v)
 }
@@ -0,0 +1,124 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// HistogramGenerator picks a bin by weight and a uniform value inside it:
+//
+//	type: histogram
+//	params:
+//	  bins:
+//	    - {min: 0, max: 18, weight: 22}
+//	    - {min: 18, max: 65, weight: 61}
+//	    - {min: 65, max: 100, weight: 17}
+//
+// Bins are half-open ([min, max)); int columns receive the floor of the
+// value, float columns accept optional 'decimals'.
+type HistogramGenerator struct{}
+
+type histogramBin struct {
+	min, max, weight float64
+}
+
+func (g *HistogramGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("histogram generator requires params")
+}
+
+func (g *HistogramGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	switch columnType {
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt, domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+	default:
+		return errors.New("histogram requires a numeric column")
+	}
+	bins, err := histogramBins(spec.Params)
+	if err != nil {
+		return err
+	}
+	for i, b := range bins {
+		if (columnType == domain.ColumnTypeInt || columnType == domain.ColumnTypeBigInt) && math.Ceil(b.min) >= b.max {
+			return fmt.Errorf("bin %d holds no integer", i+1)
+		}
+	}
+	if raw, ok := spec.Params["decimals"]; ok {
+		d, ok := number(raw)
+		if !ok || d < 0 || d != math.Trunc(d) {
+			return fmt.Errorf("'decimals' must be a non-negative whole number, got %v", raw)
+		}
+		if columnType != domain.ColumnTypeFloat && columnType != domain.ColumnTypeDouble {
+			return errors.New("'decimals' only applies to float columns")
+		}
+	}
+	return nil
+}
+
+func (g *HistogramGenerator) GenerateWithParams(rng *rand.Rand, params map[string]interface{}, columnType domain.ColumnType) (interface{}, error) {
+	bins, err := histogramBins(params)
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, b := range bins {
+		total += b.weight
+	}
+	r := rng.Float64() * total
+	bin := bins[len(bins)-1]
+	for _, b := range bins {
+		if r < b.weight {
+			bin = b
+			break
+		}
+		r -= b.weight
+	}
+	if columnType == domain.ColumnTypeInt || columnType == domain.ColumnTypeBigInt {
+		lo, hi := int64(math.Ceil(bin.min)), int64(math.Ceil(bin.max))
+		return lo + rng.Int63n(hi-lo), nil
+	}
+	x := bin.min + rng.Float64()*(bin.max-bin.min)
+	if d, ok := number(params["decimals"]); ok {
+		scale := math.Pow(10, d)
+		x = math.Round(x*scale) / scale
+	}
+	return x, nil
+}
+
+func histogramBins(params map[string]interface{}) ([]histogramBin, error) {
+	raw, ok := params["bins"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, errors.New("histogram requires a non-empty 'bins' list")
+	}
+	bins := make([]histogramBin, len(raw))
+	total := 0.0
+	for i, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("bin %d must be a map with 'min', 'max' and 'weight'", i+1)
+		}
+		var b histogramBin
+		for j, dst := range []*float64{&b.min, &b.max, &b.weight} {
+			name := [...]string{"min", "max", "weight"}[j]
+			f, ok := number(m[name])
+			if !ok {
+				return nil, fmt.Errorf("bin %d requires a numeric '%s'", i+1, name)
+			}
+			*dst = f
+		}
+		if b.max <= b.min {
+			return nil, fmt.Errorf("bin %d: max (%v) must be greater than min (%v)", i+1, b.max, b.min)
+		}
+		if b.weight < 0 {
+			return nil, fmt.Errorf("bin %d: weight must be >= 0, got %v", i+1, b.weight)
+		}
+		total += b.weight
+		bins[i] = b
+	}
+	if total == 0 {
+		return nil, errors.New("histogram total weight is zero")
+	}
+	return bins, nil
+}
@@ -0,0 +1,89 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// MixtureGenerator samples a weighted mix of nested generators: each row
+// picks a component by weight, then a value from the component's spec:
+//
+//	type: mixture
+//	params:
+//	  components:
+//	    - {weight: 0.6, generator: {type: lognormal, params: {mu: 1.5, sigma: 0.6}}}
+//	    - {weight: 0.38, generator: {type: normal, params: {mean: 80, std: 25}}}
+//	    - {weight: 0.02, generator: {type: uniform_float, params: {min: 5000, max: 45000}}}
+//
+// Weights are relative and need not sum to 1.
+type MixtureGenerator struct{}
+
+// MixtureComponent is one weighted component of a mixture.
+type MixtureComponent struct {
+	Weight float64
+	Spec   domain.GeneratorSpec
+}
+
+func (g *MixtureGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("mixture generator requires params")
+}
+
+func (g *MixtureGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	_, err := ParseMixture(spec)
+	return err
+}
+
+// Select picks the component spec for the next value from components parsed
+// by ParseMixture.
+func (g *MixtureGenerator) Select(rng *rand.Rand, components []MixtureComponent) domain.GeneratorSpec {
+	total := 0.0
+	for _, c := range components {
+		total += c.Weight
+	}
+	r := rng.Float64() * total
+	for _, c := range components {
+		if r < c.Weight {
+			return c.Spec
+		}
+		r -= c.Weight
+	}
+	return components[len(components)-1].Spec
+}
+
+// ParseMixture checks and decodes a mixture generator's components.
+func ParseMixture(spec domain.GeneratorSpec) ([]MixtureComponent, error) {
+	raw, ok := spec.Params["components"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, errors.New("mixture requires a non-empty 'components' list")
+	}
+	out := make([]MixtureComponent, len(raw))
+	total := 0.0
+	for i, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("component %d must be a map with 'weight' and 'generator'", i+1)
+		}
+		for k := range m {
+			if k != "weight" && k != "generator" {
+				return nil, fmt.Errorf("component %d: unknown field '%s'", i+1, k)
+			}
+		}
+		w, ok := number(m["weight"])
+		if !ok || w < 0 {
+			return nil, fmt.Errorf("component %d: 'weight' must be a non-negative number", i+1)
+		}
+		c, err := specFromValue(m["generator"])
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", i+1, err)
+		}
+		total += w
+		out[i] = MixtureComponent{Weight: w, Spec: c}
+	}
+	if total == 0 {
+		return nil, errors.New("mixture total weight is zero")
+	}
+	return out, nil
+}
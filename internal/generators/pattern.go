@@ -0,0 +1,462 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// PatternGenerator produces strings matching a restricted regular
+// expression:
+//
+//	type: pattern
+//	params:
+//	  pattern: 'ACC-[A-Z]{3}-\d{6}'
+//	  unique: true   # optional: no value repeats within the run
+//
+// Supported: literals, escapes (\d \w \s and escaped metacharacters), '.'
+// (printable ASCII), classes ([A-Z0-9_], [^...]), groups ((...), (?:...)),
+// alternation and the quantifiers ? {n} {n,m}. Unbounded quantifiers (* +
+// {n,}), backreferences and lookarounds are rejected; ^ and $ are accepted
+// at the ends and ignored.
+type PatternGenerator struct{}
+
+// maxRepeat bounds {n,m} and maxLength the longest string a pattern can
+// produce, so nested quantifiers cannot ask for huge strings either.
+const (
+	maxRepeat = 1000
+	maxLength = 10000
+)
+
+// Pattern is a parsed pattern, ready to sample.
+type Pattern struct {
+	root patternNode
+}
+
+func (g *PatternGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("pattern generator requires params")
+}
+
+func (g *PatternGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	if columnType != domain.ColumnTypeString && columnType != domain.ColumnTypeText {
+		return errors.New("pattern requires a string or text column")
+	}
+	if raw, ok := spec.Params["unique"]; ok {
+		if _, ok := raw.(bool); !ok {
+			return errors.New("'unique' must be a bool")
+		}
+	}
+	_, err := PatternOf(spec)
+	return err
+}
+
+// PatternOf parses a pattern generator's 'pattern' param.
+func PatternOf(spec domain.GeneratorSpec) (*Pattern, error) {
+	src, ok := spec.Params["pattern"].(string)
+	if !ok || src == "" {
+		return nil, errors.New("pattern requires a 'pattern' param")
+	}
+	return ParsePattern(src)
+}
+
+// PatternUnique reports whether a pattern generator asks for unique values.
+func PatternUnique(spec domain.GeneratorSpec) bool {
+	unique, _ := spec.Params["unique"].(bool)
+	return unique
+}
+
+// ParsePattern parses a restricted regular expression.
+func ParsePattern(src string) (*Pattern, error) {
+	p := &patternParser{src: src}
+	if strings.HasPrefix(p.src, "^") {
+		p.pos++
+	}
+	root, err := p.alternation()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) && p.src[p.pos:] != "$" {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	if root.maxLen() > maxLength {
+		return nil, fmt.Errorf("invalid pattern: strings can be longer than %d characters", maxLength)
+	}
+	return &Pattern{root: root}, nil
+}
+
+// Generate returns a random string matching the pattern.
+func (p *Pattern) Generate(rng *rand.Rand) string {
+	var sb strings.Builder
+	p.root.generate(rng, &sb)
+	return sb.String()
+}
+
+// Space estimates how many distinct strings the pattern produces. It is
+// exact unless alternatives overlap, and may be +Inf.
+func (p *Pattern) Space() float64 {
+	return p.root.space()
+}
+
+type patternNode interface {
+	generate(rng *rand.Rand, sb *strings.Builder)
+	space() float64
+	// maxLen is the longest output in runes, saturating above maxLength.
+	maxLen() int
+}
+
+type literalNode string
+
+func (n literalNode) generate(_ *rand.Rand, sb *strings.Builder) { sb.WriteString(string(n)) }
+func (n literalNode) space() float64                             { return 1 }
+func (n literalNode) maxLen() int                                { return utf8.RuneCountInString(string(n)) }
+
+type classNode []rune
+
+func (n classNode) generate(rng *rand.Rand, sb *strings.Builder) {
+	sb.WriteRune(n[rng.Intn(len(n))])
+}
+func (n classNode) space() float64 { return float64(len(n)) }
+func (n classNode) maxLen() int    { return 1 }
+
+type concatNode []patternNode
+
+func (n concatNode) generate(rng *rand.Rand, sb *strings.Builder) {
+	for _, c := range n {
+		c.generate(rng, sb)
+	}
+}
+
+func (n concatNode) space() float64 {
+	s := 1.0
+	for _, c := range n {
+		s *= c.space()
+	}
+	return s
+}
+
+func (n concatNode) maxLen() int {
+	l := 0
+	for _, c := range n {
+		l = min(l+c.maxLen(), maxLength+1)
+	}
+	return l
+}
+
+type altNode []patternNode
+
+func (n altNode) generate(rng *rand.Rand, sb *strings.Builder) {
+	n[rng.Intn(len(n))].generate(rng, sb)
+}
+
+func (n altNode) space() float64 {
+	s := 0.0
+	for _, c := range n {
+		s += c.space()
+	}
+	return s
+}
+
+func (n altNode) maxLen() int {
+	l := 0
+	for _, c := range n {
+		l = max(l, c.maxLen())
+	}
+	return l
+}
+
+type repeatNode struct {
+	node     patternNode
+	min, max int
+}
+
+func (n repeatNode) generate(rng *rand.Rand, sb *strings.Builder) {
+	count := n.min + rng.Intn(n.max-n.min+1)
+	for i := 0; i < count; i++ {
+		n.node.generate(rng, sb)
+	}
+}
+
+func (n repeatNode) space() float64 {
+	base := n.node.space()
+	s := 0.0
+	for k := n.min; k <= n.max; k++ {
+		s += math.Pow(base, float64(k))
+	}
+	return s
+}
+
+func (n repeatNode) maxLen() int {
+	return min(n.max*n.node.maxLen(), maxLength+1)
+}
+
+type patternParser struct {
+	src string
+	pos int
+}
+
+func (p *patternParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid pattern at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *patternParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *patternParser) atEnd() bool {
+	return p.pos >= len(p.src) || p.src[p.pos:] == "$"
+}
+
+func (p *patternParser) alternation() (patternNode, error) {
+	var alts []patternNode
+	for {
+		n, err := p.concat()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, n)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return altNode(alts), nil
+}
+
+func (p *patternParser) concat() (patternNode, error) {
+	var seq concatNode
+	for !p.atEnd() && p.peek() != '|' && p.peek() != ')' {
+		atom, err := p.atom()
+		if err != nil {
+			return nil, err
+		}
+		if atom, err = p.quantifier(atom); err != nil {
+			return nil, err
+		}
+		seq = append(seq, atom)
+	}
+	if len(seq) == 1 {
+		return seq[0], nil
+	}
+	return seq, nil
+}
+
+func (p *patternParser) atom() (patternNode, error) {
+	switch c := p.peek(); c {
+	case '(':
+		p.pos++
+		if strings.HasPrefix(p.src[p.pos:], "?:") {
+			p.pos += 2
+		} else if p.peek() == '?' {
+			return nil, p.errorf("lookarounds and named groups are not supported")
+		}
+		n, err := p.alternation()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing ')'")
+		}
+		p.pos++
+		return n, nil
+	case '[':
+		return p.class()
+	case '.':
+		p.pos++
+		return classNode(printable), nil
+	case '\\':
+		return p.escape(false)
+	case '*', '+', '?', '{':
+		return nil, p.errorf("quantifier %q has nothing to repeat", c)
+	case '^', '$':
+		return nil, p.errorf("anchor %q is only supported at the ends", c)
+	case ']', '}':
+		return nil, p.errorf("unexpected %q", c)
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return literalNode(string(r)), nil
+}
+
+func (p *patternParser) quantifier(atom patternNode) (patternNode, error) {
+	switch p.peek() {
+	case '*', '+':
+		return nil, p.errorf("unbounded quantifier %q is not supported; use {n,m}", p.peek())
+	case '?':
+		p.pos++
+		return p.lazy(repeatNode{node: atom, min: 0, max: 1})
+	case '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return nil, p.errorf("missing '}'")
+		}
+		body := p.src[p.pos+1 : p.pos+end]
+		lo, hi, found := strings.Cut(body, ",")
+		n, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, p.errorf("invalid quantifier {%s}", body)
+		}
+		m := n
+		if found {
+			if hi == "" {
+				return nil, p.errorf("unbounded quantifier {%s} is not supported; use {n,m}", body)
+			}
+			if m, err = strconv.Atoi(hi); err != nil {
+				return nil, p.errorf("invalid quantifier {%s}", body)
+			}
+		}
+		if n < 0 || m < n || m > maxRepeat {
+			return nil, p.errorf("quantifier {%s} must satisfy 0 <= n <= m <= %d", body, maxRepeat)
+		}
+		p.pos += end + 1
+		return p.lazy(repeatNode{node: atom, min: n, max: m})
+	}
+	return atom, nil
+}
+
+// lazy rejects a lazy or repeated quantifier after the one just parsed.
+func (p *patternParser) lazy(n patternNode) (patternNode, error) {
+	switch p.peek() {
+	case '?', '*', '+', '{':
+		return nil, p.errorf("unexpected %q after quantifier", p.peek())
+	}
+	return n, nil
+}
+
+func (p *patternParser) class() (patternNode, error) {
+	p.pos++ // [
+	negate := false
+	if p.peek() == '^' {
+		negate = true
+		p.pos++
+	}
+	set := map[rune]bool{}
+	first := true
+	for {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("missing ']'")
+		}
+		if p.peek() == ']' && !first {
+			p.pos++
+			break
+		}
+		first = false
+		var lo rune
+		if p.peek() == '\\' {
+			n, err := p.escape(true)
+			if err != nil {
+				return nil, err
+			}
+			if cls, ok := n.(classNode); ok {
+				for _, r := range cls {
+					set[r] = true
+				}
+				continue
+			}
+			lo, _ = utf8.DecodeRuneInString(string(n.(literalNode)))
+		} else {
+			var size int
+			lo, size = utf8.DecodeRuneInString(p.src[p.pos:])
+			p.pos += size
+		}
+		if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' {
+			p.pos++
+			var hi rune
+			if p.peek() == '\\' {
+				n, err := p.escape(true)
+				if err != nil {
+					return nil, err
+				}
+				lit, ok := n.(literalNode)
+				if !ok {
+					return nil, p.errorf("invalid class range")
+				}
+				hi, _ = utf8.DecodeRuneInString(string(lit))
+			} else {
+				var size int
+				hi, size = utf8.DecodeRuneInString(p.src[p.pos:])
+				p.pos += size
+			}
+			if hi < lo {
+				return nil, p.errorf("invalid class range %c-%c", lo, hi)
+			}
+			for r := lo; r <= hi; r++ {
+				set[r] = true
+			}
+			continue
+		}
+		set[lo] = true
+	}
+	var runes []rune
+	if negate {
+		for _, r := range printable {
+			if !set[r] {
+				runes = append(runes, r)
+			}
+		}
+	} else {
+		for r := range set {
+			runes = append(runes, r)
+		}
+		slices.Sort(runes)
+	}
+	if len(runes) == 0 {
+		return nil, p.errorf("empty character class")
+	}
+	return classNode(runes), nil
+}
+
+// escape parses a backslash escape; class escapes yield a classNode.
+func (p *patternParser) escape(inClass bool) (patternNode, error) {
+	p.pos++ // backslash
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("trailing backslash")
+	}
+	c, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	switch c {
+	case 'd':
+		return classNode(digits), nil
+	case 'w':
+		return classNode(wordChars), nil
+	case 's':
+		return literalNode(" "), nil
+	case 'n':
+		return literalNode("\n"), nil
+	case 't':
+		return literalNode("\t"), nil
+	}
+	if c >= '0' && c <= '9' {
+		return nil, p.errorf("backreferences are not supported")
+	}
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return nil, p.errorf("unsupported escape \\%c", c)
+	}
+	return literalNode(string(c)), nil
+}
+
+var (
+	digits    = runeRange('0', '9')
+	wordChars = append(append(append(runeRange('0', '9'), runeRange('A', 'Z')...), '_'), runeRange('a', 'z')...)
+	printable = runeRange('!', '~')
+)
+
+func runeRange(lo, hi rune) []rune {
+	out := make([]rune, 0, hi-lo+1)
+	for r := lo; r <= hi; r++ {
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// OffsetExistingMax is the sequence 'offset_from' value that continues after
+// the largest value already stored in the target (append mode only).
+const OffsetExistingMax = "existing_max"
+
+// SequenceGenerator numbers rows: start, start+step, start+2·step, ...
+//
+//	type: sequence
+//	params:
+//	  start: 1                  # default 1
+//	  step: 1                   # default 1
+//	  format: "INV-%06d"        # optional, string columns only
+//	  offset_from: existing_max # optional, see OffsetExistingMax
+type SequenceGenerator struct{}
+
+// Sequence is the parsed form of a sequence generator's params.
+type Sequence struct {
+	Start      int64
+	Step       int64
+	Format     string
+	OffsetFrom string
+}
+
+// sequenceVerbRe matches the integer verb of a format, e.g. %d or %06d.
+var sequenceVerbRe = regexp.MustCompile(`%[-+ 0]*[0-9]*[dxXob]`)
+
+func (g *SequenceGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("sequence generator requires params")
+}
+
+func (g *SequenceGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	s, err := ParseSequence(spec)
+	if err != nil {
+		return err
+	}
+	switch columnType {
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		if s.Format != "" {
+			return errors.New("'format' requires a string or text column")
+		}
+	case domain.ColumnTypeString, domain.ColumnTypeText:
+		// Stored text values are ranked by length, then lexically.
+		if s.OffsetFrom != "" && s.Start < 0 {
+			return errors.New("'offset_from' on a string column requires a non-negative 'start'")
+		}
+		if s.OffsetFrom != "" && strings.Contains(sequenceVerbRe.FindString(s.Format), "-") {
+			return errors.New("'offset_from' cannot be combined with a left-justified format")
+		}
+	default:
+		return errors.New("sequence requires an int, bigint, string or text column")
+	}
+	return nil
+}
+
+// ParseSequence checks and decodes a sequence generator's params.
+func ParseSequence(spec domain.GeneratorSpec) (*Sequence, error) {
+	s := &Sequence{Start: 1, Step: 1}
+	for _, name := range []string{"start", "step"} {
+		raw, ok := spec.Params[name]
+		if !ok {
+			continue
+		}
+		f, ok := number(raw)
+		if !ok || f != float64(int64(f)) {
+			return nil, fmt.Errorf("'%s' must be a whole number, got %v", name, raw)
+		}
+		if name == "start" {
+			s.Start = int64(f)
+		} else {
+			s.Step = int64(f)
+		}
+	}
+	if s.Step == 0 {
+		return nil, errors.New("'step' must not be 0")
+	}
+	if raw, ok := spec.Params["format"]; ok {
+		format, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("'format' must be a string")
+		}
+		rest := strings.ReplaceAll(sequenceVerbRe.ReplaceAllString(format, ""), "%%", "")
+		if len(sequenceVerbRe.FindAllString(format, -1)) != 1 || strings.Contains(rest, "%") {
+			return nil, fmt.Errorf("'format' must contain exactly one integer verb such as %%06d, got %q", format)
+		}
+		s.Format = format
+	}
+	if raw, ok := spec.Params["offset_from"]; ok {
+		if raw != OffsetExistingMax {
+			return nil, fmt.Errorf("'offset_from' must be %q, got %v", OffsetExistingMax, raw)
+		}
+		if s.Step < 0 {
+			return nil, errors.New("'offset_from' requires a positive 'step'")
+		}
+		s.OffsetFrom = OffsetExistingMax
+	}
+	return s, nil
+}
+
+// Value returns the sequence value of a row, as a string for string columns.
+func (s *Sequence) Value(rowIndex int64, columnType domain.ColumnType) interface{} {
+	n := s.Start + rowIndex*s.Step
+	switch {
+	case s.Format != "":
+		return fmt.Sprintf(s.Format, n)
+	case columnType == domain.ColumnTypeString || columnType == domain.ColumnTypeText:
+		return strconv.FormatInt(n, 10)
+	}
+	return n
+}
+
+// ContinueAfter moves the start past stored, the largest value already in
+// the target (nil for none). Formatted values are parsed back through the
+// format's prefix, suffix and verb.
+func (s *Sequence) ContinueAfter(stored interface{}) error {
+	var last int64
+	switch v := stored.(type) {
+	case nil:
+		return nil
+	case int64:
+		last = v
+	case float64:
+		last = int64(v)
+	case []byte:
+		return s.ContinueAfter(string(v))
+	case string:
+		text, base := v, 10
+		if s.Format != "" {
+			loc := sequenceVerbRe.FindStringIndex(s.Format)
+			prefix := strings.ReplaceAll(s.Format[:loc[0]], "%%", "%")
+			suffix := strings.ReplaceAll(s.Format[loc[1]:], "%%", "%")
+			if len(text) < len(prefix)+len(suffix) || !strings.HasPrefix(text, prefix) || !strings.HasSuffix(text, suffix) {
+				return fmt.Errorf("existing max %q does not match format %q", v, s.Format)
+			}
+			text = text[len(prefix) : len(text)-len(suffix)]
+			switch s.Format[loc[1]-1] {
+			case 'x', 'X':
+				base = 16
+			case 'o':
+				base = 8
+			case 'b':
+				base = 2
+			}
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(text), base, 64)
+		if err != nil {
+			return fmt.Errorf("existing max %q is not a sequence value", v)
+		}
+		last = n
+	default:
+		return fmt.Errorf("existing max %v is not a sequence value", stored)
+	}
+	s.Start = max(s.Start, last+s.Step)
+	return nil
+}
@@ -0,0 +1,115 @@
+package generators
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// SwitchGenerator picks a nested generator spec by the value of another
+// column in the same row:
+//
+//	type: switch
+//	params:
+//	  column: segment
+//	  cases:
+//	    enterprise: {type: uniform_float, params: {min: 10000, max: 1000000}}
+//	    retail: {type: uniform_float, params: {min: 10, max: 5000}}
+//	  default: {type: uniform_float, params: {min: 10, max: 1000}}
+//
+// Case keys match the column value's text form, so 1, true and "1" all
+// match a "1"/"true" key as written. A null value or a value without a case
+// uses the default; without a default such rows are null.
+type SwitchGenerator struct{}
+
+// SwitchSpec is the parsed form of a switch generator's params.
+type SwitchSpec struct {
+	Column  string
+	Cases   map[string]domain.GeneratorSpec
+	Default *domain.GeneratorSpec
+}
+
+func (g *SwitchGenerator) Generate(rng *rand.Rand, ctx GeneratorContext) (interface{}, error) {
+	return nil, errors.New("switch generator requires row context")
+}
+
+func (g *SwitchGenerator) Validate(spec domain.GeneratorSpec, columnType domain.ColumnType) error {
+	_, err := ParseSwitch(spec)
+	return err
+}
+
+// Select returns the nested spec for the current row, or false when no case
+// matches and there is no default.
+func (g *SwitchGenerator) Select(params map[string]interface{}, row map[string]interface{}) (domain.GeneratorSpec, bool, error) {
+	column, _ := params["column"].(string)
+	if v := row[column]; v != nil {
+		if cases, ok := params["cases"].(map[string]interface{}); ok {
+			if raw, ok := cases[fmt.Sprint(v)]; ok {
+				spec, err := specFromValue(raw)
+				return spec, err == nil, err
+			}
+		}
+	}
+	raw, ok := params["default"]
+	if !ok {
+		return domain.GeneratorSpec{}, false, nil
+	}
+	spec, err := specFromValue(raw)
+	return spec, err == nil, err
+}
+
+// ParseSwitch checks and decodes a switch generator's params.
+func ParseSwitch(spec domain.GeneratorSpec) (*SwitchSpec, error) {
+	column, ok := spec.Params["column"].(string)
+	if !ok || column == "" {
+		return nil, errors.New("switch requires a 'column' param")
+	}
+	rawCases, ok := spec.Params["cases"].(map[string]interface{})
+	if !ok || len(rawCases) == 0 {
+		return nil, errors.New("switch requires a non-empty 'cases' map")
+	}
+	out := &SwitchSpec{Column: column, Cases: make(map[string]domain.GeneratorSpec, len(rawCases))}
+	for key, raw := range rawCases {
+		c, err := specFromValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("case '%s': %w", key, err)
+		}
+		out.Cases[key] = c
+	}
+	if raw, ok := spec.Params["default"]; ok {
+		d, err := specFromValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+		out.Default = &d
+	}
+	return out, nil
+}
+
+// specFromValue decodes a nested {type, params} map.
+func specFromValue(v interface{}) (domain.GeneratorSpec, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return domain.GeneratorSpec{}, errors.New("must be a generator spec with 'type' and optional 'params'")
+	}
+	typ, ok := m["type"].(string)
+	if !ok || typ == "" {
+		return domain.GeneratorSpec{}, errors.New("generator type is required")
+	}
+	spec := domain.GeneratorSpec{Type: typ}
+	if raw, ok := m["params"]; ok && raw != nil {
+		params, ok := raw.(map[string]interface{})
+		if !ok {
+			return domain.GeneratorSpec{}, errors.New("'params' must be a map")
+		}
+		spec.Params = params
+	}
+	for k := range m {
+		if k != "type" && k != "params" {
+			return domain.GeneratorSpec{}, fmt.Errorf("unknown generator field '%s'", k)
+		}
+	}
+	return spec, nil
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/generators"
 )
 
 func HashScenario(scenario *domain.Scenario) (string, error) {
@@ -46,6 +47,19 @@ func canonicalizeScenario(scenario *domain.Scenario) map[string]interface{} {
 			"rows":         entity.Rows,
 			"columns":      columns,
 		}
+		if len(entity.Options) > 0 {
+			entities[i]["options"] = entity.Options
+		}
+		if len(entity.ColumnGroups) > 0 {
+			groups := make([]map[string]interface{}, len(entity.ColumnGroups))
+			for j, g := range entity.ColumnGroups {
+				groups[j] = map[string]interface{}{
+					"columns":   g.Columns,
+					"generator": canonicalizeGeneratorSpec(g.Generator),
+				}
+			}
+			entities[i]["column_groups"] = groups
+		}
 	}
 
 	result := map[string]interface{}{
@@ -70,7 +84,40 @@ func canonicalizeGeneratorSpec(spec domain.GeneratorSpec) map[string]interface{}
 		"type": spec.Type,
 	}
 	if spec.Params != nil && len(spec.Params) > 0 {
-		result["params"] = canonicalizeParams(spec.Params)
+		params := canonicalizeParams(spec.Params)
+		// Nested specs hash like top-level ones, so e.g. an empty params
+		// map in a switch case does not change the hash.
+		if spec.Type == "switch" {
+			if sw, err := generators.ParseSwitch(spec); err == nil {
+				cases := make(map[string]interface{}, len(sw.Cases))
+				for k, c := range sw.Cases {
+					cases[k] = canonicalizeGeneratorSpec(c)
+				}
+				params["cases"] = cases
+				if sw.Default != nil {
+					params["default"] = canonicalizeGeneratorSpec(*sw.Default)
+				}
+			}
+		}
+		// A loaded dictionary hashes by its file digest instead of its
+		// entries.
+		if spec.Type == "dictionary" && params["digest"] != nil {
+			delete(params, "values")
+			delete(params, "weights")
+		}
+		if spec.Type == "mixture" {
+			if components, err := generators.ParseMixture(spec); err == nil {
+				list := make([]interface{}, len(components))
+				for i, c := range components {
+					list[i] = map[string]interface{}{
+						"weight":    c.Weight,
+						"generator": canonicalizeGeneratorSpec(c.Spec),
+					}
+				}
+				params["components"] = list
+			}
+		}
+		result["params"] = params
 	}
 	return result
 }
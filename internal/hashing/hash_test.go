@@ -0,0 +1,87 @@
+package hashing
+
+import (
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func switchScenario(retail map[string]interface{}) *domain.Scenario {
+	return &domain.Scenario{
+		Name: "s",
+		Entities: []domain.Entity{{
+			Name: "customers", TargetTable: "customers", Rows: 10,
+			Columns: []domain.Column{{
+				Name: "balance", Type: domain.ColumnTypeDouble,
+				Generator: domain.GeneratorSpec{Type: "switch", Params: map[string]interface{}{
+					"column": "segment",
+					"cases": map[string]interface{}{
+						"enterprise": map[string]interface{}{"type": "uniform_float", "params": map[string]interface{}{"min": 10000, "max": 1000000}},
+						"retail":     retail,
+					},
+					"default": map[string]interface{}{"type": "const", "params": map[string]interface{}{"value": 0}},
+				}},
+			}},
+		}},
+	}
+}
+
+func TestHashScenario_SwitchCasesAreCanonical(t *testing.T) {
+	h1, err := HashScenario(switchScenario(map[string]interface{}{"type": "uuid4"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := HashScenario(switchScenario(map[string]interface{}{"type": "uuid4", "params": map[string]interface{}{}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Fatal("empty nested params should not change the scenario hash")
+	}
+	h3, err := HashScenario(switchScenario(map[string]interface{}{"type": "const", "params": map[string]interface{}{"value": 1}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 == h3 {
+		t.Fatal("a different nested generator must change the scenario hash")
+	}
+}
+
+func mixtureScenario(weight interface{}, second map[string]interface{}) *domain.Scenario {
+	return &domain.Scenario{
+		Name: "s",
+		Entities: []domain.Entity{{
+			Name: "transactions", TargetTable: "transactions", Rows: 10,
+			Columns: []domain.Column{{
+				Name: "amount", Type: domain.ColumnTypeDouble,
+				Generator: domain.GeneratorSpec{Type: "mixture", Params: map[string]interface{}{
+					"components": []interface{}{
+						map[string]interface{}{"weight": 9, "generator": map[string]interface{}{"type": "normal", "params": map[string]interface{}{"mean": 50, "std": 10}}},
+						map[string]interface{}{"weight": weight, "generator": second},
+					},
+				}},
+			}},
+		}},
+	}
+}
+
+func TestHashScenario_MixtureComponentsAreCanonical(t *testing.T) {
+	h1, err := HashScenario(mixtureScenario(1, map[string]interface{}{"type": "uuid4"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := HashScenario(mixtureScenario(1.0, map[string]interface{}{"type": "uuid4", "params": map[string]interface{}{}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Fatal("empty nested params or an int weight should not change the scenario hash")
+	}
+	h3, err := HashScenario(mixtureScenario(2, map[string]interface{}{"type": "uuid4"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 == h3 {
+		t.Fatal("a different component weight must change the scenario hash")
+	}
+}
@@ -18,9 +18,12 @@ type runConfigHashPayload struct {
 	Scale          float64          `json:"scale"`
 	ResolvedCounts map[string]int64 `json:"resolved_counts"`
 	Seed           int64            `json:"seed"`
+	// Params is omitted for scenarios without parameters so their hashes are
+	// unchanged.
+	Params map[string]interface{} `json:"params,omitempty"`
 }
 
-func HashRunConfig(scenario *domain.Scenario, target *domain.TargetConfig, mode string, scale float64, resolvedCounts map[string]int64, seed int64) (string, error) {
+func HashRunConfig(scenario *domain.Scenario, target *domain.TargetConfig, mode string, scale float64, resolvedCounts map[string]int64, seed int64, params map[string]interface{}) (string, error) {
 	sh, err := HashScenario(scenario)
 	if err != nil {
 		return "", err
@@ -48,6 +51,9 @@ func HashRunConfig(scenario *domain.Scenario, target *domain.TargetConfig, mode
 		ResolvedCounts: canon,
 		Seed:           seed,
 	}
+	if len(params) > 0 {
+		p.Params = params
+	}
 	b, err := json.Marshal(p)
 	if err != nil {
 		return "", err
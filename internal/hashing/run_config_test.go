@@ -24,19 +24,19 @@ func TestHashRunConfig_IncludesModeSeedAndResolvedCounts(t *testing.T) {
 	}
 	tg := &domain.TargetConfig{Kind: "postgres", DSN: "postgres://localhost:5432/app?sslmode=disable"}
 
-	h1, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 11)
+	h1, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 11, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	h2, err := HashRunConfig(sc, tg, "truncate", 1.0, map[string]int64{"users": 10}, 11)
+	h2, err := HashRunConfig(sc, tg, "truncate", 1.0, map[string]int64{"users": 10}, 11, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	h3, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 20}, 11)
+	h3, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 20}, 11, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	h4, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 12)
+	h4, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 12, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -50,4 +50,23 @@ func TestHashRunConfig_IncludesModeSeedAndResolvedCounts(t *testing.T) {
 	if h1 == h4 {
 		t.Fatal("expected seed to affect hash")
 	}
+
+	h5, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 11, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h5 {
+		t.Fatal("expected empty params not to affect hash")
+	}
+	h6, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 11, map[string]interface{}{"region": "eu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h7, err := HashRunConfig(sc, tg, "create", 1.0, map[string]int64{"users": 10}, 11, map[string]interface{}{"region": "us"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 == h6 || h6 == h7 {
+		t.Fatal("expected params to affect hash")
+	}
 }
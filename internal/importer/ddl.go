@@ -0,0 +1,692 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// DDL dialects accepted by ParseDDL. DialectAuto picks mysql when the script
+// uses backtick quoting or ENGINE= table options.
+const (
+	DialectAuto     = ""
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+// ParseDDL reads CREATE TABLE statements, plus ALTER TABLE ... ADD constraints
+// and postgres CREATE TYPE ... AS ENUM, from a SQL script. Column types,
+// NOT NULL, PRIMARY KEY, UNIQUE, REFERENCES / FOREIGN KEY and CHECK (col IN
+// (...)) lists are kept; everything else (indexes, defaults, other statements)
+// is ignored. Warnings report constructs that were skipped.
+func ParseDDL(script string, dialect string) ([]Table, []string, error) {
+	if dialect == DialectAuto {
+		dialect = DialectPostgres
+		upper := strings.ToUpper(script)
+		if strings.Contains(script, "`") || strings.Contains(upper, "ENGINE=") || strings.Contains(upper, "ENGINE =") {
+			dialect = DialectMySQL
+		}
+	}
+	if dialect != DialectPostgres && dialect != DialectMySQL {
+		return nil, nil, fmt.Errorf("unsupported DDL dialect: %s", dialect)
+	}
+	toks, err := lexSQL(script, dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &ddlParser{dialect: dialect, enums: map[string][]string{}, index: map[string]*Table{}}
+	for _, stmt := range splitStatements(toks) {
+		if err := p.statement(stmt); err != nil {
+			return nil, p.warnings, err
+		}
+	}
+	p.resolveReferences()
+	out := make([]Table, len(p.tables))
+	for i, t := range p.tables {
+		out[i] = *t
+	}
+	if len(out) == 0 {
+		return nil, p.warnings, fmt.Errorf("no CREATE TABLE statements found")
+	}
+	return out, p.warnings, nil
+}
+
+type tokKind int
+
+const (
+	tokWord tokKind = iota
+	tokQuoted
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokKind
+	text string
+}
+
+func (t token) is(word string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, word)
+}
+
+func (t token) punct(p string) bool {
+	return t.kind == tokPunct && t.text == p
+}
+
+// lexSQL tokenizes a script, dropping comments. In mysql, double-quoted text
+// is a string literal; in postgres it is an identifier.
+func lexSQL(src, dialect string) ([]token, error) {
+	var toks []token
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(rs) && rs[i+1] == '-', c == '#' && dialect == DialectMySQL:
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			end := indexRunes(rs, i+2, []rune("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i = end + 2
+		case c == '\'' || c == '"' || c == '`':
+			kind := tokQuoted
+			if c == '\'' || (c == '"' && dialect == DialectMySQL) {
+				kind = tokString
+			}
+			text, n, err := lexQuoted(rs[i:], c, kind == tokString && dialect == DialectMySQL)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: kind, text: text})
+			i += n
+		case (c == 'E' || c == 'e') && dialect == DialectPostgres && i+1 < len(rs) && rs[i+1] == '\'':
+			// E'...' escape string; plain postgres strings keep backslashes
+			text, n, err := lexQuoted(rs[i+1:], '\'', true)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokString, text: text})
+			i += 1 + n
+		case c == '$' && dialect == DialectPostgres && i+1 < len(rs) && (rs[i+1] == '$' || unicode.IsLetter(rs[i+1])):
+			// dollar-quoted body, e.g. in CREATE FUNCTION; kept as one string
+			j := i + 1
+			for j < len(rs) && rs[j] != '$' {
+				j++
+			}
+			end := -1
+			if j < len(rs) {
+				end = indexRunes(rs, j+1, rs[i:j+1])
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar-quoted string")
+			}
+			toks = append(toks, token{kind: tokString, text: string(rs[j+1 : end])})
+			i = end + (j + 1 - i)
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1]) && prevAllowsSign(toks)):
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.' || rs[j] == 'e' || rs[j] == 'E') {
+				j++
+			}
+			toks = append(toks, token{kind: tokNumber, text: string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '$') {
+				j++
+			}
+			toks = append(toks, token{kind: tokWord, text: string(rs[i:j])})
+			i = j
+		case c == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			toks = append(toks, token{kind: tokPunct, text: "::"})
+			i += 2
+		default:
+			toks = append(toks, token{kind: tokPunct, text: string(c)})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func prevAllowsSign(toks []token) bool {
+	if len(toks) == 0 {
+		return true
+	}
+	prev := toks[len(toks)-1]
+	return prev.kind == tokPunct && prev.text != ")"
+}
+
+// indexRunes returns the position of the first sep in rs at or after from,
+// or -1. It scans the runes directly so long scripts stay linear to lex.
+func indexRunes(rs []rune, from int, sep []rune) int {
+	for i := from; i+len(sep) <= len(rs); i++ {
+		if slices.Equal(rs[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lexQuoted reads text quoted with q, where a doubled q stands for itself.
+// Backslash escapes are honoured only when backslash is set: mysql strings
+// and postgres E'...' strings.
+func lexQuoted(rs []rune, q rune, backslash bool) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(rs); i++ {
+		if backslash && rs[i] == '\\' && i+1 < len(rs) {
+			b.WriteRune(rs[i+1])
+			i++
+			continue
+		}
+		if rs[i] == q {
+			if i+1 < len(rs) && rs[i+1] == q {
+				b.WriteRune(q)
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		b.WriteRune(rs[i])
+	}
+	return "", 0, fmt.Errorf("unterminated quoted text")
+}
+
+func splitStatements(toks []token) [][]token {
+	var out [][]token
+	start := 0
+	for i, t := range toks {
+		if t.punct(";") {
+			if i > start {
+				out = append(out, toks[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		out = append(out, toks[start:])
+	}
+	return out
+}
+
+// splitTopLevel splits tokens at commas outside parentheses.
+func splitTopLevel(toks []token) [][]token {
+	var out [][]token
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.punct("("), t.punct("["):
+			depth++
+		case t.punct(")"), t.punct("]"):
+			depth--
+		case t.punct(",") && depth == 0:
+			out = append(out, toks[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		out = append(out, toks[start:])
+	}
+	return out
+}
+
+// group returns the tokens inside the parenthesis starting at toks[i] and the
+// index just past the closing parenthesis.
+func group(toks []token, i int) ([]token, int, bool) {
+	if i >= len(toks) || !toks[i].punct("(") {
+		return nil, i, false
+	}
+	depth := 0
+	for j := i; j < len(toks); j++ {
+		switch {
+		case toks[j].punct("("):
+			depth++
+		case toks[j].punct(")"):
+			depth--
+			if depth == 0 {
+				return toks[i+1 : j], j + 1, true
+			}
+		}
+	}
+	return nil, i, false
+}
+
+type ddlParser struct {
+	dialect  string
+	tables   []*Table
+	index    map[string]*Table
+	enums    map[string][]string
+	warnings []string
+}
+
+func (p *ddlParser) ident(t token) string {
+	if t.kind == tokWord && p.dialect == DialectPostgres {
+		return strings.ToLower(t.text)
+	}
+	return t.text
+}
+
+// qualifiedName reads schema.name at toks[i] and returns the last part.
+func (p *ddlParser) qualifiedName(toks []token, i int) (string, int) {
+	name := ""
+	for i < len(toks) && (toks[i].kind == tokWord || toks[i].kind == tokQuoted) {
+		name = p.ident(toks[i])
+		i++
+		if i < len(toks) && toks[i].punct(".") {
+			i++
+			continue
+		}
+		break
+	}
+	return name, i
+}
+
+func (p *ddlParser) statement(toks []token) error {
+	i := 0
+	if !toks[0].is("CREATE") && !toks[0].is("ALTER") {
+		return nil
+	}
+	if toks[0].is("ALTER") {
+		return p.alterTable(toks)
+	}
+	i++
+	for i < len(toks) && (toks[i].is("TEMP") || toks[i].is("TEMPORARY") || toks[i].is("UNLOGGED") || toks[i].is("GLOBAL") || toks[i].is("LOCAL") || toks[i].is("OR") || toks[i].is("REPLACE")) {
+		i++
+	}
+	if i < len(toks) && toks[i].is("TYPE") {
+		return p.createType(toks[i+1:])
+	}
+	if i >= len(toks) || !toks[i].is("TABLE") {
+		return nil
+	}
+	i++
+	if i+2 < len(toks) && toks[i].is("IF") && toks[i+1].is("NOT") && toks[i+2].is("EXISTS") {
+		i += 3
+	}
+	name, i := p.qualifiedName(toks, i)
+	if name == "" {
+		return fmt.Errorf("CREATE TABLE without a name")
+	}
+	body, _, ok := group(toks, i)
+	if !ok {
+		p.warnings = append(p.warnings, fmt.Sprintf("table %s skipped: no column list (CREATE TABLE ... AS / LIKE)", name))
+		return nil
+	}
+	if _, dup := p.index[name]; dup {
+		p.warnings = append(p.warnings, fmt.Sprintf("table %s defined more than once; keeping the first definition", name))
+		return nil
+	}
+	t := &Table{Name: name}
+	p.tables = append(p.tables, t)
+	p.index[name] = t
+	for _, item := range splitTopLevel(body) {
+		if len(item) == 0 {
+			continue
+		}
+		if p.tableConstraint(t, item) {
+			continue
+		}
+		p.column(t, item)
+	}
+	return nil
+}
+
+// createType handles CREATE TYPE name AS ENUM ('a', 'b').
+func (p *ddlParser) createType(toks []token) error {
+	name, i := p.qualifiedName(toks, 0)
+	if i+1 >= len(toks) || !toks[i].is("AS") || !toks[i+1].is("ENUM") {
+		return nil
+	}
+	inner, _, ok := group(toks, i+2)
+	if !ok {
+		return fmt.Errorf("CREATE TYPE %s AS ENUM without values", name)
+	}
+	p.enums[name] = stringLiterals(inner)
+	return nil
+}
+
+// alterTable handles ALTER TABLE [ONLY] name ADD [CONSTRAINT x] <constraint>,
+// the form pg_dump and many migration tools use for keys.
+func (p *ddlParser) alterTable(toks []token) error {
+	i := 1
+	if i >= len(toks) || !toks[i].is("TABLE") {
+		return nil
+	}
+	i++
+	if i+1 < len(toks) && toks[i].is("IF") && toks[i+1].is("EXISTS") {
+		i += 2
+	}
+	if i < len(toks) && toks[i].is("ONLY") {
+		i++
+	}
+	name, i := p.qualifiedName(toks, i)
+	t, ok := p.index[name]
+	if !ok {
+		return nil
+	}
+	for _, action := range splitTopLevel(toks[i:]) {
+		if len(action) < 2 || !action[0].is("ADD") {
+			continue
+		}
+		rest := action[1:]
+		if !p.tableConstraint(t, rest) && !rest[0].is("INDEX") && !rest[0].is("KEY") {
+			if rest[0].is("COLUMN") {
+				rest = rest[1:]
+			}
+			p.column(t, rest)
+		}
+	}
+	return nil
+}
+
+// tableConstraint parses a table-level constraint and reports whether item
+// was one (including ignored index definitions).
+func (p *ddlParser) tableConstraint(t *Table, item []token) bool {
+	i := 0
+	if item[0].is("CONSTRAINT") {
+		i = 2
+		if i >= len(item) {
+			return true
+		}
+	}
+	head := item[i]
+	switch {
+	case head.is("PRIMARY"):
+		if cols, _, ok := p.columnList(item, i+2); ok {
+			t.PrimaryKey = cols
+		}
+		return true
+	case head.is("UNIQUE"):
+		j := i + 1
+		for j < len(item) && !item[j].punct("(") {
+			j++
+		}
+		if cols, _, ok := p.columnList(item, j); ok {
+			t.Unique = append(t.Unique, cols)
+		}
+		return true
+	case head.is("FOREIGN"):
+		cols, j, ok := p.columnList(item, i+2)
+		if !ok || j >= len(item) || !item[j].is("REFERENCES") {
+			return true
+		}
+		p.addReference(t, cols, item[j+1:])
+		return true
+	case head.is("CHECK"):
+		if inner, _, ok := group(item, i+1); ok {
+			p.checkIn(t, inner)
+		}
+		return true
+	case head.is("KEY"), head.is("INDEX"), head.is("FULLTEXT"), head.is("SPATIAL"), head.is("EXCLUDE"):
+		return true
+	}
+	return i > 0
+}
+
+func (p *ddlParser) columnList(toks []token, i int) ([]string, int, bool) {
+	inner, next, ok := group(toks, i)
+	if !ok {
+		return nil, i, false
+	}
+	var cols []string
+	for _, part := range splitTopLevel(inner) {
+		if len(part) > 0 {
+			cols = append(cols, p.ident(part[0]))
+		}
+	}
+	return cols, next, true
+}
+
+// addReference parses "table [(cols)] [ON DELETE ...]" after REFERENCES.
+func (p *ddlParser) addReference(t *Table, cols []string, toks []token) {
+	ref, i := p.qualifiedName(toks, 0)
+	fk := ForeignKey{Columns: cols, RefTable: ref}
+	if refCols, _, ok := p.columnList(toks, i); ok {
+		fk.RefColumns = refCols
+	}
+	t.ForeignKeys = append(t.ForeignKeys, fk)
+}
+
+// resolveReferences fills REFERENCES without a column list with the
+// referenced table's primary key.
+func (p *ddlParser) resolveReferences() {
+	for _, t := range p.tables {
+		for i := range t.ForeignKeys {
+			fk := &t.ForeignKeys[i]
+			if len(fk.RefColumns) > 0 {
+				continue
+			}
+			if ref, ok := p.index[fk.RefTable]; ok && len(ref.PrimaryKey) == len(fk.Columns) {
+				fk.RefColumns = ref.PrimaryKey
+			}
+		}
+	}
+}
+
+var columnConstraintWords = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "UNIQUE": true, "REFERENCES": true, "CHECK": true,
+	"DEFAULT": true, "CONSTRAINT": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true, "GENERATED": true,
+	"COLLATE": true, "COMMENT": true, "ON": true, "IDENTITY": true, "KEY": true,
+}
+
+// column parses "name type [constraints]".
+func (p *ddlParser) column(t *Table, item []token) {
+	if item[0].kind != tokWord && item[0].kind != tokQuoted {
+		return
+	}
+	col := Column{Name: p.ident(item[0]), Nullable: true}
+	i := 1
+	var typeToks []token
+	for i < len(item) {
+		tk := item[i]
+		if tk.kind == tokWord && columnConstraintWords[strings.ToUpper(tk.text)] {
+			break
+		}
+		if tk.punct("(") {
+			inner, next, ok := group(item, i)
+			if !ok {
+				break
+			}
+			typeToks = append(typeToks, tk)
+			typeToks = append(typeToks, inner...)
+			typeToks = append(typeToks, token{kind: tokPunct, text: ")"})
+			i = next
+			continue
+		}
+		typeToks = append(typeToks, tk)
+		i++
+	}
+	col.Type, col.Enum = p.columnType(typeToks)
+
+	for i < len(item) {
+		tk := item[i]
+		switch {
+		case tk.is("NOT") && i+1 < len(item) && item[i+1].is("NULL"):
+			col.Nullable = false
+			i += 2
+		case tk.is("PRIMARY"):
+			t.PrimaryKey = []string{col.Name}
+			col.Nullable = false
+			i += 2
+		case tk.is("UNIQUE"):
+			t.Unique = append(t.Unique, []string{col.Name})
+			i++
+		case tk.is("REFERENCES"):
+			p.addReference(t, []string{col.Name}, item[i+1:])
+			_, next := p.qualifiedName(item, i+1)
+			if _, after, ok := group(item, next); ok {
+				next = after
+			}
+			i = next
+		case tk.is("CHECK"):
+			inner, next, ok := group(item, i+1)
+			if !ok {
+				i++
+				continue
+			}
+			if values := p.inValues(inner, col.Name); len(values) > 0 && len(col.Enum) == 0 {
+				col.Enum = values
+			}
+			i = next
+		case tk.is("DEFAULT") || tk.is("COMMENT") || tk.is("COLLATE"):
+			// skip the value, which may be a parenthesized expression
+			i++
+			if _, next, ok := group(item, i); ok {
+				i = next
+			} else {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	t.Columns = append(t.Columns, col)
+}
+
+// checkIn applies a table-level CHECK (col IN (...)) to its column.
+func (p *ddlParser) checkIn(t *Table, inner []token) {
+	for j := range inner {
+		if inner[j].kind != tokWord && inner[j].kind != tokQuoted {
+			continue
+		}
+		name := p.ident(inner[j])
+		for k := range t.Columns {
+			if t.Columns[k].Name == name && len(t.Columns[k].Enum) == 0 {
+				t.Columns[k].Enum = p.inValues(inner, name)
+			}
+		}
+		return
+	}
+}
+
+// inValues returns the literals of "col IN ('a', 'b')" or the postgres form
+// "col = ANY (ARRAY['a', 'b'])" when the expression references only col.
+func (p *ddlParser) inValues(inner []token, colName string) []string {
+	i := 0
+	for i < len(inner) && inner[i].punct("(") {
+		i++
+	}
+	if i >= len(inner) || p.ident(inner[i]) != colName {
+		return nil
+	}
+	i++
+	for i < len(inner) && (inner[i].punct(")") || inner[i].punct("::") || (i > 0 && inner[i-1].punct("::"))) {
+		i++
+	}
+	if i >= len(inner) {
+		return nil
+	}
+	switch {
+	case inner[i].is("IN"):
+	case inner[i].punct("=") && i+1 < len(inner) && inner[i+1].is("ANY"):
+	default:
+		return nil
+	}
+	rest := inner[i+1:]
+	cast := false
+	for j, tk := range rest {
+		switch {
+		case tk.punct("::"):
+			cast = true
+		case tk.kind == tokWord && (tk.is("ANY") || tk.is("ARRAY")):
+		case tk.kind == tokWord && cast && (rest[j-1].punct("::") || rest[j-1].kind == tokWord):
+			// type name of a cast such as 'a'::character varying
+		case tk.kind == tokWord || tk.kind == tokQuoted:
+			return nil
+		default:
+			cast = false
+		}
+	}
+	return stringLiterals(rest)
+}
+
+func stringLiterals(toks []token) []string {
+	var values []string
+	for _, tk := range toks {
+		if tk.kind == tokString || tk.kind == tokNumber {
+			values = append(values, tk.text)
+		}
+	}
+	return values
+}
+
+// columnType maps a DDL type to a column type; enum types also return their values.
+func (p *ddlParser) columnType(toks []token) (domain.ColumnType, []string) {
+	if len(toks) == 0 {
+		return domain.ColumnTypeString, nil
+	}
+	var words []string
+	var args []token
+	for i, tk := range toks {
+		if tk.punct("(") {
+			args = toks[i+1:]
+			break
+		}
+		if tk.kind == tokWord || tk.kind == tokQuoted {
+			words = append(words, strings.ToLower(tk.text))
+		}
+		if tk.punct("[") {
+			words = append(words, "[]")
+		}
+	}
+	if len(words) == 0 {
+		return domain.ColumnTypeString, nil
+	}
+	// the last word also matches schema-qualified enum types such as public.status
+	if vals, ok := p.enums[words[len(words)-1]]; ok {
+		return domain.ColumnTypeString, vals
+	}
+	for _, w := range words {
+		if w == "[]" {
+			return domain.ColumnTypeText, nil
+		}
+	}
+	base := words[0]
+	switch base {
+	case "enum", "set":
+		return domain.ColumnTypeString, stringLiterals(args)
+	case "tinyint":
+		if len(args) > 0 && args[0].text == "1" {
+			return domain.ColumnTypeBool, nil
+		}
+		return domain.ColumnTypeInt, nil
+	case "smallint", "int", "int2", "int4", "integer", "mediumint", "serial", "smallserial", "serial4", "year":
+		return domain.ColumnTypeInt, nil
+	case "bigint", "int8", "bigserial", "serial8":
+		return domain.ColumnTypeBigInt, nil
+	case "real", "float4":
+		return domain.ColumnTypeFloat, nil
+	case "float":
+		if len(args) > 0 && args[0].kind == tokNumber && atoi(args[0].text) > 24 {
+			return domain.ColumnTypeDouble, nil
+		}
+		return domain.ColumnTypeFloat, nil
+	case "double", "float8", "numeric", "decimal", "dec", "money", "fixed":
+		return domain.ColumnTypeDouble, nil
+	case "bool", "boolean", "bit":
+		return domain.ColumnTypeBool, nil
+	case "uuid", "uniqueidentifier":
+		return domain.ColumnTypeUUID, nil
+	case "date":
+		return domain.ColumnTypeDate, nil
+	case "timestamp", "timestamptz", "datetime", "datetime2", "smalldatetime":
+		return domain.ColumnTypeTimestamp, nil
+	case "text", "tinytext", "mediumtext", "longtext", "json", "jsonb", "xml", "clob":
+		return domain.ColumnTypeText, nil
+	default:
+		return domain.ColumnTypeString, nil
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/registry"
+	"github.com/mmrzaf/sdgen/internal/validation"
+)
+
+func TestParseDDL_Postgres(t *testing.T) {
+	script := `
+-- pg_dump style
+CREATE TYPE public.order_status AS ENUM ('open', 'paid', 'shipped');
+
+CREATE TABLE IF NOT EXISTS public.customers (
+    customer_id bigserial PRIMARY KEY,
+    "Email" character varying(255) NOT NULL UNIQUE,
+    tier integer CHECK (tier IN (1, 2, 3)),
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE TABLE orders (
+    order_id uuid NOT NULL,
+    customer_id bigint NOT NULL REFERENCES customers,
+    status public.order_status NOT NULL,
+    channel text,
+    amount numeric(12,2),
+    note text, /* free text */
+    CONSTRAINT orders_channel_check CHECK ((channel = ANY (ARRAY['web'::text, 'store'::text])))
+);
+
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (order_id);
+CREATE INDEX orders_customer_idx ON orders (customer_id);
+`
+	tables, warnings, err := ParseDDL(script, DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(tables))
+	}
+
+	customers := tables[0]
+	if customers.Name != "customers" || !reflect.DeepEqual(customers.PrimaryKey, []string{"customer_id"}) {
+		t.Fatalf("unexpected customers table: %+v", customers)
+	}
+	want := []Column{
+		{Name: "customer_id", Type: domain.ColumnTypeBigInt},
+		{Name: "Email", Type: domain.ColumnTypeString},
+		{Name: "tier", Type: domain.ColumnTypeInt, Nullable: true, Enum: []string{"1", "2", "3"}},
+		{Name: "created_at", Type: domain.ColumnTypeTimestamp},
+	}
+	if !reflect.DeepEqual(customers.Columns, want) {
+		t.Fatalf("customers columns:\n got %+v\nwant %+v", customers.Columns, want)
+	}
+	if !reflect.DeepEqual(customers.Unique, [][]string{{"Email"}}) {
+		t.Fatalf("unexpected unique keys: %v", customers.Unique)
+	}
+
+	orders := tables[1]
+	if !reflect.DeepEqual(orders.PrimaryKey, []string{"order_id"}) {
+		t.Fatalf("ALTER TABLE primary key not applied: %v", orders.PrimaryKey)
+	}
+	wantFK := []ForeignKey{{Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"customer_id"}}}
+	if !reflect.DeepEqual(orders.ForeignKeys, wantFK) {
+		t.Fatalf("foreign keys:\n got %+v\nwant %+v", orders.ForeignKeys, wantFK)
+	}
+	enums := map[string][]string{}
+	types := map[string]domain.ColumnType{}
+	for _, c := range orders.Columns {
+		enums[c.Name] = c.Enum
+		types[c.Name] = c.Type
+	}
+	if !reflect.DeepEqual(enums["status"], []string{"open", "paid", "shipped"}) {
+		t.Fatalf("enum type not applied: %v", enums["status"])
+	}
+	if !reflect.DeepEqual(enums["channel"], []string{"web", "store"}) {
+		t.Fatalf("CHECK = ANY not applied: %v", enums["channel"])
+	}
+	if types["amount"] != domain.ColumnTypeDouble || types["note"] != domain.ColumnTypeText {
+		t.Fatalf("unexpected types: %v", types)
+	}
+}
+
+func TestParseDDL_MySQL(t *testing.T) {
+	script := `
+CREATE TABLE ` + "`users`" + ` (
+  ` + "`id`" + ` int unsigned NOT NULL AUTO_INCREMENT,
+  ` + "`active`" + ` tinyint(1) NOT NULL DEFAULT '1',
+  ` + "`role`" + ` enum('admin','member') NOT NULL DEFAULT 'member',
+  ` + "`plan`" + ` varchar(16) DEFAULT NULL,
+  ` + "`signed_up`" + ` datetime NOT NULL ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (` + "`id`" + `),
+  UNIQUE KEY ` + "`users_plan`" + ` (` + "`plan`" + `),
+  KEY ` + "`idx_role`" + ` (` + "`role`" + `),
+  CONSTRAINT ` + "`users_plan_chk`" + ` CHECK (` + "`plan`" + ` IN ("free", "pro"))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+# comment
+CREATE TABLE sessions (
+  id bigint NOT NULL PRIMARY KEY,
+  user_id int unsigned NOT NULL,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB;
+`
+	tables, _, err := ParseDDL(script, DialectAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := tables[0]
+	want := []Column{
+		{Name: "id", Type: domain.ColumnTypeInt},
+		{Name: "active", Type: domain.ColumnTypeBool},
+		{Name: "role", Type: domain.ColumnTypeString, Enum: []string{"admin", "member"}},
+		{Name: "plan", Type: domain.ColumnTypeString, Nullable: true, Enum: []string{"free", "pro"}},
+		{Name: "signed_up", Type: domain.ColumnTypeTimestamp},
+	}
+	if !reflect.DeepEqual(users.Columns, want) {
+		t.Fatalf("users columns:\n got %+v\nwant %+v", users.Columns, want)
+	}
+	if !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) || !reflect.DeepEqual(users.Unique, [][]string{{"plan"}}) {
+		t.Fatalf("unexpected keys: pk=%v unique=%v", users.PrimaryKey, users.Unique)
+	}
+	sessions := tables[1]
+	wantFK := []ForeignKey{{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}}}
+	if !reflect.DeepEqual(sessions.ForeignKeys, wantFK) {
+		t.Fatalf("foreign keys:\n got %+v\nwant %+v", sessions.ForeignKeys, wantFK)
+	}
+
+	sc, _ := BuildScenario(tables, Options{ID: "shop"})
+	if err := validation.NewValidator(registry.DefaultGeneratorRegistry()).ValidateScenario(sc); err != nil {
+		t.Fatalf("imported scenario is invalid: %v", err)
+	}
+	fk := sc.Entities[0].Columns[1]
+	if sc.Entities[0].Name != "sessions" || fk.Generator.Type != "fk" || fk.FK.Entity != "users" {
+		t.Fatalf("expected sessions.user_id to be an fk column, got %+v", fk)
+	}
+}
+
+func TestParseDDL_Errors(t *testing.T) {
+	if _, _, err := ParseDDL("CREATE INDEX i ON t (c);", DialectPostgres); err == nil {
+		t.Fatal("expected an error for a script without tables")
+	}
+	if _, _, err := ParseDDL("CREATE TABLE t (c text DEFAULT 'x);", DialectPostgres); err == nil {
+		t.Fatal("expected an error for an unterminated string")
+	}
+	if _, _, err := ParseDDL("CREATE TABLE t (c text);", "oracle"); err == nil {
+		t.Fatal("expected an error for an unknown dialect")
+	}
+}
+
+func TestParseDDL_BackslashEscapes(t *testing.T) {
+	pg := `CREATE TABLE paths (
+  dir text NOT NULL DEFAULT 'C:\',
+  kind text CHECK (kind IN (E'a\'b', 'c'))
+);`
+	tables, _, err := ParseDDL(pg, DialectPostgres)
+	if err != nil {
+		t.Fatalf("expected standard postgres strings to keep backslashes, got %v", err)
+	}
+	if got := tables[0].Columns[1].Enum; !reflect.DeepEqual(got, []string{"a'b", "c"}) {
+		t.Fatalf("unexpected postgres enum values %q", got)
+	}
+
+	my := "CREATE TABLE t (kind varchar(10) CHECK (kind IN ('it\\'s', 'x'))) ENGINE=InnoDB;"
+	tables, _, err = ParseDDL(my, DialectMySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tables[0].Columns[0].Enum; !reflect.DeepEqual(got, []string{"it's", "x"}) {
+		t.Fatalf("unexpected mysql enum values %q", got)
+	}
+}
+
+func TestLexSQL_CommentsAndDollarQuotes(t *testing.T) {
+	toks, err := lexSQL("/* é */ SELECT $fn$ a ü $ b $fn$, $$x$$ -- end", DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tok := range toks {
+		got = append(got, tok.text)
+	}
+	if want := []string{"SELECT", " a ü $ b ", ",", "x"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tokens %q, want %q", got, want)
+	}
+	for _, src := range []string{"/* open", "SELECT $fn$ body", "SELECT $fn"} {
+		if _, err := lexSQL(src, DialectPostgres); err == nil {
+			t.Fatalf("expected %q to be rejected as unterminated", src)
+		}
+	}
+
+	// a long script with many comments must lex in linear time
+	script := strings.Repeat("/* comment */ CREATE TABLE t (c int);\n", 20000)
+	start := time.Now()
+	if _, err := lexSQL(script, DialectPostgres); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("lexing took %s", elapsed)
+	}
+}
+
+func TestDefaultGenerator_NumericEnum(t *testing.T) {
+	spec := DefaultGenerator(Column{Name: "tier", Type: domain.ColumnTypeInt, Enum: []string{"1", "2"}}, false, 10, 0)
+	if got := spec.Params["values"]; !reflect.DeepEqual(got, []interface{}{int64(1), int64(2)}) {
+		t.Fatalf("expected int64 choice values, got %#v", got)
+	}
+}
@@ -0,0 +1,329 @@
+// Package importer builds scenarios from existing schema descriptions such as
+// live database catalogs.
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/validation"
+)
+
+// Table is a source-neutral description of a table or entity.
+type Table struct {
+	Name        string
+	Columns     []Column
+	PrimaryKey  []string
+	Unique      [][]string
+	ForeignKeys []ForeignKey
+	// Rows is the current row count; <= 0 uses Options.DefaultRows.
+	Rows int64
+}
+
+type Column struct {
+	Name     string
+	Type     domain.ColumnType
+	Nullable bool
+	// Enum lists the allowed values (enum types, CHECK ... IN lists).
+	Enum []string
+	// Generator, when set, replaces the type-based default.
+	Generator *domain.GeneratorSpec
+}
+
+type ForeignKey struct {
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+type Options struct {
+	ID          string
+	Name        string
+	Description string
+	// DefaultRows is used for tables without a row count (default 1000).
+	DefaultRows int64
+	// TimeWindow is how far back time_series columns start (default 30d).
+	TimeWindow time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.DefaultRows <= 0 {
+		o.DefaultRows = 1000
+	}
+	if o.TimeWindow <= 0 {
+		o.TimeWindow = 30 * 24 * time.Hour
+	}
+	if o.Name == "" {
+		o.Name = o.ID
+	}
+	return o
+}
+
+// BuildScenario maps tables to entities with generators picked from column
+// types, keys and names. Tables or columns that cannot be represented (for
+// example reserved-word identifiers) are skipped and reported as warnings.
+// Foreign keys closing a reference cycle are generated as plain columns so the
+// entity graph stays acyclic.
+func BuildScenario(tables []Table, opts Options) (*domain.Scenario, []string) {
+	opts = opts.withDefaults()
+	var warnings []string
+
+	byName := make(map[string]*Table, len(tables))
+	names := make([]string, 0, len(tables))
+	for i := range tables {
+		t := &tables[i]
+		if !validation.IsValidIdentifier(t.Name) {
+			warnings = append(warnings, fmt.Sprintf("table %s skipped: not a valid identifier", t.Name))
+			continue
+		}
+		byName[t.Name] = t
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+
+	fkEdges := usableForeignKeys(byName, names, &warnings)
+
+	sc := &domain.Scenario{
+		ID:          opts.ID,
+		Name:        opts.Name,
+		Version:     "1.0.0",
+		Description: opts.Description,
+	}
+	for _, name := range names {
+		t := byName[name]
+		rows := t.Rows
+		if rows <= 0 {
+			rows = opts.DefaultRows
+		}
+		entity := domain.Entity{Name: t.Name, TargetTable: t.Name, Rows: rows}
+		unique := uniqueColumns(t)
+		for _, c := range t.Columns {
+			if !validation.IsValidIdentifier(c.Name) {
+				warnings = append(warnings, fmt.Sprintf("column %s.%s skipped: not a valid identifier", t.Name, c.Name))
+				continue
+			}
+			col := domain.Column{Name: c.Name, Type: c.Type, Nullable: c.Nullable}
+			if ref, ok := fkEdges[t.Name][c.Name]; ok {
+				col.Generator = domain.GeneratorSpec{Type: "fk", Params: map[string]interface{}{"entity": ref.Entity, "column": ref.Column}}
+				col.FK = &domain.ForeignKey{Entity: ref.Entity, Column: ref.Column}
+			} else if c.Generator != nil {
+				col.Generator = *c.Generator
+				if unique[c.Name] {
+					col.Generator = uniqueGenerator(col.Generator)
+				}
+			} else {
+				col.Generator = DefaultGenerator(c, unique[c.Name], rows, opts.TimeWindow)
+			}
+			entity.Columns = append(entity.Columns, col)
+		}
+		if len(entity.Columns) == 0 {
+			warnings = append(warnings, fmt.Sprintf("table %s skipped: no usable columns", t.Name))
+			continue
+		}
+		sc.Entities = append(sc.Entities, entity)
+	}
+	return sc, warnings
+}
+
+// usableForeignKeys returns table -> column -> referenced column for
+// single-column foreign keys, dropping edges into skipped tables and edges
+// that would close a reference cycle. NOT NULL keys are accepted before
+// nullable ones so cycles are preferably broken at optional references.
+func usableForeignKeys(byName map[string]*Table, names []string, warnings *[]string) map[string]map[string]domain.ForeignKey {
+	type edge struct {
+		table, column string
+		ref           domain.ForeignKey
+	}
+	var required, optional []edge
+	for _, name := range names {
+		t := byName[name]
+		for _, fk := range t.ForeignKeys {
+			if len(fk.Columns) != 1 || len(fk.RefColumns) != 1 {
+				*warnings = append(*warnings, fmt.Sprintf("composite foreign key %s(%s) not generated as fk", t.Name, strings.Join(fk.Columns, ",")))
+				continue
+			}
+			ref, ok := byName[fk.RefTable]
+			if !ok || !hasColumn(ref, fk.RefColumns[0]) || !validation.IsValidIdentifier(fk.RefColumns[0]) {
+				*warnings = append(*warnings, fmt.Sprintf("foreign key %s.%s references unavailable %s.%s", t.Name, fk.Columns[0], fk.RefTable, fk.RefColumns[0]))
+				continue
+			}
+			e := edge{table: t.Name, column: fk.Columns[0], ref: domain.ForeignKey{Entity: fk.RefTable, Column: fk.RefColumns[0]}}
+			if isNullable(t, e.column) {
+				optional = append(optional, e)
+			} else {
+				required = append(required, e)
+			}
+		}
+	}
+
+	edges := map[string]map[string]domain.ForeignKey{}
+	reaches := func(from, to string) bool {
+		seen := map[string]bool{}
+		stack := []string{from}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if n == to {
+				return true
+			}
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			for _, ref := range edges[n] {
+				stack = append(stack, ref.Entity)
+			}
+		}
+		return false
+	}
+	for _, e := range append(required, optional...) {
+		if reaches(e.ref.Entity, e.table) {
+			*warnings = append(*warnings, fmt.Sprintf("foreign key %s.%s -> %s dropped to break a reference cycle", e.table, e.column, e.ref.Entity))
+			continue
+		}
+		if edges[e.table] == nil {
+			edges[e.table] = map[string]domain.ForeignKey{}
+		}
+		edges[e.table][e.column] = e.ref
+	}
+	return edges
+}
+
+func isNullable(t *Table, name string) bool {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c.Nullable
+		}
+	}
+	return false
+}
+
+func hasColumn(t *Table, name string) bool {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueColumns returns the columns that are unique on their own.
+func uniqueColumns(t *Table) map[string]bool {
+	out := map[string]bool{}
+	if len(t.PrimaryKey) == 1 {
+		out[t.PrimaryKey[0]] = true
+	}
+	for _, u := range t.Unique {
+		if len(u) == 1 {
+			out[u[0]] = true
+		}
+	}
+	return out
+}
+
+// DefaultGenerator picks a generator for a column from its enum values, type,
+// uniqueness and a few well-known names.
+func DefaultGenerator(c Column, unique bool, rows int64, window time.Duration) domain.GeneratorSpec {
+	if len(c.Enum) > 0 {
+		return domain.GeneratorSpec{Type: "choice", Params: map[string]interface{}{"values": enumValues(c)}}
+	}
+	switch c.Type {
+	case domain.ColumnTypeUUID:
+		return domain.GeneratorSpec{Type: "uuid4"}
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		if unique {
+			// Numbering rows cannot collide, unlike random draws from a range.
+			return domain.GeneratorSpec{Type: "sequence", Params: map[string]interface{}{"start": int64(1)}}
+		}
+		if c.Type == domain.ColumnTypeInt {
+			return uniformInt(0, 1000)
+		}
+		return uniformInt(0, 1000000)
+	case domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+		return domain.GeneratorSpec{Type: "uniform_float", Params: map[string]interface{}{"min": 0.0, "max": 1000.0}}
+	case domain.ColumnTypeBool:
+		return domain.GeneratorSpec{Type: "choice", Params: map[string]interface{}{"values": []interface{}{true, false}}}
+	case domain.ColumnTypeTimestamp, domain.ColumnTypeDate:
+		return timeSeries(rows, window)
+	}
+	if unique {
+		return domain.GeneratorSpec{Type: "uuid4"}
+	}
+	switch strings.ToLower(c.Name) {
+	case "name", "full_name", "person_name", "customer_name", "employee_name":
+		return domain.GeneratorSpec{Type: "faker_name"}
+	case "city", "city_name":
+		return domain.GeneratorSpec{Type: "faker_city"}
+	case "device", "device_name":
+		return domain.GeneratorSpec{Type: "faker_device_name"}
+	}
+	values := make([]interface{}, 10)
+	for i := range values {
+		values[i] = fmt.Sprintf("%s_%02d", c.Name, i+1)
+	}
+	return domain.GeneratorSpec{Type: "choice", Params: map[string]interface{}{"values": values}}
+}
+
+// uniqueGenerator replaces a source-provided generator that repeats values
+// for a unique column: integer ranges are numbered from their minimum and
+// emails get the row number in the local part.
+func uniqueGenerator(spec domain.GeneratorSpec) domain.GeneratorSpec {
+	switch spec.Type {
+	case "uniform_int":
+		start, ok := spec.Params["min"].(int64)
+		if !ok {
+			start = 1
+		}
+		return domain.GeneratorSpec{Type: "sequence", Params: map[string]interface{}{"start": start}}
+	case "faker_email":
+		return domain.GeneratorSpec{Type: "sequence", Params: map[string]interface{}{"format": "user%d@example.com"}}
+	}
+	return spec
+}
+
+// enumValues converts enum literals to the column's type so numeric CHECK ...
+// IN lists produce numeric choices.
+func enumValues(c Column) []interface{} {
+	values := make([]interface{}, len(c.Enum))
+	for i, v := range c.Enum {
+		values[i] = v
+		switch c.Type {
+		case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				values[i] = n
+			}
+		case domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				values[i] = f
+			}
+		}
+	}
+	return values
+}
+
+func uniformInt(min, max int64) domain.GeneratorSpec {
+	return domain.GeneratorSpec{Type: "uniform_int", Params: map[string]interface{}{"min": min, "max": max}}
+}
+
+// timeSeries spreads rows evenly over the window ending now.
+func timeSeries(rows int64, window time.Duration) domain.GeneratorSpec {
+	if rows <= 0 {
+		rows = 1
+	}
+	step := (window / time.Duration(rows)).Round(time.Millisecond)
+	if step <= 0 {
+		step = time.Millisecond
+	}
+	start := fmt.Sprintf("-%dh", int64(window/time.Hour))
+	if window%(24*time.Hour) == 0 {
+		start = fmt.Sprintf("-%dd", int64(window/(24*time.Hour)))
+	}
+	return domain.GeneratorSpec{Type: "time_series", Params: map[string]interface{}{
+		"start": start,
+		"step":  step.String(),
+	}}
+}
@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/registry"
+	"github.com/mmrzaf/sdgen/internal/validation"
+)
+
+func TestBuildScenario_GeneratorsKeysAndCycles(t *testing.T) {
+	tables := []Table{
+		{
+			Name:       "orders",
+			Rows:       5000,
+			PrimaryKey: []string{"order_id"},
+			Columns: []Column{
+				{Name: "order_id", Type: domain.ColumnTypeUUID},
+				{Name: "customer_id", Type: domain.ColumnTypeBigInt},
+				{Name: "status", Type: domain.ColumnTypeString, Enum: []string{"open", "paid"}},
+				{Name: "created_at", Type: domain.ColumnTypeTimestamp},
+				{Name: "user", Type: domain.ColumnTypeString},
+			},
+			ForeignKeys: []ForeignKey{{Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"customer_id"}}},
+		},
+		{
+			Name:       "customers",
+			PrimaryKey: []string{"customer_id"},
+			Unique:     [][]string{{"email"}},
+			Columns: []Column{
+				{Name: "customer_id", Type: domain.ColumnTypeBigInt},
+				{Name: "email", Type: domain.ColumnTypeString},
+				{Name: "name", Type: domain.ColumnTypeString, Nullable: true},
+				{Name: "last_order_id", Type: domain.ColumnTypeUUID, Nullable: true},
+				{Name: "referrer_id", Type: domain.ColumnTypeBigInt, Nullable: true},
+			},
+			ForeignKeys: []ForeignKey{
+				{Columns: []string{"last_order_id"}, RefTable: "orders", RefColumns: []string{"order_id"}},
+				{Columns: []string{"referrer_id"}, RefTable: "customers", RefColumns: []string{"customer_id"}},
+			},
+		},
+		{Name: "select", Columns: []Column{{Name: "id", Type: domain.ColumnTypeInt}}},
+	}
+
+	sc, warnings := BuildScenario(tables, Options{ID: "shop"})
+	if err := validation.NewValidator(registry.DefaultGeneratorRegistry()).ValidateScenario(sc); err != nil {
+		t.Fatalf("expected valid scenario, got %v", err)
+	}
+	if len(sc.Entities) != 2 || sc.Entities[0].Name != "customers" || sc.Entities[0].Rows != 1000 || sc.Entities[1].Rows != 5000 {
+		t.Fatalf("unexpected entities %+v", sc.Entities)
+	}
+
+	gens := map[string]domain.GeneratorSpec{}
+	for _, e := range sc.Entities {
+		for _, c := range e.Columns {
+			gens[e.Name+"."+c.Name] = c.Generator
+		}
+	}
+	want := map[string]string{
+		"orders.order_id":         "uuid4",
+		"orders.customer_id":      "fk",
+		"orders.status":           "choice",
+		"orders.created_at":       "time_series",
+		"customers.customer_id":   "sequence",
+		"customers.email":         "uuid4",
+		"customers.name":          "faker_name",
+		"customers.last_order_id": "uuid4",
+		"customers.referrer_id":   "uniform_int",
+	}
+	for key, typ := range want {
+		if gens[key].Type != typ {
+			t.Errorf("%s: expected %s, got %s", key, typ, gens[key].Type)
+		}
+	}
+	if !reflect.DeepEqual(gens["orders.status"].Params["values"], []interface{}{"open", "paid"}) {
+		t.Fatalf("unexpected choice values %v", gens["orders.status"].Params)
+	}
+	if gens["orders.created_at"].Params["start"] != "-30d" || gens["orders.created_at"].Params["step"] != "8m38.4s" {
+		t.Fatalf("unexpected time_series params %v", gens["orders.created_at"].Params)
+	}
+	if _, ok := gens["orders.user"]; ok {
+		t.Fatal("expected reserved-word column to be skipped")
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, w := range []string{"table select skipped", "column orders.user skipped", "customers.last_order_id -> orders dropped", "customers.referrer_id -> customers dropped"} {
+		if !strings.Contains(joined, w) {
+			t.Errorf("missing warning %q in %v", w, warnings)
+		}
+	}
+}
+
+func TestParsePostgresCheckIn(t *testing.T) {
+	got := parsePostgresCheckIn(`CHECK (((status)::text = ANY ((ARRAY['open'::character varying, 'won''t fix'::character varying])::text[])))`)
+	if !reflect.DeepEqual(got, []string{"open", "won't fix"}) {
+		t.Fatalf("unexpected values %v", got)
+	}
+	if got := parsePostgresCheckIn(`CHECK ((amount > (0)::numeric))`); got != nil {
+		t.Fatalf("expected no values, got %v", got)
+	}
+}
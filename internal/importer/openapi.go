@@ -0,0 +1,512 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/generators"
+	"gopkg.in/yaml.v3"
+)
+
+// ParseOpenAPI reads object schemas from an OpenAPI 3 document
+// (components.schemas), a Swagger 2 document (definitions) or a JSON Schema
+// document ($defs) and maps each to a table. components selects schemas by
+// name; empty selects every object schema. Component names become plural
+// snake_case table names and property names snake_case columns. A property
+// that is a $ref to another selected object schema becomes a <property>_id
+// column referencing that schema's id property. Nested objects, arrays and
+// references to unselected schemas are skipped with a warning.
+func ParseOpenAPI(doc []byte, components []string) ([]Table, []string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil {
+		return nil, nil, fmt.Errorf("parse document: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return nil, nil, fmt.Errorf("empty document")
+	}
+	schemas := schemaSection(root.Content[0])
+	if schemas == nil {
+		return nil, nil, fmt.Errorf("no components.schemas, definitions or $defs section found")
+	}
+	p := &openAPIParser{schemas: map[string]*yaml.Node{}}
+	var all []string
+	for _, kv := range pairs(schemas) {
+		p.schemas[kv.key] = kv.value
+		all = append(all, kv.key)
+	}
+
+	selected := components
+	if len(selected) == 0 {
+		for _, name := range all {
+			if p.isObject(p.schemas[name]) {
+				selected = append(selected, name)
+			}
+		}
+	}
+	p.selected = map[string]bool{}
+	for _, name := range selected {
+		s, ok := p.schemas[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("component not found: %s", name)
+		}
+		if !p.isObject(s) {
+			return nil, nil, fmt.Errorf("component %s is not an object schema", name)
+		}
+		p.selected[name] = true
+	}
+	if len(selected) == 0 {
+		return nil, nil, fmt.Errorf("no object schemas found")
+	}
+
+	tables := make([]Table, 0, len(selected))
+	for _, name := range selected {
+		tables = append(tables, p.table(name))
+	}
+	return tables, p.warnings, nil
+}
+
+func schemaSection(doc *yaml.Node) *yaml.Node {
+	if c := field(doc, "components"); c != nil {
+		if s := field(c, "schemas"); s != nil {
+			return s
+		}
+	}
+	if s := field(doc, "definitions"); s != nil {
+		return s
+	}
+	return field(doc, "$defs")
+}
+
+type openAPIParser struct {
+	schemas  map[string]*yaml.Node
+	selected map[string]bool
+	warnings []string
+}
+
+func (p *openAPIParser) warn(format string, args ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// table maps an object schema, merging allOf members, to a table.
+func (p *openAPIParser) table(component string) Table {
+	t := Table{Name: TableName(component)}
+	props, required := p.properties(p.schemas[component], map[string]bool{})
+	for _, kv := range props {
+		col, fk, ok := p.column(component, kv.key, kv.value, required[kv.key])
+		if !ok {
+			continue
+		}
+		if col.Name == "id" {
+			t.PrimaryKey = []string{"id"}
+			col.Nullable = false
+		}
+		if fk != nil {
+			t.ForeignKeys = append(t.ForeignKeys, *fk)
+		}
+		t.Columns = append(t.Columns, col)
+	}
+	return t
+}
+
+// properties returns the ordered properties and required set of an object
+// schema, following $ref and allOf.
+func (p *openAPIParser) properties(s *yaml.Node, seen map[string]bool) ([]nodePair, map[string]bool) {
+	required := map[string]bool{}
+	var props []nodePair
+	if ref := scalar(field(s, "$ref")); ref != "" {
+		name := refName(ref)
+		if seen[name] || p.schemas[name] == nil {
+			return nil, required
+		}
+		seen[name] = true
+		return p.properties(p.schemas[name], seen)
+	}
+	if all := field(s, "allOf"); all != nil {
+		for _, member := range all.Content {
+			more, req := p.properties(member, seen)
+			props = append(props, more...)
+			for k := range req {
+				required[k] = true
+			}
+		}
+	}
+	props = append(props, pairs(field(s, "properties"))...)
+	if req := field(s, "required"); req != nil {
+		for _, n := range req.Content {
+			required[n.Value] = true
+		}
+	}
+	return props, required
+}
+
+func (p *openAPIParser) isObject(s *yaml.Node) bool {
+	s = p.deref(s)
+	if s == nil {
+		return false
+	}
+	return field(s, "properties") != nil || field(s, "allOf") != nil || hasType(s, "object")
+}
+
+// deref follows a chain of $refs to the schema it ends at. A dangling or
+// cyclic chain gives nil.
+func (p *openAPIParser) deref(s *yaml.Node) *yaml.Node {
+	seen := map[string]bool{}
+	for s != nil {
+		ref := scalar(field(s, "$ref"))
+		if ref == "" {
+			return s
+		}
+		name := refName(ref)
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+		s = p.schemas[name]
+	}
+	return nil
+}
+
+// refTarget returns the component referenced by a property, unwrapping the
+// allOf/oneOf/anyOf wrappers commonly used for nullable or described refs.
+func (p *openAPIParser) refTarget(s *yaml.Node) (string, bool) {
+	if ref := scalar(field(s, "$ref")); ref != "" {
+		return refName(ref), true
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		list := field(s, key)
+		if list == nil {
+			continue
+		}
+		var target string
+		for _, member := range list.Content {
+			if ref := scalar(field(member, "$ref")); ref != "" {
+				if target != "" {
+					return "", false
+				}
+				target = refName(ref)
+			} else if !hasType(member, "null") {
+				return "", false
+			}
+		}
+		if target != "" {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// column maps a property; ok is false when the property is skipped.
+func (p *openAPIParser) column(component, prop string, s *yaml.Node, required bool) (Column, *ForeignKey, bool) {
+	name := SnakeCase(prop)
+	nullable := !required || scalar(field(s, "nullable")) == "true" || hasType(s, "null") || hasNullMember(s)
+
+	if target, ok := p.refTarget(s); ok {
+		if ref := p.schemas[target]; ref != nil && !p.isObject(ref) {
+			// a $ref to a shared scalar schema such as an enum: inline it
+			col, ok := p.scalarColumn(component, prop, name, ref)
+			col.Nullable = nullable
+			return col, nil, ok
+		}
+		if !p.selected[target] {
+			p.warn("%s.%s references %s, which is not imported; skipped", component, prop, target)
+			return Column{}, nil, false
+		}
+		idProps, _ := p.properties(p.schemas[target], map[string]bool{})
+		var idSchema *yaml.Node
+		for _, kv := range idProps {
+			if kv.key == "id" {
+				idSchema = kv.value
+			}
+		}
+		if idSchema == nil {
+			p.warn("%s.%s references %s, which has no id property; skipped", component, prop, target)
+			return Column{}, nil, false
+		}
+		idCol, _ := p.scalarColumn(target, "id", "id", idSchema)
+		if !strings.HasSuffix(name, "_id") {
+			name += "_id"
+		}
+		col := Column{Name: name, Type: idCol.Type, Nullable: nullable}
+		return col, &ForeignKey{Columns: []string{name}, RefTable: TableName(target), RefColumns: []string{"id"}}, true
+	}
+	col, ok := p.scalarColumn(component, prop, name, s)
+	col.Nullable = nullable
+	return col, nil, ok
+}
+
+// scalarColumn maps type, format, enum, bounds and pattern of a scalar schema.
+func (p *openAPIParser) scalarColumn(component, prop, name string, s *yaml.Node) (Column, bool) {
+	if ref := scalar(field(s, "$ref")); ref != "" {
+		if s = p.deref(s); s == nil {
+			p.warn("%s.%s: $ref %s is dangling or cyclic; skipped", component, prop, ref)
+			return Column{}, false
+		}
+	}
+	col := Column{Name: name}
+	if e := field(s, "enum"); e != nil {
+		for _, v := range e.Content {
+			if v.Tag != "!!null" {
+				col.Enum = append(col.Enum, v.Value)
+			}
+		}
+	} else if c := field(s, "const"); c != nil {
+		col.Enum = []string{c.Value}
+	}
+
+	typ := schemaType(s)
+	if typ == "" && len(col.Enum) > 0 {
+		typ = "string"
+	}
+	format := scalar(field(s, "format"))
+	switch typ {
+	case "string":
+		switch format {
+		case "uuid":
+			col.Type = domain.ColumnTypeUUID
+		case "date-time":
+			col.Type = domain.ColumnTypeTimestamp
+		case "date":
+			col.Type = domain.ColumnTypeDate
+		default:
+			col.Type = domain.ColumnTypeString
+			if n, err := strconv.Atoi(scalar(field(s, "maxLength"))); err == nil && n > 255 {
+				col.Type = domain.ColumnTypeText
+			}
+		}
+		if len(col.Enum) > 0 {
+			break
+		}
+		if format == "email" {
+			col.Generator = &domain.GeneratorSpec{Type: "faker_email"}
+		} else if pattern := scalar(field(s, "pattern")); pattern != "" {
+			if values := patternAlternatives(pattern); len(values) > 0 {
+				col.Enum = values
+			} else if _, err := generators.ParsePattern(pattern); err == nil && (col.Type == domain.ColumnTypeString || col.Type == domain.ColumnTypeText) {
+				col.Generator = &domain.GeneratorSpec{Type: "pattern", Params: map[string]interface{}{"pattern": pattern}}
+			} else {
+				p.warn("%s.%s: pattern %q is not enforced", component, prop, pattern)
+			}
+		}
+	case "integer":
+		col.Type = domain.ColumnTypeInt
+		if format == "int64" {
+			col.Type = domain.ColumnTypeBigInt
+		}
+		limit := 2147483646.0
+		if col.Type == domain.ColumnTypeBigInt {
+			limit = 9007199254740990
+		}
+		if lo, hi, ok := bounds(s, limit, 1); ok && len(col.Enum) == 0 {
+			spec := uniformInt(int64(math.Ceil(lo)), int64(math.Floor(hi))+1)
+			col.Generator = &spec
+		}
+	case "number":
+		col.Type = domain.ColumnTypeDouble
+		if format == "float" {
+			col.Type = domain.ColumnTypeFloat
+		}
+		if lo, hi, ok := bounds(s, 0, 0); ok && len(col.Enum) == 0 {
+			col.Generator = &domain.GeneratorSpec{Type: "uniform_float", Params: map[string]interface{}{"min": lo, "max": hi}}
+		}
+	case "boolean":
+		col.Type = domain.ColumnTypeBool
+	default:
+		p.warn("%s.%s: %s properties are not supported; skipped", component, prop, orUnknown(typ))
+		return Column{}, false
+	}
+	return col, true
+}
+
+func orUnknown(typ string) string {
+	if typ == "" {
+		return "untyped"
+	}
+	return typ
+}
+
+// bounds reads minimum/maximum with OpenAPI 3.0 boolean and JSON Schema
+// numeric exclusive bounds. Exclusive bounds move inwards by step, or for
+// numbers (step 0) by a small relative epsilon. A missing maximum becomes
+// limit when set (the largest value of an integer type), otherwise bounds are
+// widened by 1000.
+func bounds(s *yaml.Node, limit, step float64) (float64, float64, bool) {
+	inward := func(v float64) float64 {
+		if step > 0 {
+			return step
+		}
+		return 1e-9 * math.Max(1, math.Abs(v))
+	}
+	lo, hasLo := number(field(s, "minimum"))
+	hi, hasHi := number(field(s, "maximum"))
+	if v, ok := number(field(s, "exclusiveMinimum")); ok {
+		lo, hasLo = v+inward(v), true
+	} else if scalar(field(s, "exclusiveMinimum")) == "true" {
+		lo += inward(lo)
+	}
+	if v, ok := number(field(s, "exclusiveMaximum")); ok {
+		hi, hasHi = v-inward(v), true
+	} else if scalar(field(s, "exclusiveMaximum")) == "true" {
+		hi -= inward(hi)
+	}
+	switch {
+	case hasLo && hasHi:
+		return lo, math.Max(lo, hi), true
+	case hasLo && limit > 0 && limit > lo:
+		return lo, limit, true
+	case hasLo:
+		return lo, lo + 1000, true
+	case hasHi:
+		return hi - 1000, hi, true
+	}
+	return 0, 0, false
+}
+
+func number(n *yaml.Node) (float64, bool) {
+	if n == nil || (n.Tag != "!!int" && n.Tag != "!!float") {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(n.Value, 64)
+	return f, err == nil
+}
+
+var patternAlternativesRe = regexp.MustCompile(`^\^?\(?((?:[A-Za-z0-9_\- ]+\|)+[A-Za-z0-9_\- ]+)\)?\$?$`)
+
+// patternAlternatives returns the literals of a pattern such as ^(a|b|c)$.
+func patternAlternatives(pattern string) []string {
+	m := patternAlternativesRe.FindStringSubmatch(pattern)
+	if m == nil {
+		return nil
+	}
+	return strings.Split(m[1], "|")
+}
+
+func schemaType(s *yaml.Node) string {
+	t := field(s, "type")
+	if t == nil {
+		return ""
+	}
+	if t.Kind == yaml.SequenceNode {
+		for _, n := range t.Content {
+			if n.Value != "null" {
+				return n.Value
+			}
+		}
+		return ""
+	}
+	return t.Value
+}
+
+func hasType(s *yaml.Node, typ string) bool {
+	t := field(s, "type")
+	if t == nil {
+		return false
+	}
+	if t.Kind == yaml.SequenceNode {
+		for _, n := range t.Content {
+			if n.Value == typ {
+				return true
+			}
+		}
+		return false
+	}
+	return t.Value == typ
+}
+
+func hasNullMember(s *yaml.Node) bool {
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if list := field(s, key); list != nil {
+			for _, member := range list.Content {
+				if hasType(member, "null") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// refName returns the last segment of a local reference such as
+// #/components/schemas/User.
+func refName(ref string) string {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+}
+
+type nodePair struct {
+	key   string
+	value *yaml.Node
+}
+
+func pairs(n *yaml.Node) []nodePair {
+	n = deref(n)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	out := make([]nodePair, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out = append(out, nodePair{key: n.Content[i].Value, value: deref(n.Content[i+1])})
+	}
+	return out
+}
+
+func field(n *yaml.Node, key string) *yaml.Node {
+	for _, kv := range pairs(n) {
+		if kv.key == key {
+			return kv.value
+		}
+	}
+	return nil
+}
+
+func scalar(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+func deref(n *yaml.Node) *yaml.Node {
+	if n != nil && n.Kind == yaml.AliasNode {
+		return n.Alias
+	}
+	return n
+}
+
+// SnakeCase converts camelCase and PascalCase names to snake_case.
+func SnakeCase(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 && rs[i-1] != '_' && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// TableName returns the plural snake_case table name of a component, which
+// also keeps names such as User and Order clear of SQL reserved words.
+func TableName(component string) string {
+	name := SnakeCase(component)
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
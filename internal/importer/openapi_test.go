@@ -0,0 +1,236 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/infra/repos/scenarios"
+	"github.com/mmrzaf/sdgen/internal/registry"
+	"github.com/mmrzaf/sdgen/internal/validation"
+	"gopkg.in/yaml.v3"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info: {title: shop, version: "1"}
+paths: {}
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [open, paid, shipped]
+    Audit:
+      type: object
+      properties:
+        createdAt: {type: string, format: date-time}
+    User:
+      type: object
+      required: [id, email]
+      properties:
+        id: {type: string, format: uuid}
+        email: {type: string, format: email}
+        displayName: {type: string, maxLength: 1000}
+        age: {type: integer, minimum: 18, maximum: 99}
+        countryCode: {type: string, pattern: "^(US|DE|JP)$"}
+        handle: {type: string, pattern: "^[a-z]+$"}
+        postcode: {type: string, pattern: "^\\d{5}(-\\d{4})?$"}
+        tags: {type: array, items: {type: string}}
+    Order:
+      allOf:
+        - $ref: '#/components/schemas/Audit'
+        - type: object
+          required: [id, buyer, total]
+          properties:
+            id: {type: integer, format: int64, minimum: 1}
+            buyer: {$ref: '#/components/schemas/User'}
+            reviewer:
+              nullable: true
+              allOf: [{$ref: '#/components/schemas/User'}]
+            status: {$ref: '#/components/schemas/Status'}
+            total: {type: number, minimum: 0, exclusiveMinimum: true, maximum: 500}
+            priority: {type: integer, enum: [1, 2, 3]}
+            coupon: {$ref: '#/components/schemas/Coupon'}
+    Coupon:
+      type: object
+      properties:
+        code: {type: string}
+`
+
+func TestParseOpenAPI_Components(t *testing.T) {
+	tables, warnings, err := ParseOpenAPI([]byte(testSpec), []string{"User", "Order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].Name != "users" || tables[1].Name != "orders" {
+		t.Fatalf("unexpected tables: %+v", tables)
+	}
+
+	users := tables[0]
+	wantUsers := []Column{
+		{Name: "id", Type: domain.ColumnTypeUUID},
+		{Name: "email", Type: domain.ColumnTypeString, Generator: &domain.GeneratorSpec{Type: "faker_email"}},
+		{Name: "display_name", Type: domain.ColumnTypeText, Nullable: true},
+		{Name: "age", Type: domain.ColumnTypeInt, Nullable: true, Generator: &domain.GeneratorSpec{Type: "uniform_int", Params: map[string]interface{}{"min": int64(18), "max": int64(100)}}},
+		{Name: "country_code", Type: domain.ColumnTypeString, Nullable: true, Enum: []string{"US", "DE", "JP"}},
+		{Name: "handle", Type: domain.ColumnTypeString, Nullable: true},
+		{Name: "postcode", Type: domain.ColumnTypeString, Nullable: true, Generator: &domain.GeneratorSpec{Type: "pattern", Params: map[string]interface{}{"pattern": `^\d{5}(-\d{4})?$`}}},
+	}
+	if !reflect.DeepEqual(users.Columns, wantUsers) {
+		t.Fatalf("users columns:\n got %+v\nwant %+v", users.Columns, wantUsers)
+	}
+	if !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) {
+		t.Fatalf("expected id primary key, got %v", users.PrimaryKey)
+	}
+
+	orders := tables[1]
+	cols := map[string]Column{}
+	var names []string
+	for _, c := range orders.Columns {
+		cols[c.Name] = c
+		names = append(names, c.Name)
+	}
+	if want := []string{"created_at", "id", "buyer_id", "reviewer_id", "status", "total", "priority"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("orders columns %v, want %v", names, want)
+	}
+	if cols["id"].Type != domain.ColumnTypeBigInt || cols["id"].Generator.Params["max"] != int64(9007199254740991) {
+		t.Fatalf("unexpected id column: %+v", cols["id"])
+	}
+	if cols["buyer_id"].Type != domain.ColumnTypeUUID || cols["buyer_id"].Nullable || !cols["reviewer_id"].Nullable {
+		t.Fatalf("unexpected reference columns: %+v %+v", cols["buyer_id"], cols["reviewer_id"])
+	}
+	wantFKs := []ForeignKey{
+		{Columns: []string{"buyer_id"}, RefTable: "users", RefColumns: []string{"id"}},
+		{Columns: []string{"reviewer_id"}, RefTable: "users", RefColumns: []string{"id"}},
+	}
+	if !reflect.DeepEqual(orders.ForeignKeys, wantFKs) {
+		t.Fatalf("foreign keys:\n got %+v\nwant %+v", orders.ForeignKeys, wantFKs)
+	}
+	if !reflect.DeepEqual(cols["status"].Enum, []string{"open", "paid", "shipped"}) {
+		t.Fatalf("shared enum schema not inlined: %+v", cols["status"])
+	}
+	if got := cols["total"].Generator.Params; got["min"].(float64) <= 0 || got["min"].(float64) > 1e-6 || got["max"] != 500.0 {
+		t.Fatalf("unexpected total bounds: %v", got)
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"User.handle: pattern", "User.tags: array", "Order.coupon references Coupon"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing warning %q in:\n%s", want, joined)
+		}
+	}
+}
+
+func TestParseOpenAPI_NegativeMinimumOnly(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Reading:
+      type: object
+      properties:
+        delta: {type: number, minimum: -100}
+        offset: {type: integer, minimum: -5}
+`
+	tables, _, err := ParseOpenAPI([]byte(spec), []string{"Reading"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := tables[0].Columns
+	if got := cols[0].Generator.Params; got["min"] != -100.0 || got["max"] != 900.0 {
+		t.Fatalf("expected a number with only minimum -100 to span [-100,900], got %v", got)
+	}
+	if got := cols[1].Generator.Params; got["min"] != int64(-5) || got["max"] != int64(2147483647) {
+		t.Fatalf("expected an integer with only minimum -5 to reach the type limit, got %v", got)
+	}
+}
+
+func TestParseOpenAPI_ScenarioLoadsFromFileRepository(t *testing.T) {
+	tables, _, err := ParseOpenAPI([]byte(testSpec), []string{"User", "Order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, _ := BuildScenario(tables, Options{ID: "shop", DefaultRows: 10})
+	b, err := yaml.Marshal(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "shop.yaml"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := scenarios.NewFileRepository(dir).Get("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validation.NewValidator(registry.DefaultGeneratorRegistry()).ValidateScenario(loaded); err != nil {
+		t.Fatalf("imported scenario is invalid: %v", err)
+	}
+	for _, e := range sc.Entities {
+		for _, c := range e.Columns {
+			if e.Name == "orders" && c.Name == "id" && (c.Generator.Type != "sequence" || c.Generator.Params["start"] != int64(1)) {
+				t.Fatalf("expected the integer primary key to be a sequence, got %+v", c.Generator)
+			}
+		}
+	}
+	for _, c := range loaded.Entities[0].Columns {
+		if c.Name == "priority" && !reflect.DeepEqual(c.Generator.Params["values"], []interface{}{1, 2, 3}) {
+			t.Fatalf("expected numeric enum choice, got %#v", c.Generator.Params["values"])
+		}
+	}
+}
+
+func TestParseOpenAPI_Errors(t *testing.T) {
+	if _, _, err := ParseOpenAPI([]byte(testSpec), []string{"Missing"}); err == nil {
+		t.Fatal("expected an error for an unknown component")
+	}
+	if _, _, err := ParseOpenAPI([]byte(testSpec), []string{"Status"}); err == nil {
+		t.Fatal("expected an error for a non-object component")
+	}
+	if _, _, err := ParseOpenAPI([]byte("openapi: 3.0.0\n"), nil); err == nil {
+		t.Fatal("expected an error for a document without schemas")
+	}
+}
+
+func TestParseOpenAPI_CyclicRef(t *testing.T) {
+	spec := `
+openapi: 3.0.3
+info: {title: loop, version: "1"}
+paths: {}
+components:
+  schemas:
+    A: {$ref: '#/components/schemas/B'}
+    B: {$ref: '#/components/schemas/A'}
+    Item:
+      type: object
+      properties:
+        id: {type: string, format: email}
+        loop: {$ref: '#/components/schemas/A'}
+`
+	tables, warnings, err := ParseOpenAPI([]byte(spec), []string{"Item"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables[0].Columns) != 1 || !strings.Contains(strings.Join(warnings, "\n"), "Item.loop: $ref") {
+		t.Fatalf("expected the cyclic column to be skipped, got %+v %v", tables[0].Columns, warnings)
+	}
+	if _, _, err := ParseOpenAPI([]byte(spec), []string{"A"}); err == nil {
+		t.Fatal("expected an error for a cyclic component")
+	}
+	sc, _ := BuildScenario(tables, Options{ID: "loop", DefaultRows: 10})
+	if g := sc.Entities[0].Columns[0].Generator; g.Type != "sequence" || g.Params["format"] != "user%d@example.com" {
+		t.Fatalf("expected a numbered email for the unique id, got %+v", g)
+	}
+}
+
+func TestTableName(t *testing.T) {
+	for in, want := range map[string]string{"User": "users", "OrderItem": "order_items", "Category": "categories", "Address": "addresses", "HTTPLog": "http_logs", "Day": "days"} {
+		if got := TableName(in); got != want {
+			t.Fatalf("TableName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
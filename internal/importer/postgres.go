@@ -0,0 +1,322 @@
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// InspectPostgres reads tables, columns, keys, enum types and single-column
+// CHECK ... IN lists of a schema from information_schema and pg_catalog.
+// Row counts come from planner statistics, falling back to count(*) for
+// tables that were never analyzed.
+func InspectPostgres(dsn, schema string) ([]Table, error) {
+	if schema == "" {
+		schema = "public"
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	tables, index, err := pgTables(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	enums, err := pgEnums(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := pgColumns(db, schema, index, enums); err != nil {
+		return nil, err
+	}
+	if err := pgConstraints(db, schema, index); err != nil {
+		return nil, err
+	}
+	for _, t := range tables {
+		if t.Rows >= 0 {
+			continue
+		}
+		if err := db.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(t.Name))).Scan(&t.Rows); err != nil {
+			return nil, fmt.Errorf("count rows of %s: %w", t.Name, err)
+		}
+	}
+
+	out := make([]Table, len(tables))
+	for i, t := range tables {
+		out[i] = *t
+	}
+	return out, nil
+}
+
+func pgTables(db *sql.DB, schema string) ([]*Table, map[string]*Table, error) {
+	rows, err := db.Query(`
+		SELECT c.relname, c.reltuples::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		ORDER BY c.relname`, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var tables []*Table
+	index := map[string]*Table{}
+	for rows.Next() {
+		t := &Table{}
+		if err := rows.Scan(&t.Name, &t.Rows); err != nil {
+			return nil, nil, err
+		}
+		tables = append(tables, t)
+		index[t.Name] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(tables) == 0 {
+		return nil, nil, fmt.Errorf("no tables found in schema %s", schema)
+	}
+	return tables, index, nil
+}
+
+// pgEnums returns enum labels keyed by "schema.type", since same-named enums
+// in different schemas are distinct types.
+func pgEnums(db *sql.DB) (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT n.nspname || '.' || t.typname, e.enumlabel
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		ORDER BY n.nspname, t.typname, e.enumsortorder`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	enums := map[string][]string{}
+	for rows.Next() {
+		var typ, label string
+		if err := rows.Scan(&typ, &label); err != nil {
+			return nil, err
+		}
+		enums[typ] = append(enums[typ], label)
+	}
+	return enums, rows.Err()
+}
+
+func pgColumns(db *sql.DB, schema string, index map[string]*Table, enums map[string][]string) error {
+	rows, err := db.Query(`
+		SELECT table_name, column_name, data_type, udt_schema, udt_name, is_nullable
+		FROM information_schema.columns
+		WHERE table_schema = $1
+		ORDER BY table_name, ordinal_position`, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, name, dataType, udtSchema, udt, nullable string
+		if err := rows.Scan(&table, &name, &dataType, &udtSchema, &udt, &nullable); err != nil {
+			return err
+		}
+		t, ok := index[table]
+		if !ok {
+			continue
+		}
+		t.Columns = append(t.Columns, Column{
+			Name:     name,
+			Type:     PostgresColumnType(dataType),
+			Nullable: nullable == "YES",
+			Enum:     enums[udtSchema+"."+udt],
+		})
+	}
+	return rows.Err()
+}
+
+func pgConstraints(db *sql.DB, schema string, index map[string]*Table) error {
+	rows, err := db.Query(`
+		SELECT rel.relname, con.contype,
+			ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[],
+			COALESCE(fns.nspname, ''), COALESCE(frel.relname, ''),
+			ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.ord)::text[],
+			pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class rel ON rel.oid = con.conrelid
+		JOIN pg_namespace ns ON ns.oid = rel.relnamespace
+		LEFT JOIN pg_class frel ON frel.oid = con.confrelid
+		LEFT JOIN pg_namespace fns ON fns.oid = frel.relnamespace
+		WHERE ns.nspname = $1 AND con.contype IN ('p', 'u', 'f', 'c')
+		ORDER BY rel.relname, con.conname`, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			table, kind, refSchema, refTable, def string
+			cols, refCols                         []string
+		)
+		if err := rows.Scan(&table, &kind, pq.Array(&cols), &refSchema, &refTable, pq.Array(&refCols), &def); err != nil {
+			return err
+		}
+		t, ok := index[table]
+		if !ok {
+			continue
+		}
+		switch kind {
+		case "p":
+			t.PrimaryKey = cols
+		case "u":
+			t.Unique = append(t.Unique, cols)
+		case "f":
+			if refSchema != schema {
+				refTable = refSchema + "." + refTable
+			}
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKey{Columns: cols, RefTable: refTable, RefColumns: refCols})
+		case "c":
+			if len(cols) != 1 {
+				continue
+			}
+			if values := parsePostgresCheckIn(def); len(values) > 0 {
+				for i := range t.Columns {
+					if t.Columns[i].Name == cols[0] && len(t.Columns[i].Enum) == 0 {
+						t.Columns[i].Enum = values
+					}
+				}
+			}
+		}
+	}
+	return rows.Err()
+}
+
+var (
+	pgCheckAnyRe   = regexp.MustCompile(`(?is)=\s*ANY\s*\(\s*\(?\s*ARRAY\s*\[(.*)\]`)
+	pgCheckLiteral = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+// parsePostgresCheckIn extracts the literals of a constraint definition such
+// as CHECK ((status = ANY (ARRAY['open'::text, 'closed'::text]))).
+func parsePostgresCheckIn(def string) []string {
+	m := pgCheckAnyRe.FindStringSubmatch(def)
+	if m == nil {
+		return nil
+	}
+	var values []string
+	for _, lit := range pgCheckLiteral.FindAllStringSubmatch(m[1], -1) {
+		values = append(values, strings.ReplaceAll(lit[1], "''", "'"))
+	}
+	return values
+}
+
+// PostgresColumnType maps an information_schema data_type to a column type.
+func PostgresColumnType(dataType string) domain.ColumnType {
+	switch strings.ToLower(dataType) {
+	case "smallint", "integer":
+		return domain.ColumnTypeInt
+	case "bigint":
+		return domain.ColumnTypeBigInt
+	case "real":
+		return domain.ColumnTypeFloat
+	case "double precision", "numeric", "decimal", "money":
+		return domain.ColumnTypeDouble
+	case "boolean":
+		return domain.ColumnTypeBool
+	case "uuid":
+		return domain.ColumnTypeUUID
+	case "date":
+		return domain.ColumnTypeDate
+	case "timestamp without time zone", "timestamp with time zone", "timestamp", "timestamptz":
+		return domain.ColumnTypeTimestamp
+	case "text", "json", "jsonb", "xml":
+		return domain.ColumnTypeText
+	default:
+		return domain.ColumnTypeString
+	}
+}
+
+// ProfilePostgres samples up to opts.SampleRows rows of every table and sets
+// each column's generator from FitColumn. Foreign key columns are left to
+// BuildScenario.
+func ProfilePostgres(dsn, schema string, tables []Table, opts ProfileOptions) error {
+	opts = opts.withDefaults()
+	if schema == "" {
+		schema = "public"
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for i := range tables {
+		t := &tables[i]
+		sample, err := samplePostgres(db, schema, t, opts.SampleRows)
+		if err != nil {
+			return fmt.Errorf("sample %s: %w", t.Name, err)
+		}
+		unique := uniqueColumns(t)
+		fks := map[string]bool{}
+		for _, fk := range t.ForeignKeys {
+			if len(fk.Columns) == 1 {
+				fks[fk.Columns[0]] = true
+			}
+		}
+		for j := range t.Columns {
+			c := &t.Columns[j]
+			if fks[c.Name] {
+				continue
+			}
+			spec := FitColumn(*c, sample[j], unique[c.Name], t.Rows, opts)
+			c.Generator = &spec
+		}
+	}
+	return nil
+}
+
+// samplePostgres returns sampled values per column, in column order. Large
+// tables are sampled with TABLESAMPLE BERNOULLI sized from the row estimate.
+func samplePostgres(db *sql.DB, schema string, t *Table, limit int) ([][]interface{}, error) {
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = pq.QuoteIdentifier(c.Name)
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s.%s", strings.Join(cols, ", "), pq.QuoteIdentifier(schema), pq.QuoteIdentifier(t.Name))
+	if t.Rows > int64(limit) {
+		pct := math.Min(100, float64(limit)*120/float64(t.Rows))
+		query += fmt.Sprintf(" TABLESAMPLE BERNOULLI (%.6f)", pct)
+	}
+	query += fmt.Sprintf(" LIMIT %d", limit)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([][]interface{}, len(cols))
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			out[i] = append(out[i], v)
+		}
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+type ProfileOptions struct {
+	// SampleRows is the number of rows sampled per table (default 10000).
+	SampleRows int
+	// MaxCardinality is the largest number of distinct text values copied into
+	// a choice generator (default 20). Columns with more distinct values keep
+	// their type-based default so identifying values are never copied.
+	MaxCardinality int
+}
+
+func (o ProfileOptions) withDefaults() ProfileOptions {
+	if o.SampleRows <= 0 {
+		o.SampleRows = 10000
+	}
+	if o.MaxCardinality <= 0 {
+		o.MaxCardinality = 20
+	}
+	return o
+}
+
+// FitColumn derives a generator from sampled values of a column. Text values
+// are only copied when the column has at most MaxCardinality distinct values
+// and each copied value occurs at least twice in the sample. Nullable columns
+// get a null_rate from the sampled NULL fraction.
+func FitColumn(c Column, sample []interface{}, unique bool, rows int64, opts ProfileOptions) domain.GeneratorSpec {
+	opts = opts.withDefaults()
+	spec := fitValues(c, nonNull(sample), unique, rows, opts)
+	if c.Nullable && len(sample) > 0 {
+		nulls := len(sample) - len(nonNull(sample))
+		if nulls > 0 {
+			if spec.Params == nil {
+				spec.Params = map[string]interface{}{}
+			}
+			spec.Params["null_rate"] = round(float64(nulls)/float64(len(sample)), 4)
+		}
+	}
+	return spec
+}
+
+func fitValues(c Column, values []interface{}, unique bool, rows int64, opts ProfileOptions) domain.GeneratorSpec {
+	fallback := DefaultGenerator(c, unique, rows, 30*24*time.Hour)
+	if len(values) == 0 || unique {
+		return fallback
+	}
+	if len(c.Enum) > 0 {
+		counts := countValues(values, func(v interface{}) (interface{}, bool) {
+			s, ok := v.(string)
+			return s, ok
+		})
+		seen := map[interface{}]int{}
+		for _, vc := range counts {
+			seen[vc.value] = vc.count
+		}
+		enum := make([]valueCount, len(c.Enum))
+		for i, v := range c.Enum {
+			enum[i] = valueCount{value: v, count: seen[v]}
+		}
+		return weightedChoice(enum, 0)
+	}
+	switch c.Type {
+	case domain.ColumnTypeBool:
+		return weightedChoice(countValues(values, func(v interface{}) (interface{}, bool) {
+			b, ok := v.(bool)
+			return b, ok
+		}), 1)
+	case domain.ColumnTypeInt, domain.ColumnTypeBigInt:
+		nums := numbers(values)
+		if len(nums) == 0 {
+			return fallback
+		}
+		lo, hi := minMax(nums)
+		return uniformInt(int64(math.Floor(lo)), int64(math.Floor(hi))+1)
+	case domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+		nums := numbers(values)
+		if len(nums) == 0 {
+			return fallback
+		}
+		return fitFloat(nums)
+	case domain.ColumnTypeTimestamp, domain.ColumnTypeDate:
+		times := timesOf(values)
+		if len(times) == 0 {
+			return fallback
+		}
+		return fitTimes(times, rows)
+	case domain.ColumnTypeString, domain.ColumnTypeText:
+		counts := countValues(values, func(v interface{}) (interface{}, bool) {
+			s, ok := v.(string)
+			return s, ok
+		})
+		if len(counts) > opts.MaxCardinality {
+			return fallback
+		}
+		spec := weightedChoice(counts, 2)
+		if spec.Type == "" {
+			return fallback
+		}
+		return spec
+	}
+	return fallback
+}
+
+type valueCount struct {
+	value interface{}
+	count int
+}
+
+// countValues returns distinct values by descending frequency.
+func countValues(values []interface{}, key func(interface{}) (interface{}, bool)) []valueCount {
+	idx := map[interface{}]int{}
+	var out []valueCount
+	for _, v := range values {
+		k, ok := key(v)
+		if !ok {
+			continue
+		}
+		if i, seen := idx[k]; seen {
+			out[i].count++
+			continue
+		}
+		idx[k] = len(out)
+		out = append(out, valueCount{value: k, count: 1})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return fmt.Sprint(out[i].value) < fmt.Sprint(out[j].value)
+	})
+	return out
+}
+
+// weightedChoice builds a choice over values seen at least minCount times.
+func weightedChoice(counts []valueCount, minCount int) domain.GeneratorSpec {
+	var values, weights []interface{}
+	for _, vc := range counts {
+		if vc.count < minCount {
+			continue
+		}
+		values = append(values, vc.value)
+		weights = append(weights, vc.count)
+	}
+	if len(values) == 0 {
+		return domain.GeneratorSpec{}
+	}
+	return domain.GeneratorSpec{Type: "choice", Params: map[string]interface{}{"values": values, "weights": weights}}
+}
+
+// fitFloat uses uniform_float when the spread matches a uniform distribution
+// over [min, max] and normal otherwise.
+func fitFloat(nums []float64) domain.GeneratorSpec {
+	lo, hi := minMax(nums)
+	mean, std := meanStd(nums)
+	uniformStd := (hi - lo) / math.Sqrt(12)
+	if hi > lo && math.Abs(std-uniformStd)/uniformStd < 0.1 {
+		return domain.GeneratorSpec{Type: "uniform_float", Params: map[string]interface{}{"min": round(lo, 6), "max": round(hi, 6)}}
+	}
+	return domain.GeneratorSpec{Type: "normal", Params: map[string]interface{}{"mean": round(mean, 6), "std": round(std, 6)}}
+}
+
+// fitTimes spreads rows over the sampled time range.
+func fitTimes(times []time.Time, rows int64) domain.GeneratorSpec {
+	lo, hi := times[0], times[0]
+	for _, ts := range times[1:] {
+		if ts.Before(lo) {
+			lo = ts
+		}
+		if ts.After(hi) {
+			hi = ts
+		}
+	}
+	if rows <= 0 {
+		rows = 1
+	}
+	step := (hi.Sub(lo) / time.Duration(rows)).Round(time.Millisecond)
+	if step <= 0 {
+		step = time.Millisecond
+	}
+	return domain.GeneratorSpec{Type: "time_series", Params: map[string]interface{}{
+		"start": lo.UTC().Format(time.RFC3339),
+		"step":  step.String(),
+	}}
+}
+
+func nonNull(values []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func numbers(values []interface{}) []float64 {
+	out := make([]float64, 0, len(values))
+	for _, v := range values {
+		switch x := v.(type) {
+		case int64:
+			out = append(out, float64(x))
+		case int:
+			out = append(out, float64(x))
+		case float64:
+			out = append(out, x)
+		case string:
+			if f, err := strconv.ParseFloat(x, 64); err == nil {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+func timesOf(values []interface{}) []time.Time {
+	out := make([]time.Time, 0, len(values))
+	for _, v := range values {
+		if ts, ok := v.(time.Time); ok {
+			out = append(out, ts)
+		}
+	}
+	return out
+}
+
+func minMax(nums []float64) (float64, float64) {
+	lo, hi := nums[0], nums[0]
+	for _, n := range nums[1:] {
+		lo = math.Min(lo, n)
+		hi = math.Max(hi, n)
+	}
+	return lo, hi
+}
+
+func meanStd(nums []float64) (float64, float64) {
+	var sum float64
+	for _, n := range nums {
+		sum += n
+	}
+	mean := sum / float64(len(nums))
+	var sq float64
+	for _, n := range nums {
+		sq += (n - mean) * (n - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(nums)))
+}
+
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func TestFitColumn(t *testing.T) {
+	opts := ProfileOptions{MaxCardinality: 3}
+
+	status := FitColumn(Column{Name: "status", Type: domain.ColumnTypeString, Nullable: true},
+		[]interface{}{"open", "open", "open", "closed", "closed", "escalated", nil, nil}, false, 100, opts)
+	if status.Type != "choice" ||
+		!reflect.DeepEqual(status.Params["values"], []interface{}{"open", "closed"}) ||
+		!reflect.DeepEqual(status.Params["weights"], []interface{}{3, 2}) ||
+		status.Params["null_rate"] != 0.25 {
+		t.Fatalf("unexpected status fit %+v", status)
+	}
+
+	emails := FitColumn(Column{Name: "email", Type: domain.ColumnTypeString},
+		[]interface{}{"a@x", "b@x", "c@x", "d@x", "a@x"}, false, 100, opts)
+	for _, v := range emails.Params["values"].([]interface{}) {
+		if v == "a@x" {
+			t.Fatalf("expected high-cardinality values not to be copied, got %+v", emails)
+		}
+	}
+
+	qty := FitColumn(Column{Name: "qty", Type: domain.ColumnTypeInt}, []interface{}{int64(3), int64(9), int64(5)}, false, 100, opts)
+	if qty.Type != "uniform_int" || qty.Params["min"] != int64(3) || qty.Params["max"] != int64(10) {
+		t.Fatalf("unexpected int fit %+v", qty)
+	}
+
+	var uniform, bell []interface{}
+	for i := 0; i <= 100; i++ {
+		uniform = append(uniform, float64(i))
+	}
+	for i := 0; i < 100; i++ {
+		bell = append(bell, 50.0)
+	}
+	bell = append(bell, 0.0, 100.0)
+	if spec := FitColumn(Column{Name: "u", Type: domain.ColumnTypeDouble}, uniform, false, 100, opts); spec.Type != "uniform_float" || spec.Params["max"] != 100.0 {
+		t.Fatalf("expected uniform fit, got %+v", spec)
+	}
+	if spec := FitColumn(Column{Name: "n", Type: domain.ColumnTypeDouble}, bell, false, 100, opts); spec.Type != "normal" {
+		t.Fatalf("expected normal fit, got %+v", spec)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := FitColumn(Column{Name: "ts", Type: domain.ColumnTypeTimestamp}, []interface{}{start.Add(10 * time.Hour), start}, false, 10, opts)
+	if ts.Type != "time_series" || ts.Params["start"] != "2026-01-01T00:00:00Z" || ts.Params["step"] != "1h0m0s" {
+		t.Fatalf("unexpected time fit %+v", ts)
+	}
+
+	tier := FitColumn(Column{Name: "tier", Type: domain.ColumnTypeString, Enum: []string{"free", "pro", "team"}},
+		[]interface{}{"pro", "free", "pro"}, false, 10, opts)
+	if !reflect.DeepEqual(tier.Params["weights"], []interface{}{1, 2, 0}) {
+		t.Fatalf("expected enum weights in enum order, got %+v", tier)
+	}
+}
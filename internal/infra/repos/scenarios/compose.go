@@ -0,0 +1,484 @@
+package scenarios
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario files may be composed from other files:
+//
+//	imports: [common/columns.yaml]      # paths relative to the importing file
+//	column_templates:
+//	  created_at: {type: timestamp, generator: {type: time_series, params: {start: "-30d", step: 1m}}}
+//	entities:
+//	  - name: events
+//	    extends: base_event               # local or imported entity
+//	    columns:
+//	      - {name: created_at, use: created_at, generator: {params: {step: 5s}}}
+//
+// Imported files contribute column templates and entities that can be
+// extended; imported entities are not generated on their own. Local
+// definitions shadow imported ones. A column that uses a template or
+// overrides a base column is merged key by key, and generator params are
+// merged as long as the generator type is unchanged. The resolved document
+// carries no composition keys.
+
+type composer struct {
+	baseDir string
+	loading []string
+	cache   map[string]*composed
+}
+
+// composed is a resolved file: its document without composition keys plus
+// the templates and entities it exposes to importers, with their origin file.
+type composed struct {
+	doc       map[string]interface{}
+	templates map[string]definition
+	entities  map[string]definition
+}
+
+type definition struct {
+	value  map[string]interface{}
+	origin string
+}
+
+// decodeFile reads a YAML or JSON scenario file into a generic document.
+func decodeFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return doc, nil
+}
+
+// resolveFile loads path and resolves its imports, column templates and
+// entity inheritance.
+func (c *composer) resolveFile(path string) (*composed, error) {
+	if done, ok := c.cache[path]; ok {
+		return done, nil
+	}
+	for i, p := range c.loading {
+		if p == path {
+			chain := append(append([]string{}, c.loading[i:]...), path)
+			for j := range chain {
+				chain[j] = c.rel(chain[j])
+			}
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	c.loading = append(c.loading, path)
+	defer func() { c.loading = c.loading[:len(c.loading)-1] }()
+
+	doc, err := decodeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out := &composed{templates: map[string]definition{}, entities: map[string]definition{}}
+
+	imports, err := stringList(doc["imports"], "imports")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+	}
+	for _, imp := range imports {
+		impPath, err := c.importPath(path, imp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+		}
+		sub, err := c.resolveFile(impPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := mergeDefinitions(out.templates, sub.templates, "column template"); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+		}
+		if err := mergeDefinitions(out.entities, sub.entities, "entity"); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+		}
+	}
+
+	if err := c.resolveTemplates(path, doc, out); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+	}
+	if err := c.resolveEntities(path, doc, out); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.rel(path), err)
+	}
+	delete(doc, "imports")
+	delete(doc, "column_templates")
+	out.doc = doc
+	c.cache[path] = out
+	return out, nil
+}
+
+func (c *composer) rel(path string) string {
+	if rel, err := filepath.Rel(c.baseDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// importPath resolves an import relative to the importing file and keeps it
+// inside the scenarios dir.
+func (c *composer) importPath(from, imp string) (string, error) {
+	if imp == "" || filepath.IsAbs(imp) {
+		return "", fmt.Errorf("import %q must be a relative path", imp)
+	}
+	candidate := filepath.Clean(filepath.Join(filepath.Dir(from), imp))
+	rel, err := filepath.Rel(c.baseDir, candidate)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("import %q must be inside scenarios dir", imp)
+	}
+	if _, err := os.Stat(candidate); err != nil {
+		return "", fmt.Errorf("import %q: %w", imp, err)
+	}
+	return candidate, nil
+}
+
+func mergeDefinitions(dst, src map[string]definition, kind string) error {
+	for name, def := range src {
+		if have, ok := dst[name]; ok && have.origin != def.origin {
+			return fmt.Errorf("%s %q is imported from both %s and %s", kind, name, have.origin, def.origin)
+		}
+		dst[name] = def
+	}
+	return nil
+}
+
+// resolveTemplates resolves the file's column_templates, which may use other
+// templates, and adds them over the imported ones.
+func (c *composer) resolveTemplates(path string, doc map[string]interface{}, out *composed) error {
+	raw, ok := doc["column_templates"]
+	if !ok || raw == nil {
+		return nil
+	}
+	local, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("column_templates must be a mapping")
+	}
+	imported := make(map[string]definition, len(out.templates))
+	for name, def := range out.templates {
+		imported[name] = def
+	}
+	resolved := map[string]map[string]interface{}{}
+	var resolve func(name string, chain []string) (map[string]interface{}, error)
+	resolve = func(name string, chain []string) (map[string]interface{}, error) {
+		if t, ok := resolved[name]; ok {
+			return t, nil
+		}
+		for _, n := range chain {
+			if n == name {
+				return nil, fmt.Errorf("column template cycle: %s", strings.Join(append(chain, name), " -> "))
+			}
+		}
+		rawT, isLocal := local[name]
+		if !isLocal {
+			if def, ok := imported[name]; ok {
+				return def.value, nil
+			}
+			return nil, fmt.Errorf("unknown column template %q", name)
+		}
+		t, ok := rawT.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("column template %q must be a mapping", name)
+		}
+		if use, ok := t["use"]; ok {
+			useName, ok := use.(string)
+			if !ok {
+				return nil, fmt.Errorf("column template %q: use must be a string", name)
+			}
+			var base map[string]interface{}
+			if def, ok := imported[useName]; ok && useName == name {
+				// a local template may refine the imported one it shadows
+				base = def.value
+			} else {
+				r, err := resolve(useName, append(chain, name))
+				if err != nil {
+					return nil, err
+				}
+				base = r
+			}
+			t = mergeColumn(base, t)
+		}
+		delete(t, "use")
+		resolved[name] = t
+		return t, nil
+	}
+	for name := range local {
+		t, err := resolve(name, nil)
+		if err != nil {
+			return err
+		}
+		out.templates[name] = definition{value: t, origin: c.rel(path)}
+	}
+	return nil
+}
+
+// resolveEntities applies templates to columns and inheritance to entities,
+// in the file's entity order.
+func (c *composer) resolveEntities(path string, doc map[string]interface{}, out *composed) error {
+	raw, ok := doc["entities"]
+	if !ok || raw == nil {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("entities must be a list")
+	}
+	local := map[string]map[string]interface{}{}
+	for i, item := range list {
+		e, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("entity %d must be a mapping", i)
+		}
+		name, _ := e["name"].(string)
+		if name != "" {
+			local[name] = e
+		}
+	}
+
+	resolved := map[string]map[string]interface{}{}
+	var resolve func(name string, chain []string) (map[string]interface{}, error)
+	resolve = func(name string, chain []string) (map[string]interface{}, error) {
+		if r, ok := resolved[name]; ok {
+			return r, nil
+		}
+		e := copyMap(local[name])
+		if err := applyTemplates(e, out.templates); err != nil {
+			return nil, fmt.Errorf("entity %q: %w", name, err)
+		}
+		if ext, ok := e["extends"]; ok {
+			baseName, ok := ext.(string)
+			if !ok {
+				return nil, fmt.Errorf("entity %q: extends must be a string", name)
+			}
+			var base map[string]interface{}
+			if _, isLocal := local[baseName]; isLocal && baseName != name {
+				for _, n := range chain {
+					if n == baseName {
+						return nil, fmt.Errorf("entity inheritance cycle: %s", strings.Join(append(chain, baseName), " -> "))
+					}
+				}
+				r, err := resolve(baseName, append(chain, baseName))
+				if err != nil {
+					return nil, err
+				}
+				base = r
+			} else if def, ok := out.entities[baseName]; ok {
+				// an entity may extend the imported entity of the same name
+				base = def.value
+			} else {
+				return nil, fmt.Errorf("entity %q extends unknown entity %q", name, baseName)
+			}
+			e = extendEntity(base, e)
+		}
+		delete(e, "extends")
+		resolved[name] = e
+		return e, nil
+	}
+
+	for i, item := range list {
+		e := item.(map[string]interface{})
+		name, _ := e["name"].(string)
+		if name == "" {
+			// left for validation to reject
+			continue
+		}
+		r, err := resolve(name, []string{name})
+		if err != nil {
+			return err
+		}
+		list[i] = r
+	}
+	for name, r := range resolved {
+		out.entities[name] = definition{value: r, origin: c.rel(path)}
+	}
+	doc["entities"] = list
+	return nil
+}
+
+// applyTemplates replaces columns that use a template with the merged column.
+func applyTemplates(e map[string]interface{}, templates map[string]definition) error {
+	cols, ok := e["columns"].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]interface{}, len(cols))
+	for i, item := range cols {
+		col, ok := item.(map[string]interface{})
+		if !ok {
+			out[i] = item
+			continue
+		}
+		use, ok := col["use"]
+		if !ok {
+			out[i] = col
+			continue
+		}
+		name, ok := use.(string)
+		if !ok {
+			return fmt.Errorf("column %v: use must be a string", col["name"])
+		}
+		t, ok := templates[name]
+		if !ok {
+			return fmt.Errorf("column %v uses unknown column template %q", col["name"], name)
+		}
+		merged := mergeColumn(t.value, col)
+		delete(merged, "use")
+		out[i] = merged
+	}
+	e["columns"] = out
+	return nil
+}
+
+// extendEntity merges e over base: scalar keys replace, options merge, and
+// columns replace the base column of the same name or are appended. The
+// target table defaults to the entity's own name rather than the base's.
+func extendEntity(base, e map[string]interface{}) map[string]interface{} {
+	out := copyMap(base)
+	for k, v := range e {
+		switch k {
+		case "columns":
+			out[k] = mergeColumns(base["columns"], v)
+		case "options":
+			out[k] = mergeMaps(base["options"], v)
+		default:
+			out[k] = v
+		}
+	}
+	if _, ok := e["target_table"]; !ok {
+		if name, ok := e["name"]; ok {
+			out["target_table"] = name
+		}
+	}
+	return out
+}
+
+func mergeColumns(base, override interface{}) []interface{} {
+	baseCols, _ := base.([]interface{})
+	overCols, _ := override.([]interface{})
+	out := make([]interface{}, 0, len(baseCols)+len(overCols))
+	index := map[interface{}]int{}
+	for _, item := range baseCols {
+		if col, ok := item.(map[string]interface{}); ok {
+			index[col["name"]] = len(out)
+		}
+		out = append(out, item)
+	}
+	for _, item := range overCols {
+		col, ok := item.(map[string]interface{})
+		if !ok {
+			out = append(out, item)
+			continue
+		}
+		if i, ok := index[col["name"]]; ok {
+			if baseCol, ok := out[i].(map[string]interface{}); ok {
+				out[i] = mergeColumn(baseCol, col)
+				continue
+			}
+		}
+		out = append(out, col)
+	}
+	return out
+}
+
+// mergeColumn merges a column over a template or base column. A generator of
+// a different type replaces the base generator; otherwise params are merged.
+func mergeColumn(base, override map[string]interface{}) map[string]interface{} {
+	out := copyMap(base)
+	for k, v := range override {
+		if k != "generator" {
+			out[k] = v
+			continue
+		}
+		baseGen, _ := base["generator"].(map[string]interface{})
+		gen, ok := v.(map[string]interface{})
+		if !ok || baseGen == nil {
+			out[k] = v
+			continue
+		}
+		if typ, ok := gen["type"]; ok && typ != baseGen["type"] {
+			out[k] = v
+			continue
+		}
+		merged := copyMap(baseGen)
+		for gk, gv := range gen {
+			if gk == "params" {
+				merged[gk] = mergeMaps(baseGen["params"], gv)
+			} else {
+				merged[gk] = gv
+			}
+		}
+		out[k] = merged
+	}
+	return out
+}
+
+func mergeMaps(base, override interface{}) interface{} {
+	b, ok1 := base.(map[string]interface{})
+	o, ok2 := override.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return override
+	}
+	out := copyMap(b)
+	for k, v := range o {
+		out[k] = v
+	}
+	return out
+}
+
+// copyMap copies maps and lists deeply so resolved definitions shared by
+// several entities are never aliased.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+func copyValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return copyMap(x)
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, item := range x {
+			out[i] = copyValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func stringList(v interface{}, key string) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of paths", key)
+	}
+	out := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list of paths", key)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
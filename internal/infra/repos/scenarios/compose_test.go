@@ -0,0 +1,225 @@
+package scenarios
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	base := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base
+}
+
+const commonColumns = `
+column_templates:
+  id:
+    type: uuid
+    generator: {type: uuid4}
+  created_at:
+    type: timestamp
+    generator:
+      type: time_series
+      params: {start: "-30d", step: 1m, jitter_seconds: 5}
+  updated_at:
+    use: created_at
+    nullable: true
+entities:
+  - name: base_event
+    target_table: base_event
+    rows: 100
+    options: {order_by: created_at}
+    columns:
+      - {name: event_id, use: id}
+      - {name: created_at, use: created_at}
+      - name: source
+        type: string
+        generator: {type: choice, params: {values: [api, web]}}
+`
+
+func TestFileRepository_ResolvesComposition(t *testing.T) {
+	base := writeFiles(t, map[string]string{
+		"common/columns.yaml": commonColumns,
+		"app.yaml": `
+id: app
+name: app
+imports: [common/columns.yaml]
+column_templates:
+  created_at:
+    use: created_at
+    generator: {params: {step: 10s}}
+entities:
+  - name: clicks
+    extends: base_event
+    rows: 500
+    options: {partition_by: source}
+    columns:
+      - name: source
+        generator: {type: const, params: {value: mobile}}
+      - {name: updated_at, use: updated_at}
+  - name: views
+    extends: clicks
+    target_table: page_views
+  - name: users
+    target_table: users
+    rows: 10
+    columns:
+      - {name: user_id, use: id}
+      - {name: created_at, use: created_at, nullable: true}
+`,
+	})
+	repo := NewFileRepository(base)
+	list, err := repo.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected only app.yaml to be listed, got %d scenarios", len(list))
+	}
+	sc, err := repo.Get("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Entities) != 3 {
+		t.Fatalf("expected 3 entities (imported entities are not generated), got %d", len(sc.Entities))
+	}
+
+	clicks := sc.Entities[0]
+	if clicks.TargetTable != "clicks" || clicks.Rows != 500 {
+		t.Fatalf("unexpected clicks entity: %+v", clicks)
+	}
+	if want := map[string]string{"order_by": "created_at", "partition_by": "source"}; !reflect.DeepEqual(clicks.Options, want) {
+		t.Fatalf("options not merged: %v", clicks.Options)
+	}
+	var names []string
+	cols := map[string]domain.Column{}
+	for _, c := range clicks.Columns {
+		names = append(names, c.Name)
+		cols[c.Name] = c
+	}
+	if want := []string{"event_id", "created_at", "source", "updated_at"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("clicks columns %v, want %v", names, want)
+	}
+	if cols["event_id"].Type != domain.ColumnTypeUUID || cols["event_id"].Generator.Type != "uuid4" {
+		t.Fatalf("template not applied: %+v", cols["event_id"])
+	}
+	// base_event resolved created_at against the imported template
+	if got := cols["created_at"].Generator.Params["step"]; got != "1m" {
+		t.Fatalf("expected inherited created_at step 1m, got %v", got)
+	}
+	if g := cols["source"].Generator; g.Type != "const" || g.Params["value"] != "mobile" || g.Params["values"] != nil {
+		t.Fatalf("generator of a different type must replace the base generator: %+v", g)
+	}
+	if u := cols["updated_at"]; !u.Nullable || u.Type != domain.ColumnTypeTimestamp || u.Generator.Params["jitter_seconds"] != 5 {
+		t.Fatalf("template chain not applied: %+v", u)
+	}
+
+	views := sc.Entities[1]
+	if views.TargetTable != "page_views" || views.Rows != 500 || len(views.Columns) != 4 {
+		t.Fatalf("unexpected views entity: %+v", views)
+	}
+
+	users := sc.Entities[2]
+	created := users.Columns[1]
+	if !created.Nullable || created.Generator.Params["step"] != "10s" || created.Generator.Params["jitter_seconds"] != 5 {
+		t.Fatalf("local template override not applied: %+v", created)
+	}
+
+	src, err := repo.GetSource("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "extends: base_event") {
+		t.Fatalf("expected the unresolved source, got:\n%s", src)
+	}
+}
+
+func TestFileRepository_CompositionErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "import cycle",
+			files: map[string]string{
+				"a.yaml":     "id: a\nimports: [lib/b.yaml]\n",
+				"lib/b.yaml": "imports: [../a.yaml]\n",
+			},
+			want: "import cycle: a.yaml -> lib/b.yaml -> a.yaml",
+		},
+		{
+			name:  "extends cycle",
+			files: map[string]string{"a.yaml": "id: a\nentities:\n  - {name: x, extends: y}\n  - {name: y, extends: x}\n"},
+			want:  "entity inheritance cycle: x -> y -> x",
+		},
+		{
+			name:  "template cycle",
+			files: map[string]string{"a.yaml": "id: a\ncolumn_templates:\n  p: {use: q}\n  q: {use: p}\n"},
+			want:  "column template cycle",
+		},
+		{
+			name:  "unknown template",
+			files: map[string]string{"a.yaml": "id: a\nentities:\n  - name: x\n    columns:\n      - {name: c, use: nope}\n"},
+			want:  `uses unknown column template "nope"`,
+		},
+		{
+			name:  "unknown base",
+			files: map[string]string{"a.yaml": "id: a\nentities:\n  - {name: x, extends: nope}\n"},
+			want:  `extends unknown entity "nope"`,
+		},
+		{
+			name:  "import outside dir",
+			files: map[string]string{"a.yaml": "id: a\nimports: [../outside.yaml]\n"},
+			want:  "must be inside scenarios dir",
+		},
+		{
+			name: "conflicting imports",
+			files: map[string]string{
+				"a.yaml":     "id: a\nimports: [lib/b.yaml, lib/c.yaml]\n",
+				"lib/b.yaml": "column_templates:\n  id: {type: uuid}\n",
+				"lib/c.yaml": "column_templates:\n  id: {type: int}\n",
+			},
+			want: `column template "id" is imported from both`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := writeFiles(t, tc.files)
+			_, err := NewFileRepository(base).GetByPath("a.yaml")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestFileRepository_DiamondImports(t *testing.T) {
+	base := writeFiles(t, map[string]string{
+		"lib/common.yaml": "column_templates:\n  id: {type: uuid, generator: {type: uuid4}}\n",
+		"lib/b.yaml":      "imports: [common.yaml]\n",
+		"lib/c.yaml":      "imports: [common.yaml]\n",
+		"a.yaml":          "id: a\nimports: [lib/b.yaml, lib/c.yaml]\nentities:\n  - name: x\n    target_table: x\n    rows: 1\n    columns:\n      - {name: id, use: id}\n",
+	})
+	sc, err := NewFileRepository(base).GetByPath("a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Entities[0].Columns[0].Type != domain.ColumnTypeUUID {
+		t.Fatalf("unexpected column: %+v", sc.Entities[0].Columns[0])
+	}
+}
@@ -0,0 +1,181 @@
+package scenarios
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/generators"
+)
+
+// LoadDictionaries reads the files of the scenario's dictionary generators,
+// nested ones included, into their 'values' and 'weights' params and records
+// a sha256 'digest' of each file. Scenarios are listed and fetched without
+// their dictionaries; call this before validating or running one. Files are
+// read before parameters are applied, so 'file' cannot reference ${name}.
+func (r *FileRepository) LoadDictionaries(scenario *domain.Scenario) error {
+	var load func(spec domain.GeneratorSpec) error
+	load = func(spec domain.GeneratorSpec) error {
+		if spec.Type == "dictionary" {
+			return r.loadDictionary(spec.Params)
+		}
+		nested, err := generators.NestedSpecs(spec)
+		if err != nil {
+			return nil // reported by validation
+		}
+		for _, n := range nested {
+			if err := load(n.Spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, entity := range scenario.Entities {
+		for _, col := range entity.Columns {
+			if err := load(col.Generator); err != nil {
+				return fmt.Errorf("entity '%s', column '%s': %w", entity.Name, col.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *FileRepository) loadDictionary(params map[string]interface{}) error {
+	file, ok := params["file"].(string)
+	if !ok || file == "" {
+		return nil // reported by validation
+	}
+	if strings.Contains(file, "${") {
+		return fmt.Errorf("dictionary file '%s': parameter references are not supported in 'file'", file)
+	}
+	path, err := r.resolveScenarioPath(file)
+	if err != nil {
+		return fmt.Errorf("dictionary file '%s': %w", file, err)
+	}
+	column, _ := params["column"].(string)
+	weightColumn, _ := params["weight_column"].(string)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("dictionary file '%s': %w", file, err)
+	}
+	var values, weights []interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv", ".tsv":
+		values, weights, err = readDelimited(data, ext == ".tsv", column, weightColumn)
+	case ".json":
+		values, weights, err = readJSONDictionary(data, column, weightColumn)
+	default:
+		err = errors.New("must be a .csv, .tsv or .json file")
+	}
+	if err != nil {
+		return fmt.Errorf("dictionary file '%s': %w", file, err)
+	}
+	sum := sha256.Sum256(data)
+	params["digest"] = hex.EncodeToString(sum[:])
+	params["values"] = values
+	if weightColumn != "" {
+		params["weights"] = weights
+	}
+	return nil
+}
+
+// readDelimited reads a CSV or TSV file with a header row. The value column
+// defaults to the first one.
+func readDelimited(data []byte, tsv bool, column, weightColumn string) ([]interface{}, []interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	if tsv {
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) < 2 {
+		return nil, nil, errors.New("needs a header row and at least one entry")
+	}
+	header := records[0]
+	valueIdx, weightIdx := 0, -1
+	if column != "" {
+		if valueIdx = indexOf(header, column); valueIdx < 0 {
+			return nil, nil, fmt.Errorf("no column '%s'", column)
+		}
+	}
+	if weightColumn != "" {
+		if weightIdx = indexOf(header, weightColumn); weightIdx < 0 {
+			return nil, nil, fmt.Errorf("no column '%s'", weightColumn)
+		}
+	}
+	values := make([]interface{}, 0, len(records)-1)
+	weights := make([]interface{}, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		values = append(values, rec[valueIdx])
+		if weightIdx >= 0 {
+			w, err := strconv.ParseFloat(strings.TrimSpace(rec[weightIdx]), 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: weight %q is not a number", i+2, rec[weightIdx])
+			}
+			weights = append(weights, w)
+		}
+	}
+	return values, weights, nil
+}
+
+// readJSONDictionary reads a JSON array of scalars, or of objects whose value
+// is under column (default "value").
+func readJSONDictionary(data []byte, column, weightColumn string) ([]interface{}, []interface{}, error) {
+	var entries []interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, fmt.Errorf("must be a JSON array: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil, errors.New("needs at least one entry")
+	}
+	if column == "" {
+		column = "value"
+	}
+	values := make([]interface{}, len(entries))
+	var weights []interface{}
+	for i, e := range entries {
+		obj, isObject := e.(map[string]interface{})
+		if !isObject {
+			if weightColumn != "" {
+				return nil, nil, fmt.Errorf("entry %d: 'weight_column' needs object entries", i+1)
+			}
+			values[i] = e
+			continue
+		}
+		v, ok := obj[column]
+		if !ok {
+			return nil, nil, fmt.Errorf("entry %d: no field '%s'", i+1, column)
+		}
+		values[i] = v
+		if weightColumn != "" {
+			w, ok := obj[weightColumn].(float64)
+			if !ok {
+				return nil, nil, fmt.Errorf("entry %d: '%s' must be a number", i+1, weightColumn)
+			}
+			weights = append(weights, w)
+		}
+	}
+	return values, weights, nil
+}
+
+func indexOf(header []string, name string) int {
+	for i, h := range header {
+		if strings.TrimSpace(h) == name {
+			return i
+		}
+	}
+	return -1
+}
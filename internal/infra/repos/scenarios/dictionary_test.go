@@ -0,0 +1,123 @@
+package scenarios
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/hashing"
+)
+
+const dictionaryScenario = `
+id: people
+name: people
+entities:
+  - name: people
+    target_table: people
+    rows: 10
+    columns:
+      - name: surname
+        type: string
+        generator: {type: dictionary, params: {file: data/surnames.csv, column: surname, weight_column: count}}
+      - name: city
+        type: string
+        generator:
+          type: switch
+          params:
+            column: surname
+            cases:
+              Smith: {type: dictionary, params: {file: data/cities.json}}
+            default: {type: dictionary, params: {file: data/ids.tsv}}
+`
+
+func TestLoadScenario_Dictionaries(t *testing.T) {
+	files := map[string]string{
+		"people.yaml":       dictionaryScenario,
+		"data/surnames.csv": "rank,surname,count\n1,Smith,2442977\n2,Johnson,1932812\n",
+		"data/cities.json":  `[{"value": "Oslo", "weight": 2}, {"value": "Lima", "weight": 1}]`,
+		"data/ids.tsv":      "id\tname\n7\tseven\n",
+	}
+	repo := NewFileRepository(writeFiles(t, files))
+	s, err := repo.GetByPath("people.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := s.Entities[0].Columns[0].Generator.Params
+	if params["values"] != nil {
+		t.Fatalf("expected dictionaries to be read on demand, got %v", params)
+	}
+	if err := repo.LoadDictionaries(s); err != nil {
+		t.Fatal(err)
+	}
+	if digest, _ := params["digest"].(string); len(digest) != 64 {
+		t.Fatalf("expected a sha256 digest, got %v", params["digest"])
+	}
+	if !reflect.DeepEqual(params["values"], []interface{}{"Smith", "Johnson"}) ||
+		!reflect.DeepEqual(params["weights"], []interface{}{2442977.0, 1932812.0}) {
+		t.Fatalf("unexpected csv dictionary params: %v", params)
+	}
+	cases := s.Entities[0].Columns[1].Generator.Params["cases"].(map[string]interface{})
+	nested := cases["Smith"].(map[string]interface{})["params"].(map[string]interface{})
+	if !reflect.DeepEqual(nested["values"], []interface{}{"Oslo", "Lima"}) || nested["weights"] != nil {
+		t.Fatalf("unexpected json dictionary params: %v", nested)
+	}
+	def := s.Entities[0].Columns[1].Generator.Params["default"].(map[string]interface{})["params"].(map[string]interface{})
+	if !reflect.DeepEqual(def["values"], []interface{}{"7"}) {
+		t.Fatalf("unexpected tsv dictionary params: %v", def)
+	}
+
+	// editing a dictionary changes the scenario hash
+	before, _ := hashing.HashScenario(s)
+	files["data/surnames.csv"] = "rank,surname,count\n1,Smith,2442977\n2,Jones,1932812\n"
+	repo = NewFileRepository(writeFiles(t, files))
+	s2, err := repo.GetByPath("people.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.LoadDictionaries(s2); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := hashing.HashScenario(s2); after == before {
+		t.Fatal("expected dictionary edit to change the scenario hash")
+	}
+}
+
+func TestLoadScenario_DictionaryErrors(t *testing.T) {
+	cases := map[string]map[string]string{
+		"path must be inside scenarios dir": {
+			"data/surnames.csv": "surname\nSmith\n",
+			"people.yaml":       strings.Replace(dictionaryScenario, "data/surnames.csv", "../surnames.csv", 1),
+		},
+		"no column 'count'": {
+			"data/surnames.csv": "surname\nSmith\n",
+		},
+		"line 3: weight \"many\" is not a number": {
+			"data/surnames.csv": "surname,count\nSmith,1\nJones,many\n",
+		},
+		"parameter references are not supported in 'file'": {
+			"data/surnames.csv": "surname,count\nSmith,1\n",
+			"people.yaml":       strings.Replace(dictionaryScenario, "data/ids.tsv", `"data/${region}.tsv"`, 1),
+		},
+		"must be a .csv, .tsv or .json file": {
+			"data/surnames.csv": "surname,count\nSmith,1\n",
+			"people.yaml":       strings.Replace(dictionaryScenario, "data/ids.tsv", "data/ids.txt", 1),
+		},
+	}
+	for want, files := range cases {
+		if _, ok := files["people.yaml"]; !ok {
+			files["people.yaml"] = dictionaryScenario
+		}
+		files["data/cities.json"] = `["Oslo"]`
+		files["data/ids.tsv"] = "id\n7\n"
+		files["data/ids.txt"] = "7\n"
+		repo := NewFileRepository(writeFiles(t, files))
+		s, err := repo.GetByPath("people.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = repo.LoadDictionaries(s)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
@@ -107,8 +107,19 @@ func (r *FileRepository) resolveScenarioPath(path string) (string, error) {
 	return candidateAbs, nil
 }
 
+// loadScenario reads a scenario file and resolves its imports, column
+// templates and entity inheritance before decoding it.
 func (r *FileRepository) loadScenario(path string) (*domain.Scenario, error) {
-	data, err := os.ReadFile(path)
+	baseAbs, err := filepath.Abs(r.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	pathAbs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &composer{baseDir: baseAbs, cache: map[string]*composed{}}
+	resolved, err := c.resolveFile(pathAbs)
 	if err != nil {
 		return nil, err
 	}
@@ -117,9 +128,15 @@ func (r *FileRepository) loadScenario(path string) (*domain.Scenario, error) {
 	ext := filepath.Ext(path)
 
 	if ext == ".json" {
-		err = json.Unmarshal(data, &scenario)
+		var data []byte
+		if data, err = json.Marshal(resolved.doc); err == nil {
+			err = json.Unmarshal(data, &scenario)
+		}
 	} else {
-		err = yaml.Unmarshal(data, &scenario)
+		var data []byte
+		if data, err = yaml.Marshal(resolved.doc); err == nil {
+			err = yaml.Unmarshal(data, &scenario)
+		}
 	}
 
 	if err != nil {
@@ -132,3 +149,27 @@ func (r *FileRepository) loadScenario(path string) (*domain.Scenario, error) {
 
 	return &scenario, nil
 }
+
+// GetSource returns the file contents of a scenario as written, before
+// imports, templates and inheritance are resolved.
+func (r *FileRepository) GetSource(id string) ([]byte, error) {
+	entries, err := os.ReadDir(r.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(r.baseDir, entry.Name())
+		s, err := r.loadScenario(path)
+		if err != nil {
+			continue
+		}
+		if s.ID == id || s.Name == id {
+			return os.ReadFile(path)
+		}
+	}
+	return nil, fmt.Errorf("scenario not found: %s", id)
+}
@@ -53,9 +53,52 @@ func RedactTarget(t *domain.TargetConfig) *domain.TargetConfig {
 	}
 	cp := *t
 	cp.DSN = RedactDSN(cp.DSN)
+	cp.Options = RedactOptions(cp.Options)
 	return &cp
 }
 
+// RedactOptions returns a copy of target options with credential values
+// masked.
+func RedactOptions(options map[string]string) map[string]string {
+	if len(options) == 0 {
+		return options
+	}
+	out := make(map[string]string, len(options))
+	for k, v := range options {
+		if v != "" && isSecretOption(k) {
+			v = "****"
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// RestoreRedactedOptions puts back stored credential values where an update
+// echoes the mask returned by RedactOptions, so editing a listed target does
+// not overwrite its secrets.
+func RestoreRedactedOptions(options, stored map[string]string) {
+	for k, v := range options {
+		if v == "****" && isSecretOption(k) {
+			if old, ok := stored[k]; ok {
+				options[k] = old
+			}
+		}
+	}
+}
+
+// secretOptions are target option keys holding credentials; every header.*
+// option is treated as one too.
+var secretOptions = map[string]bool{
+	"password":     true,
+	"api_key":      true,
+	"bearer_token": true,
+}
+
+func isSecretOption(key string) bool {
+	k := strings.ToLower(key)
+	return secretOptions[k] || strings.HasPrefix(k, "header.")
+}
+
 func RedactTargets(list []*domain.TargetConfig) []*domain.TargetConfig {
 	out := make([]*domain.TargetConfig, 0, len(list))
 	for _, t := range list {
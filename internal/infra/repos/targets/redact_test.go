@@ -1,6 +1,10 @@
 package targets
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
 
 func TestRedactDSN(t *testing.T) {
 	if got := RedactDSN(""); got != "" {
@@ -13,3 +17,43 @@ func TestRedactDSN(t *testing.T) {
 		t.Fatalf("expected URL DSN redaction, got %q", got)
 	}
 }
+
+func TestRedactTarget_Options(t *testing.T) {
+	in := &domain.TargetConfig{
+		Kind: "http",
+		DSN:  "https://api.example.com",
+		Options: map[string]string{
+			"bearer_token":         "tok",
+			"header.Authorization": "Basic abc",
+			"header.X-Api-Key":     "k",
+			"password":             "pw",
+			"api_key":              "id:key",
+			"username":             "elastic",
+			"path":                 "/{table}",
+		},
+	}
+	out := RedactTarget(in)
+	for _, k := range []string{"bearer_token", "header.Authorization", "header.X-Api-Key", "password", "api_key"} {
+		if out.Options[k] != "****" {
+			t.Fatalf("expected %s redacted, got %v", k, out.Options)
+		}
+	}
+	if out.Options["path"] != "/{table}" || out.Options["username"] != "elastic" {
+		t.Fatalf("expected non-secret options kept, got %v", out.Options)
+	}
+	if in.Options["bearer_token"] != "tok" {
+		t.Fatal("redaction must not modify the input target")
+	}
+}
+
+func TestRestoreRedactedOptions(t *testing.T) {
+	stored := map[string]string{"bearer_token": "tok", "path": "/a"}
+	update := map[string]string{"bearer_token": "****", "path": "****"}
+	RestoreRedactedOptions(update, stored)
+	if update["bearer_token"] != "tok" {
+		t.Fatalf("expected masked secret restored, got %v", update)
+	}
+	if update["path"] != "****" {
+		t.Fatalf("expected non-secret option left as sent, got %v", update)
+	}
+}
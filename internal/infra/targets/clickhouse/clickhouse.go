@@ -0,0 +1,634 @@
+package clickhouse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+const (
+	FormatJSONEachRow = "JSONEachRow"
+	FormatRowBinary   = "RowBinary"
+)
+
+// ClickHouseTarget writes rows through the ClickHouse HTTP interface.
+//
+// Supported options (target-level, overridable per entity via entity options):
+//   - order_by: MergeTree ORDER BY key (comma-separated columns; default tuple())
+//   - partition_by: MergeTree PARTITION BY key (column or func(column))
+//   - format: insert format, JSONEachRow (default) or RowBinary
+type ClickHouseTarget struct {
+	baseURL  string
+	user     string
+	password string
+	database string
+	options  map[string]string
+	client   *http.Client
+
+	// column types per table, learned on create or fetched lazily from system.columns
+	types map[string]map[string]string
+	// insert format per table, resolved from entity options in BindEntity
+	formats map[string]string
+}
+
+func NewClickHouseTarget(dsn, database string, options map[string]string) *ClickHouseTarget {
+	base, user, password := parseDSN(dsn)
+	if database == "" {
+		database = "default"
+	}
+	return &ClickHouseTarget{
+		baseURL:  base,
+		user:     user,
+		password: password,
+		database: database,
+		options:  options,
+		types:    map[string]map[string]string{},
+		formats:  map[string]string{},
+	}
+}
+
+func (t *ClickHouseTarget) Connect() error {
+	t.client = &http.Client{Timeout: 60 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, t.baseURL+"/ping", nil)
+	if err != nil {
+		return err
+	}
+	t.authorize(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("clickhouse ping failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (t *ClickHouseTarget) Close() error { return nil }
+
+func (t *ClickHouseTarget) CreateTableIfNotExists(entity *domain.Entity) error {
+	existing, err := t.fetchColumnTypes(entity.TargetTable)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return t.validateExistingTable(entity, existing)
+	}
+
+	orderBy := t.option(entity, "order_by")
+	partitionBy := t.option(entity, "partition_by")
+	if err := validateKeyExpr(orderBy, entity); err != nil {
+		return fmt.Errorf("invalid order_by: %w", err)
+	}
+	if err := validateKeyExpr(partitionBy, entity); err != nil {
+		return fmt.Errorf("invalid partition_by: %w", err)
+	}
+	orderBy, partitionBy = quoteKeyExpr(orderBy), quoteKeyExpr(partitionBy)
+	if orderBy == "" {
+		orderBy = "tuple()"
+	}
+
+	types := make(map[string]string, len(entity.Columns))
+	columnDefs := make([]string, len(entity.Columns))
+	for i, col := range entity.Columns {
+		colType := mapColumnType(col.Type)
+		if col.Nullable {
+			colType = "Nullable(" + colType + ")"
+		}
+		types[col.Name] = colType
+		columnDefs[i] = fmt.Sprintf("%s %s", quoteIdent(col.Name), colType)
+	}
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) ENGINE = MergeTree", t.qualified(entity.TargetTable), strings.Join(columnDefs, ", "))
+	if partitionBy != "" {
+		ddl += " PARTITION BY " + partitionBy
+	}
+	ddl += " ORDER BY " + orderBy
+
+	if _, err := t.exec(ddl, nil, nil); err != nil {
+		return fmt.Errorf("clickhouse create table failed: %w", err)
+	}
+	t.types[entity.TargetTable] = types
+	return nil
+}
+
+func (t *ClickHouseTarget) validateExistingTable(entity *domain.Entity, existing map[string]string) error {
+	for _, col := range entity.Columns {
+		got, ok := existing[col.Name]
+		if !ok {
+			return fmt.Errorf("existing table %s missing column %s", t.qualified(entity.TargetTable), col.Name)
+		}
+		expected := mapColumnType(col.Type)
+		if !clickhouseTypeCompatible(expected, got) {
+			return fmt.Errorf("existing table %s column %s type mismatch: expected %s, got %s", t.qualified(entity.TargetTable), col.Name, expected, got)
+		}
+	}
+	t.types[entity.TargetTable] = existing
+	return nil
+}
+
+// BindEntity resolves the insert format for an entity's table.
+func (t *ClickHouseTarget) BindEntity(entity *domain.Entity) error {
+	format := t.option(entity, "format")
+	switch format {
+	case "":
+		format = FormatJSONEachRow
+	case FormatJSONEachRow, FormatRowBinary:
+	default:
+		return fmt.Errorf("unsupported clickhouse insert format: %s", format)
+	}
+	t.formats[entity.TargetTable] = format
+	return nil
+}
+
+func (t *ClickHouseTarget) TruncateTable(tableName string) error {
+	if _, err := t.exec("TRUNCATE TABLE "+t.qualified(tableName), nil, nil); err != nil {
+		return fmt.Errorf("clickhouse truncate failed: %w", err)
+	}
+	return nil
+}
+
+func (t *ClickHouseTarget) InsertBatch(tableName string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	types, ok := t.types[tableName]
+	if !ok {
+		var err error
+		types, err = t.fetchColumnTypes(tableName)
+		if err != nil {
+			return err
+		}
+		if len(types) == 0 {
+			return fmt.Errorf("clickhouse table %s does not exist", t.qualified(tableName))
+		}
+		t.types[tableName] = types
+	}
+
+	format, ok := t.formats[tableName]
+	if !ok {
+		format = strings.TrimSpace(t.options["format"])
+	}
+	if format == "" {
+		format = FormatJSONEachRow
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+	switch format {
+	case FormatJSONEachRow:
+		body, err = encodeJSONEachRow(columns, rows, types)
+	case FormatRowBinary:
+		body, err = encodeRowBinary(columns, rows, types)
+	default:
+		return fmt.Errorf("unsupported clickhouse insert format: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) FORMAT %s", t.qualified(tableName), strings.Join(quoted, ", "), format)
+	if _, err := t.exec(query, body, nil); err != nil {
+		return fmt.Errorf("clickhouse insert failed: %w", err)
+	}
+	return nil
+}
+
+// MaxValue returns the largest value of a column, or nil for an empty table.
+// 64-bit integers arrive as JSON strings.
+func (t *ClickHouseTarget) MaxValue(tableName, column string, byLength bool) (interface{}, error) {
+	col := quoteIdent(column)
+	q := fmt.Sprintf("SELECT maxOrNull(%s) AS v FROM %s FORMAT JSONEachRow", col, t.qualified(tableName))
+	if byLength {
+		q = fmt.Sprintf("SELECT %s AS v FROM %s ORDER BY length(%s) DESC, %s DESC LIMIT 1 FORMAT JSONEachRow",
+			col, t.qualified(tableName), col, col)
+	}
+	out, err := t.exec(q, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse max query failed: %w", err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var row struct {
+		V interface{} `json:"v"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &row); err != nil {
+		return nil, err
+	}
+	return row.V, nil
+}
+
+// fetchColumnTypes returns name->type for an existing table, or an empty map if it does not exist.
+func (t *ClickHouseTarget) fetchColumnTypes(tableName string) (map[string]string, error) {
+	q := "SELECT name, type FROM system.columns WHERE database = {db:String} AND table = {tbl:String} FORMAT JSONEachRow"
+	out, err := t.exec(q, nil, url.Values{"param_db": {t.database}, "param_tbl": {tableName}})
+	if err != nil {
+		return nil, err
+	}
+	types := map[string]string{}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var row struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := dec.Decode(&row); err != nil {
+			return nil, err
+		}
+		types[row.Name] = row.Type
+	}
+	return types, nil
+}
+
+// exec runs a statement. If data is nil the statement is sent as the request body,
+// otherwise it is passed in the query string and data is sent as the body.
+func (t *ClickHouseTarget) exec(query string, data []byte, params url.Values) ([]byte, error) {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	q.Set("database", t.database)
+
+	var body io.Reader
+	if data == nil {
+		body = strings.NewReader(query)
+	} else {
+		q.Set("query", query)
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(http.MethodPost, t.baseURL+"/?"+q.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	t.authorize(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+func (t *ClickHouseTarget) authorize(req *http.Request) {
+	if t.user != "" {
+		req.Header.Set("X-ClickHouse-User", t.user)
+	}
+	if t.password != "" {
+		req.Header.Set("X-ClickHouse-Key", t.password)
+	}
+}
+
+func (t *ClickHouseTarget) option(entity *domain.Entity, key string) string {
+	if v, ok := entity.Options[key]; ok {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(t.options[key])
+}
+
+func (t *ClickHouseTarget) qualified(tableName string) string {
+	return quoteIdent(t.database) + "." + quoteIdent(tableName)
+}
+
+// quoteIdent quotes a database, table or column name with backticks so
+// reserved words and other characters are taken literally.
+func quoteIdent(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
+
+var keyPartRe = regexp.MustCompile(`^(?:([A-Za-z_][A-Za-z0-9_]*)\(\s*([A-Za-z_][A-Za-z0-9_]*)\s*\)|([A-Za-z_][A-Za-z0-9_]*))$`)
+
+// validateKeyExpr accepts a comma-separated list of column names or single-argument
+// function calls over a column (e.g. "device_id, toYYYYMM(ts)").
+func validateKeyExpr(expr string, entity *domain.Entity) error {
+	if expr == "" {
+		return nil
+	}
+	columns := make(map[string]bool, len(entity.Columns))
+	for _, col := range entity.Columns {
+		columns[col.Name] = true
+	}
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		m := keyPartRe.FindStringSubmatch(part)
+		if m == nil {
+			return fmt.Errorf("unsupported key expression %q", part)
+		}
+		col := m[3]
+		if col == "" {
+			col = m[2]
+		}
+		if !columns[col] {
+			return fmt.Errorf("unknown column %q", col)
+		}
+	}
+	return nil
+}
+
+// quoteKeyExpr quotes the column names of a key expression accepted by
+// validateKeyExpr.
+func quoteKeyExpr(expr string) string {
+	if expr == "" {
+		return ""
+	}
+	parts := strings.Split(expr, ",")
+	for i, part := range parts {
+		m := keyPartRe.FindStringSubmatch(strings.TrimSpace(part))
+		if m[3] != "" {
+			parts[i] = quoteIdent(m[3])
+		} else {
+			parts[i] = m[1] + "(" + quoteIdent(m[2]) + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func mapColumnType(colType domain.ColumnType) string {
+	switch colType {
+	case domain.ColumnTypeInt:
+		return "Int32"
+	case domain.ColumnTypeBigInt:
+		return "Int64"
+	case domain.ColumnTypeFloat:
+		return "Float32"
+	case domain.ColumnTypeDouble:
+		return "Float64"
+	case domain.ColumnTypeString, domain.ColumnTypeText:
+		return "String"
+	case domain.ColumnTypeBool:
+		return "Bool"
+	case domain.ColumnTypeTimestamp:
+		return "DateTime64(3)"
+	case domain.ColumnTypeDate:
+		return "Date"
+	case domain.ColumnTypeUUID:
+		return "UUID"
+	default:
+		return "String"
+	}
+}
+
+func clickhouseTypeCompatible(expected, actual string) bool {
+	actual = unwrapNullable(actual)
+	if expected == actual {
+		return true
+	}
+	switch expected {
+	case "Int32":
+		return actual == "Int64"
+	case "Float32":
+		return actual == "Float64"
+	case "DateTime64(3)":
+		return strings.HasPrefix(actual, "DateTime")
+	case "Date":
+		return actual == "Date32"
+	case "String":
+		return strings.HasPrefix(actual, "LowCardinality(String") || strings.HasPrefix(actual, "FixedString(")
+	case "Bool":
+		return actual == "UInt8"
+	default:
+		return false
+	}
+}
+
+func unwrapNullable(typ string) string {
+	if strings.HasPrefix(typ, "Nullable(") && strings.HasSuffix(typ, ")") {
+		return typ[len("Nullable(") : len(typ)-1]
+	}
+	return typ
+}
+
+func encodeJSONEachRow(columns []string, rows [][]interface{}, types map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		doc := make(map[string]any, len(columns))
+		for i, col := range columns {
+			doc[col] = jsonValue(row[i], unwrapNullable(types[col]))
+		}
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func jsonValue(v interface{}, typ string) interface{} {
+	ts, ok := v.(time.Time)
+	if !ok {
+		return v
+	}
+	if strings.HasPrefix(typ, "Date") && !strings.HasPrefix(typ, "DateTime") {
+		return ts.UTC().Format("2006-01-02")
+	}
+	return ts.UTC().Format("2006-01-02 15:04:05.000")
+}
+
+func encodeRowBinary(columns []string, rows [][]interface{}, types map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		for i, col := range columns {
+			if err := writeBinaryValue(&buf, row[i], types[col]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", col, err)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeBinaryValue(buf *bytes.Buffer, v interface{}, typ string) error {
+	if inner := unwrapNullable(typ); inner != typ {
+		if v == nil {
+			buf.WriteByte(1)
+			return nil
+		}
+		buf.WriteByte(0)
+		typ = inner
+	} else if v == nil {
+		return fmt.Errorf("nil value for non-nullable %s", typ)
+	}
+
+	le := binary.LittleEndian
+	switch {
+	case typ == "Int32":
+		n, err := toInt64(v, typ)
+		if err != nil {
+			return err
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return fmt.Errorf("value %d is out of range for Int32", n)
+		}
+		return binary.Write(buf, le, int32(n))
+	case typ == "Int64":
+		n, err := toInt64(v, typ)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, le, n)
+	case typ == "UInt8" || typ == "Bool":
+		if b, ok := v.(bool); ok {
+			if b {
+				return buf.WriteByte(1)
+			}
+			return buf.WriteByte(0)
+		}
+		n, err := toInt64(v, typ)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > math.MaxUint8 {
+			return fmt.Errorf("value %d is out of range for %s", n, typ)
+		}
+		return buf.WriteByte(uint8(n))
+	case typ == "Float32":
+		f, err := toFloat64(v, typ)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, le, math.Float32bits(float32(f)))
+	case typ == "Float64":
+		f, err := toFloat64(v, typ)
+		if err != nil {
+			return err
+		}
+		return binary.Write(buf, le, math.Float64bits(f))
+	case typ == "String" || strings.HasPrefix(typ, "LowCardinality(String"):
+		s := fmt.Sprint(v)
+		var lenBuf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(lenBuf[:], uint64(len(s)))
+		buf.Write(lenBuf[:n])
+		buf.WriteString(s)
+		return nil
+	case typ == "Date" || typ == "Date32":
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time value for %s, got %T", typ, v)
+		}
+		days := ts.UTC().Unix() / 86400
+		if ts.UTC().Unix()%86400 < 0 {
+			days--
+		}
+		if typ == "Date32" {
+			return binary.Write(buf, le, int32(days))
+		}
+		// Date is an unsigned day count: 1970-01-01 through 2149-06-06.
+		if days < 0 || days > math.MaxUint16 {
+			return fmt.Errorf("date %s is outside the ClickHouse Date range 1970-01-01 to 2149-06-06", ts.UTC().Format("2006-01-02"))
+		}
+		return binary.Write(buf, le, uint16(days))
+	case strings.HasPrefix(typ, "DateTime64(3"):
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time value for %s, got %T", typ, v)
+		}
+		return binary.Write(buf, le, ts.UnixMilli())
+	case strings.HasPrefix(typ, "DateTime"):
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time value for %s, got %T", typ, v)
+		}
+		return binary.Write(buf, le, uint32(ts.Unix()))
+	case typ == "UUID":
+		u, err := uuid.Parse(fmt.Sprint(v))
+		if err != nil {
+			return err
+		}
+		// ClickHouse stores UUIDs as two little-endian UInt64 halves.
+		if err := binary.Write(buf, le, binary.BigEndian.Uint64(u[0:8])); err != nil {
+			return err
+		}
+		return binary.Write(buf, le, binary.BigEndian.Uint64(u[8:16]))
+	default:
+		return fmt.Errorf("RowBinary encoding not supported for type %s", typ)
+	}
+}
+
+func toInt64(v interface{}, typ string) (int64, error) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	case float64:
+		return int64(val), nil
+	case float32:
+		return int64(val), nil
+	default:
+		return 0, fmt.Errorf("expected integer value for %s, got %T", typ, v)
+	}
+}
+
+func toFloat64(v interface{}, typ string) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case float32:
+		return float64(val), nil
+	case int:
+		return float64(val), nil
+	case int32:
+		return float64(val), nil
+	case int64:
+		return float64(val), nil
+	default:
+		return 0, fmt.Errorf("expected numeric value for %s, got %T", typ, v)
+	}
+}
+
+// parseDSN splits an http(s) DSN into a base URL and optional userinfo credentials.
+func parseDSN(dsn string) (string, string, string) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return "http://localhost:8123", "", ""
+	}
+	if !strings.HasPrefix(dsn, "http://") && !strings.HasPrefix(dsn, "https://") {
+		dsn = "http://" + dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return strings.TrimRight(dsn, "/"), "", ""
+	}
+	var user, password string
+	if u.User != nil {
+		user = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+	u.RawQuery = ""
+	return strings.TrimRight(u.String(), "/"), user, password
+}
+
+func GetServerVersion(dsn string) (string, error) {
+	t := NewClickHouseTarget(dsn, "", nil)
+	t.client = &http.Client{Timeout: 15 * time.Second}
+	out, err := t.exec("SELECT version()", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
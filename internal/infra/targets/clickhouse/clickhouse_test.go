@@ -0,0 +1,290 @@
+package clickhouse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func TestClickHouseTarget_BasicFlow(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var (
+		mu      sync.Mutex
+		queries []string
+		created bool
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-ClickHouse-User") != "bench" || r.Header.Get("X-ClickHouse-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Path == "/ping" {
+			_, _ = w.Write([]byte("Ok.\n"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		q := r.URL.Query().Get("query")
+		if q == "" {
+			q = string(body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, q)
+		switch {
+		case strings.HasPrefix(q, "SELECT name, type FROM system.columns"):
+			if r.URL.Query().Get("param_tbl") != "readings" {
+				t.Errorf("unexpected table param: %s", r.URL.RawQuery)
+			}
+			if created {
+				_, _ = w.Write([]byte(`{"name":"device_id","type":"UUID"}` + "\n" + `{"name":"ts","type":"DateTime64(3)"}` + "\n"))
+			}
+		case strings.HasPrefix(q, "CREATE TABLE"):
+			created = true
+		case strings.HasPrefix(q, "INSERT INTO"):
+			if !strings.Contains(string(body), `"ts":"2026-01-02 03:04:05.000"`) {
+				t.Errorf("insert payload missing formatted timestamp: %s", string(body))
+			}
+		case strings.HasPrefix(q, "SELECT maxOrNull(`ts`) AS v FROM `analytics`.`readings`"):
+			_, _ = w.Write([]byte(`{"v":"2026-01-02 03:04:05.000"}` + "\n"))
+		case strings.HasPrefix(q, "SELECT `code` AS v FROM `analytics`.`readings` ORDER BY length(`code`) DESC, `code` DESC LIMIT 1"):
+			_, _ = w.Write([]byte(`{"v":"INV-10"}` + "\n"))
+		case strings.HasPrefix(q, "SELECT `note` AS v FROM `analytics`.`readings` ORDER BY length(`note`)"):
+		case strings.HasPrefix(q, "TRUNCATE"), strings.HasPrefix(q, "SELECT version()"):
+			_, _ = w.Write([]byte("24.3.1.1\n"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	dsn := strings.Replace(ts.URL, "http://", "http://bench:secret@", 1)
+	tgt := NewClickHouseTarget(dsn, "analytics", map[string]string{"order_by": "ts"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{
+		Name:        "readings",
+		TargetTable: "readings",
+		Columns: []domain.Column{
+			{Name: "device_id", Type: domain.ColumnTypeUUID},
+			{Name: "ts", Type: domain.ColumnTypeTimestamp},
+		},
+		Options: map[string]string{"partition_by": "toYYYYMM(ts)"},
+	}
+	if err := tgt.CreateTableIfNotExists(entity); err != nil {
+		t.Fatal(err)
+	}
+	ts0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := tgt.InsertBatch("readings", []string{"device_id", "ts"}, [][]interface{}{{"8d7f3c1e-0000-4000-8000-000000000001", ts0}}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := tgt.MaxValue("readings", "ts", false); err != nil || v != "2026-01-02 03:04:05.000" {
+		t.Fatalf("unexpected max value %v, err=%v", v, err)
+	}
+	if v, err := tgt.MaxValue("readings", "code", true); err != nil || v != "INV-10" {
+		t.Fatalf("unexpected text max value %v, err=%v", v, err)
+	}
+	if v, err := tgt.MaxValue("readings", "note", true); err != nil || v != nil {
+		t.Fatalf("expected no text max value for an empty column, got %v, err=%v", v, err)
+	}
+	if err := tgt.TruncateTable("readings"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ddl string
+	for _, q := range queries {
+		if strings.HasPrefix(q, "CREATE TABLE") {
+			ddl = q
+		}
+	}
+	want := "CREATE TABLE IF NOT EXISTS `analytics`.`readings` (`device_id` UUID, `ts` DateTime64(3)) ENGINE = MergeTree PARTITION BY toYYYYMM(`ts`) ORDER BY `ts`"
+	if ddl != want {
+		t.Fatalf("unexpected DDL:\n got %s\nwant %s", ddl, want)
+	}
+
+	// A second create validates the existing table instead of issuing DDL.
+	if err := tgt.CreateTableIfNotExists(entity); err != nil {
+		t.Fatal(err)
+	}
+	if ver, err := GetServerVersion(dsn); err != nil || ver != "24.3.1.1" {
+		t.Fatalf("unexpected version result ver=%q err=%v", ver, err)
+	}
+}
+
+func TestValidateKeyExpr(t *testing.T) {
+	entity := &domain.Entity{Columns: []domain.Column{{Name: "device_id"}, {Name: "ts"}}}
+	for _, ok := range []string{"", "ts", "device_id, ts", "toYYYYMM(ts)", "device_id,toDate(ts)"} {
+		if err := validateKeyExpr(ok, entity); err != nil {
+			t.Fatalf("expected %q valid, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"missing", "ts; DROP TABLE x", "f(g(ts))", "ts DESC"} {
+		if err := validateKeyExpr(bad, entity); err == nil {
+			t.Fatalf("expected %q invalid", bad)
+		}
+	}
+}
+
+func TestClickHouseTarget_EntityFormat(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var (
+		mu      sync.Mutex
+		inserts []string
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		q := r.URL.Query().Get("query")
+		if q == "" {
+			q = string(body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/ping":
+			_, _ = w.Write([]byte("Ok.\n"))
+		case strings.HasPrefix(q, "SELECT name, type FROM system.columns"):
+			_, _ = w.Write([]byte(`{"name":"n","type":"Int64"}` + "\n"))
+		case strings.HasPrefix(q, "INSERT INTO"):
+			inserts = append(inserts, q)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	tgt := NewClickHouseTarget(ts.URL, "analytics", map[string]string{"format": FormatJSONEachRow})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	binary := &domain.Entity{Name: "a", TargetTable: "a", Columns: []domain.Column{{Name: "n", Type: domain.ColumnTypeBigInt}}, Options: map[string]string{"format": FormatRowBinary}}
+	plain := &domain.Entity{Name: "b", TargetTable: "b", Columns: []domain.Column{{Name: "n", Type: domain.ColumnTypeBigInt}}}
+	for _, e := range []*domain.Entity{binary, plain} {
+		if err := tgt.BindEntity(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := tgt.InsertBatch(e.TargetTable, []string{"n"}, [][]interface{}{{int64(1)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"INSERT INTO `analytics`.`a` (`n`) FORMAT RowBinary", "INSERT INTO `analytics`.`b` (`n`) FORMAT JSONEachRow"}
+	if strings.Join(inserts, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected inserts:\n got %v\nwant %v", inserts, want)
+	}
+
+	bad := &domain.Entity{Name: "c", TargetTable: "c", Options: map[string]string{"format": "CSV"}}
+	if err := tgt.BindEntity(bad); err == nil {
+		t.Fatal("expected unsupported format to be rejected")
+	}
+}
+
+func TestClickHouseTarget_QuotesIdentifiers(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		q := r.URL.Query().Get("query")
+		if q == "" {
+			q = string(body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/ping" && !strings.HasPrefix(q, "SELECT name, type FROM system.columns") {
+			queries = append(queries, q)
+		}
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	tgt := NewClickHouseTarget(ts.URL, "analytics", map[string]string{"order_by": "order"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{Name: "o", TargetTable: "orders", Columns: []domain.Column{
+		{Name: "order", Type: domain.ColumnTypeBigInt},
+		{Name: "user-id", Type: domain.ColumnTypeString},
+		{Name: "odd`name", Type: domain.ColumnTypeString},
+	}}
+	if err := tgt.CreateTableIfNotExists(entity); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("orders", []string{"order", "user-id", "odd`name"}, [][]interface{}{{int64(1), "u", "x"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS `analytics`.`orders` (`order` Int64, `user-id` String, `odd\\`name` String) ENGINE = MergeTree ORDER BY `order`",
+		"INSERT INTO `analytics`.`orders` (`order`, `user-id`, `odd\\`name`) FORMAT JSONEachRow",
+	}
+	if strings.Join(queries, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected queries:\n got %v\nwant %v", queries, want)
+	}
+}
+
+func TestWriteBinaryValue_RejectsBadValues(t *testing.T) {
+	cases := map[string]struct {
+		v   interface{}
+		typ string
+	}{
+		"expected integer value for Int32, got string":         {"12", "Int32"},
+		"expected numeric value for Float64, got bool":         {true, "Float64"},
+		"value 40000000000 is out of range for Int32":          {int64(40000000000), "Int32"},
+		"date 1960-01-01 is outside the ClickHouse Date range": {time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), "Date"},
+		"date 2150-01-01 is outside the ClickHouse Date range": {time.Date(2150, 1, 1, 0, 0, 0, 0, time.UTC), "Date"},
+	}
+	for want, c := range cases {
+		var buf bytes.Buffer
+		if err := writeBinaryValue(&buf, c.v, c.typ); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := writeBinaryValue(&buf, time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), "Date32"); err != nil {
+		t.Fatal(err)
+	}
+	if got := int32(binary.LittleEndian.Uint32(buf.Bytes())); got != -3653 {
+		t.Fatalf("expected Date32 day -3653, got %d", got)
+	}
+}
+
+func TestClickHouseTypeCompatible(t *testing.T) {
+	for _, c := range []struct {
+		expected, actual string
+		ok               bool
+	}{
+		{"Int32", "Int64", true},
+		{"Int32", "Nullable(Int32)", true},
+		{"Int32", "Int16", false},
+		{"Float32", "Float64", true},
+		{"Float64", "Float32", false},
+		{"Date", "Date32", true},
+	} {
+		if got := clickhouseTypeCompatible(c.expected, c.actual); got != c.ok {
+			t.Errorf("clickhouseTypeCompatible(%s, %s) = %v, want %v", c.expected, c.actual, got, c.ok)
+		}
+	}
+}
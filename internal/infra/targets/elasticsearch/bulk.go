@@ -0,0 +1,213 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type bulkItemResult struct {
+	Index  string `json:"_index"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+type bulkStatusError struct {
+	status int
+	body   string
+}
+
+func (e *bulkStatusError) Error() string {
+	return fmt.Sprintf("elasticsearch bulk insert failed: status=%d body=%s", e.status, e.body)
+}
+
+// SetRejectPolicy sets how many documents may be rejected over the whole run
+// and where rejection reasons are logged.
+func (t *ElasticsearchTarget) SetRejectPolicy(maxRejected int64, log func(level, message string)) {
+	t.maxRejected = maxRejected
+	t.log = log
+}
+
+func (t *ElasticsearchTarget) Rejected() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rejected
+}
+
+// enqueue hands items to the bulk writers. Without bulk_max_bytes every
+// InsertBatch call becomes one request; with it, items are buffered and cut
+// into requests of at most that many bytes. With bulk_workers > 1 requests run
+// concurrently and errors surface on a later call or on Flush.
+func (t *ElasticsearchTarget) enqueue(items [][]byte) error {
+	if t.maxBytes <= 0 {
+		if err := t.dispatch(items); err != nil {
+			return err
+		}
+		return t.writeErr()
+	}
+	for _, item := range items {
+		if len(t.pending) > 0 && t.pendingBytes+len(item) > t.maxBytes {
+			if err := t.dispatch(t.pending); err != nil {
+				return err
+			}
+			t.pending, t.pendingBytes = nil, 0
+		}
+		t.pending = append(t.pending, item)
+		t.pendingBytes += len(item)
+	}
+	return t.writeErr()
+}
+
+// Flush sends buffered items and waits for in-flight bulk requests.
+func (t *ElasticsearchTarget) Flush() error {
+	if len(t.pending) > 0 {
+		items := t.pending
+		t.pending, t.pendingBytes = nil, 0
+		if err := t.dispatch(items); err != nil {
+			return err
+		}
+	}
+	t.wg.Wait()
+	return t.writeErr()
+}
+
+func (t *ElasticsearchTarget) dispatch(items [][]byte) error {
+	if t.workers <= 1 {
+		return t.bulk(items)
+	}
+	if err := t.writeErr(); err != nil {
+		return err
+	}
+	if t.sem == nil {
+		t.sem = make(chan struct{}, t.workers)
+	}
+	t.sem <- struct{}{}
+	t.wg.Add(1)
+	go func() {
+		defer func() {
+			<-t.sem
+			t.wg.Done()
+		}()
+		if err := t.bulk(items); err != nil {
+			t.mu.Lock()
+			if t.asyncErr == nil {
+				t.asyncErr = err
+			}
+			t.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+func (t *ElasticsearchTarget) writeErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.asyncErr
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// bulk sends items (each an action line plus a source line) and retries
+// requests or individual items rejected with 429/503, backing off
+// exponentially. Remaining failures count against the reject budget.
+func (t *ElasticsearchTarget) bulk(items [][]byte) error {
+	pending := items
+	backoff := t.retryBackoff
+	for attempt := 0; ; attempt++ {
+		results, err := t.sendBulk(pending)
+		if err != nil {
+			if se, ok := err.(*bulkStatusError); ok && retryableStatus(se.status) && attempt < t.maxRetries {
+				time.Sleep(backoff)
+				backoff *= 2
+				continue
+			}
+			return err
+		}
+		if len(results) != len(pending) {
+			return fmt.Errorf("elasticsearch bulk insert returned %d items for %d documents", len(results), len(pending))
+		}
+
+		var retry [][]byte
+		for i, r := range results {
+			if r.Status >= 200 && r.Status <= 299 {
+				continue
+			}
+			if retryableStatus(r.Status) && attempt < t.maxRetries {
+				retry = append(retry, pending[i])
+				continue
+			}
+			t.reject(r)
+		}
+		if len(retry) == 0 {
+			break
+		}
+		pending = retry
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rejected > t.maxRejected {
+		return fmt.Errorf("elasticsearch bulk insert rejected %d documents (max_rejected_docs=%d); first error: %s", t.rejected, t.maxRejected, t.firstRejection)
+	}
+	return nil
+}
+
+func (t *ElasticsearchTarget) reject(r bulkItemResult) {
+	reason := fmt.Sprintf("status=%d", r.Status)
+	if r.Error != nil {
+		reason = fmt.Sprintf("status=%d type=%s reason=%s", r.Status, r.Error.Type, r.Error.Reason)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rejected++
+	if t.firstRejection == "" {
+		t.firstRejection = reason
+	}
+	if t.log != nil && t.rejected <= t.errorLogLimit {
+		t.log("warn", fmt.Sprintf("elasticsearch rejected document in %s: %s", r.Index, reason))
+	}
+}
+
+func (t *ElasticsearchTarget) sendBulk(items [][]byte) ([]bulkItemResult, error) {
+	var buf bytes.Buffer
+	for _, item := range items {
+		buf.Write(item)
+	}
+	status, body, err := t.do(http.MethodPost, "/_bulk", "application/x-ndjson", buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status > 299 {
+		return nil, &bulkStatusError{status: status, body: strings.TrimSpace(string(body))}
+	}
+	var bulkResp struct {
+		Errors bool                        `json:"errors"`
+		Items  []map[string]bulkItemResult `json:"items"`
+	}
+	if err := json.Unmarshal(body, &bulkResp); err != nil {
+		return nil, fmt.Errorf("elasticsearch bulk response invalid: %w", err)
+	}
+	if !bulkResp.Errors {
+		results := make([]bulkItemResult, len(items))
+		for i := range results {
+			results[i].Status = http.StatusOK
+		}
+		return results, nil
+	}
+	results := make([]bulkItemResult, len(bulkResp.Items))
+	for i, item := range bulkResp.Items {
+		for _, r := range item {
+			results[i] = r
+		}
+	}
+	return results, nil
+}
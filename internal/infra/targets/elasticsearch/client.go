@@ -0,0 +1,64 @@
+package elasticsearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// newHTTPClient builds a client honoring ca_file and insecure_skip_verify.
+func newHTTPClient(options map[string]string) (*http.Client, error) {
+	timeout := time.Duration(optionInt(options, "timeout_seconds", 60)) * time.Second
+	caFile := strings.TrimSpace(options["ca_file"])
+	insecure := strings.TrimSpace(options["insecure_skip_verify"]) == "true"
+	if caFile == "" && !insecure {
+		return &http.Client{Timeout: timeout}, nil
+	}
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: insecure}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file %s contains no PEM certificates", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// authorize applies api_key, bearer_token or basic auth, in that order of
+// precedence. Basic auth options override DSN userinfo.
+func (t *ElasticsearchTarget) authorize(req *http.Request) {
+	if key := strings.TrimSpace(t.options["api_key"]); key != "" {
+		if strings.Contains(key, ":") {
+			key = base64.StdEncoding.EncodeToString([]byte(key))
+		}
+		req.Header.Set("Authorization", "ApiKey "+key)
+		return
+	}
+	if tok := strings.TrimSpace(t.options["bearer_token"]); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+		return
+	}
+	user, password := t.user, t.password
+	if v, ok := t.options["username"]; ok {
+		user, password = v, t.options["password"]
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+}
@@ -7,119 +7,369 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
 )
 
+// ElasticsearchTarget writes rows through the _bulk API into an index per
+// entity. It also serves OpenSearch clusters, which share the APIs used here.
+//
+// Supported options (target-level, overridable per entity via entity options):
+//   - mapping.<column>: field mapping override, a type ("text") or JSON object
+//   - number_of_shards, number_of_replicas, refresh_interval: index settings on create
+//   - bulk_max_retries: retries for 429/503 bulk responses and items (default 3)
+//   - bulk_retry_backoff_ms: initial retry backoff, doubled per attempt (default 500)
+//   - error_log_limit: rejected documents whose reasons are logged (default 10)
+//   - id_column: column whose value becomes the document _id, so reruns overwrite
+//   - bulk_tuning: "true" sets refresh_interval=-1 and number_of_replicas=0
+//     while loading and restores the previous values on Close; for index
+//     patterns and data streams the sdgen-<table> template carries them too
+//   - bulk_workers: concurrent _bulk requests (default 1)
+//   - bulk_max_bytes: split bulk requests by payload size instead of per batch
+//   - index_pattern, data_stream, timestamp_column, op_type: see indexRoute
+//
+// Connection options (target-level only):
+//   - username, password: basic auth (default: DSN userinfo)
+//   - api_key: "id:key" or its base64 encoding, sent as "ApiKey ..."
+//   - bearer_token: sent as "Bearer ..."
+//   - ca_file: PEM bundle trusted in addition to the system roots
+//   - insecure_skip_verify: "true" disables TLS certificate verification
+//   - timeout_seconds: per-request timeout (default 60)
 type ElasticsearchTarget struct {
-	baseURL string
-	client  *http.Client
+	baseURL  string
+	user     string
+	password string
+	options  map[string]string
+	client   *http.Client
+
+	maxRetries     int
+	retryBackoff   time.Duration
+	errorLogLimit  int64
+	maxRejected    int64
+	log            func(level, message string)
+	mu             sync.Mutex
+	rejected       int64
+	firstRejection string
+
+	entities       map[string]*domain.Entity
+	routes         map[string]*indexRoute
+	tuned          []tunedIndex
+	tunedTemplates []*indexRoute
+
+	workers      int
+	maxBytes     int
+	pending      [][]byte
+	pendingBytes int
+	sem          chan struct{}
+	wg           sync.WaitGroup
+	asyncErr     error
 }
 
-func NewElasticsearchTarget(dsn string) *ElasticsearchTarget {
-	return &ElasticsearchTarget{baseURL: normalizeURL(dsn)}
+func NewElasticsearchTarget(dsn string, options map[string]string) *ElasticsearchTarget {
+	base, user, password := parseDSN(dsn)
+	return &ElasticsearchTarget{
+		baseURL:       base,
+		user:          user,
+		password:      password,
+		options:       options,
+		maxRetries:    optionInt(options, "bulk_max_retries", 3),
+		retryBackoff:  time.Duration(optionInt(options, "bulk_retry_backoff_ms", 500)) * time.Millisecond,
+		errorLogLimit: int64(optionInt(options, "error_log_limit", 10)),
+		entities:      map[string]*domain.Entity{},
+		routes:        map[string]*indexRoute{},
+		workers:       max(optionInt(options, "bulk_workers", 1), 1),
+		maxBytes:      optionInt(options, "bulk_max_bytes", 0),
+	}
 }
 
 func (t *ElasticsearchTarget) Connect() error {
-	t.client = &http.Client{Timeout: 15 * time.Second}
-	resp, err := t.client.Get(t.baseURL + "/")
+	client, err := newHTTPClient(t.options)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("elasticsearch ping failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	t.client = client
+	_, err = t.serverInfo()
+	return err
+}
+
+// Close waits for in-flight bulk requests and restores index settings changed
+// by bulk_tuning.
+func (t *ElasticsearchTarget) Close() error {
+	flushErr := t.Flush()
+	if err := t.restoreSettings(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// BindEntity records the entity for id_column lookups and index routing and
+// applies bulk_tuning to its existing indices and, for index patterns and data
+// streams, to the template of indices created during the load.
+func (t *ElasticsearchTarget) BindEntity(entity *domain.Entity) error {
+	route, err := t.bindRoute(entity)
+	if err != nil {
+		return err
+	}
+	if t.option(entity, "bulk_tuning") != "true" {
+		return nil
+	}
+	if err := t.tuneTemplate(entity, route); err != nil {
+		return err
+	}
+	indices, err := t.concreteIndices(route)
+	if err != nil {
+		return err
+	}
+	for _, name := range indices {
+		if err := t.tuneForLoad(name); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (t *ElasticsearchTarget) Close() error { return nil }
+func (t *ElasticsearchTarget) bindRoute(entity *domain.Entity) (*indexRoute, error) {
+	route, err := t.buildRoute(entity)
+	if err != nil {
+		return nil, err
+	}
+	t.entities[route.name] = entity
+	t.routes[route.name] = route
+	return route, nil
+}
 
+// CreateTableIfNotExists creates the entity's index, or for index patterns and
+// data streams an index template that the cluster applies on first write.
 func (t *ElasticsearchTarget) CreateTableIfNotExists(entity *domain.Entity) error {
-	indexName := toIndexName(entity.TargetTable)
-	req, err := http.NewRequest(http.MethodPut, t.baseURL+"/"+indexName, nil)
+	route, err := t.bindRoute(entity)
 	if err != nil {
 		return err
 	}
-	resp, err := t.client.Do(req)
+	if route.dataStream || len(route.parts) > 0 {
+		return t.putIndexTemplate(entity, route, nil)
+	}
+	indexName := route.name
+	existing, found, err := t.fetchMapping(indexName)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+	if found {
+		return t.validateExistingMapping(entity, indexName, existing)
+	}
+
+	payload, err := t.buildIndexBody(entity)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	status, body, err := t.do(http.MethodPut, "/"+indexName, "application/json", data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK || status == http.StatusCreated {
 		return nil
 	}
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode == http.StatusBadRequest && strings.Contains(string(body), "resource_already_exists_exception") {
+	if status == http.StatusBadRequest && strings.Contains(string(body), "resource_already_exists_exception") {
 		return nil
 	}
-	return fmt.Errorf("elasticsearch create index failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	return fmt.Errorf("elasticsearch create index failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
 }
 
-func (t *ElasticsearchTarget) TruncateTable(tableName string) error {
-	indexName := toIndexName(tableName)
-	payload := []byte(`{"query":{"match_all":{}}}`)
-	req, err := http.NewRequest(http.MethodPost, t.baseURL+"/"+indexName+"/_delete_by_query", bytes.NewReader(payload))
+// fetchMapping returns the top-level field types of an index and whether it exists.
+func (t *ElasticsearchTarget) fetchMapping(indexName string) (map[string]string, bool, error) {
+	status, body, err := t.do(http.MethodGet, "/"+indexName+"/_mapping", "", nil)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := t.client.Do(req)
+	if status == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if status < 200 || status > 299 {
+		return nil, false, fmt.Errorf("elasticsearch get mapping failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	fields, err := parseMappingResponse(body)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("elasticsearch truncate failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	return fields, true, nil
+}
+
+// TruncateTable deletes all documents of the table's index or data stream,
+// or of the existing indices its index_pattern writes to.
+func (t *ElasticsearchTarget) TruncateTable(tableName string) error {
+	indices := []string{toIndexName(tableName)}
+	query := ""
+	if route, ok := t.routes[toIndexName(tableName)]; ok && (route.dataStream || len(route.parts) > 0) {
+		// The data stream or pattern indices may not exist before the first write.
+		query = "?allow_no_indices=true&ignore_unavailable=true"
+		var err error
+		if indices, err = t.concreteIndices(route); err != nil {
+			return err
+		}
+	}
+	payload := []byte(`{"query":{"match_all":{}}}`)
+	for start := 0; start < len(indices); start += truncateChunk {
+		names := indices[start:min(start+truncateChunk, len(indices))]
+		path := "/" + strings.Join(names, ",") + "/_delete_by_query" + query
+		status, body, err := t.do(http.MethodPost, path, "application/json", payload)
+		if err != nil {
+			return err
+		}
+		if status < 200 || status > 299 {
+			return fmt.Errorf("elasticsearch truncate failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+		}
 	}
 	return nil
 }
 
+// truncateChunk bounds the number of indices named in one delete-by-query
+// URL.
+const truncateChunk = 50
+
 func (t *ElasticsearchTarget) InsertBatch(tableName string, columns []string, rows [][]interface{}) error {
 	if len(rows) == 0 {
 		return nil
 	}
 	indexName := toIndexName(tableName)
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
+	entity := t.entities[indexName]
+	route := t.routes[indexName]
+	if route == nil {
+		route = &indexRoute{name: indexName, opType: "index"}
+	}
+	colIdx := make(map[string]int, len(columns))
+	for i, col := range columns {
+		colIdx[col] = i
+	}
+	idIdx := -1
+	if idCol := t.option(entity, "id_column"); idCol != "" {
+		i, ok := colIdx[idCol]
+		if !ok {
+			return fmt.Errorf("elasticsearch id_column %s not found in columns of %s", idCol, tableName)
+		}
+		idIdx = i
+	}
+	items := make([][]byte, 0, len(rows))
 	for _, row := range rows {
-		if err := enc.Encode(map[string]any{"index": map[string]string{"_index": indexName}}); err != nil {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		target, err := route.indexFor(row, colIdx)
+		if err != nil {
+			return err
+		}
+		action := map[string]string{"_index": target}
+		if idIdx >= 0 {
+			if row[idIdx] == nil {
+				return fmt.Errorf("elasticsearch id_column %s is null", columns[idIdx])
+			}
+			action["_id"] = documentID(row[idIdx])
+		}
+		if err := enc.Encode(map[string]any{route.opType: action}); err != nil {
 			return err
 		}
 		doc := map[string]any{}
 		for i, col := range columns {
 			doc[col] = row[i]
 		}
+		if route.timestamp != "" && route.timestamp != "@timestamp" {
+			doc["@timestamp"] = row[colIdx[route.timestamp]]
+		}
 		if err := enc.Encode(doc); err != nil {
 			return err
 		}
+		items = append(items, buf.Bytes())
 	}
-	req, err := http.NewRequest(http.MethodPost, t.baseURL+"/_bulk", &buf)
+	return t.enqueue(items)
+}
+
+// documentID renders an id_column value the same way for every run.
+func documentID(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// do sends an authenticated request and returns the status and body.
+func (t *ElasticsearchTarget) do(method, path, contentType string, payload []byte) (int, []byte, error) {
+	var r io.Reader
+	if payload != nil {
+		r = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, t.baseURL+path, r)
 	if err != nil {
-		return err
+		return 0, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
 	}
-	req.Header.Set("Content-Type", "application/x-ndjson")
+	t.authorize(req)
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return fmt.Errorf("elasticsearch bulk insert failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+type serverInfo struct {
+	Version struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+	Tagline string `json:"tagline"`
+}
+
+// isOpenSearch reports whether the root endpoint answered as OpenSearch.
+func (i *serverInfo) isOpenSearch() bool {
+	return i.Version.Distribution == "opensearch" || strings.Contains(i.Tagline, "OpenSearch")
+}
+
+func (t *ElasticsearchTarget) serverInfo() (*serverInfo, error) {
+	status, body, err := t.do(http.MethodGet, "/", "", nil)
+	if err != nil {
+		return nil, err
 	}
-	var bulkResp struct {
-		Errors bool `json:"errors"`
+	if status < 200 || status > 299 {
+		return nil, fmt.Errorf("elasticsearch ping failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
 	}
-	_ = json.Unmarshal(body, &bulkResp)
-	if bulkResp.Errors {
-		return fmt.Errorf("elasticsearch bulk insert returned errors")
+	var info serverInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
 	}
-	return nil
+	return &info, nil
+}
+
+func (t *ElasticsearchTarget) option(entity *domain.Entity, key string) string {
+	if entity != nil {
+		if v, ok := entity.Options[key]; ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return strings.TrimSpace(t.options[key])
+}
+
+func optionInt(options map[string]string, key string, def int) int {
+	if v, ok := options[key]; ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
 }
 
 func normalizeURL(dsn string) string {
@@ -133,30 +383,40 @@ func normalizeURL(dsn string) string {
 	return "http://" + strings.TrimRight(dsn, "/")
 }
 
+// parseDSN strips userinfo from the DSN so credentials are only ever sent via
+// the Authorization header.
+func parseDSN(dsn string) (string, string, string) {
+	base := normalizeURL(dsn)
+	u, err := url.Parse(base)
+	if err != nil || u.User == nil {
+		return base, "", ""
+	}
+	user := u.User.Username()
+	password, _ := u.User.Password()
+	u.User = nil
+	return strings.TrimRight(u.String(), "/"), user, password
+}
+
 func toIndexName(name string) string {
 	name = strings.ToLower(strings.TrimSpace(name))
 	return url.PathEscape(name)
 }
 
-func GetServerVersion(dsn string) (string, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
-	base := normalizeURL(dsn)
-	resp, err := client.Get(base + "/")
+// GetServerVersion returns the version number for Elasticsearch and
+// "opensearch <number>" for OpenSearch clusters.
+func GetServerVersion(dsn string, options map[string]string) (string, error) {
+	t := NewElasticsearchTarget(dsn, options)
+	client, err := newHTTPClient(options)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return "", fmt.Errorf("status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
-	}
-	var root struct {
-		Version struct {
-			Number string `json:"number"`
-		} `json:"version"`
-	}
-	if err := json.Unmarshal(body, &root); err != nil {
+	t.client = client
+	info, err := t.serverInfo()
+	if err != nil {
 		return "", err
 	}
-	return root.Version.Number, nil
+	if info.isOpenSearch() {
+		return "opensearch " + info.Version.Number, nil
+	}
+	return info.Version.Number, nil
 }
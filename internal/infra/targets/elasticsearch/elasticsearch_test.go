@@ -1,12 +1,18 @@
 package elasticsearch
 
 import (
+	"encoding/base64"
+	"encoding/pem"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
 )
@@ -22,6 +28,12 @@ func TestElasticsearchTarget_BasicFlow(t *testing.T) {
 			w.Header().Set("Content-Type", "application/json")
 			_, _ = w.Write([]byte(`{"version":{"number":"8.12.0"}}`))
 		case r.Method == http.MethodPut && r.URL.Path == "/events":
+			body, _ := io.ReadAll(r.Body)
+			for _, want := range []string{`"event_id":{"type":"keyword"}`, `"message":{"analyzer":"english","type":"text"}`, `"number_of_shards":"2"`} {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("create index body missing %s: %s", want, string(body))
+				}
+			}
 			w.WriteHeader(http.StatusCreated)
 			_, _ = w.Write([]byte(`{"acknowledged":true}`))
 		case r.Method == http.MethodPost && r.URL.Path == "/events/_delete_by_query":
@@ -42,11 +54,19 @@ func TestElasticsearchTarget_BasicFlow(t *testing.T) {
 	ts.Start()
 	defer ts.Close()
 
-	tgt := NewElasticsearchTarget(ts.URL)
+	tgt := NewElasticsearchTarget(ts.URL, map[string]string{"number_of_shards": "2"})
 	if err := tgt.Connect(); err != nil {
 		t.Fatal(err)
 	}
-	entity := &domain.Entity{Name: "events", TargetTable: "events"}
+	entity := &domain.Entity{
+		Name:        "events",
+		TargetTable: "events",
+		Columns: []domain.Column{
+			{Name: "event_id", Type: domain.ColumnTypeUUID},
+			{Name: "message", Type: domain.ColumnTypeString},
+		},
+		Options: map[string]string{"mapping.message": `{"type":"text","analyzer":"english"}`},
+	}
 	if err := tgt.CreateTableIfNotExists(entity); err != nil {
 		t.Fatal(err)
 	}
@@ -56,7 +76,360 @@ func TestElasticsearchTarget_BasicFlow(t *testing.T) {
 	if err := tgt.TruncateTable("events"); err != nil {
 		t.Fatal(err)
 	}
-	if ver, err := GetServerVersion(ts.URL); err != nil || ver != "8.12.0" {
+	if ver, err := GetServerVersion(ts.URL, nil); err != nil || ver != "8.12.0" {
+		t.Fatalf("unexpected version result ver=%q err=%v", ver, err)
+	}
+}
+
+func TestValidateExistingMapping(t *testing.T) {
+	body := []byte(`{"events-000001":{"mappings":{"properties":{"id":{"type":"long"},"ts":{"type":"date_nanos"},"tag":{"type":"text"}}}}}`)
+	existing, err := parseMappingResponse(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgt := NewElasticsearchTarget("http://localhost:9200", nil)
+	entity := &domain.Entity{
+		Name:        "events",
+		TargetTable: "events",
+		Columns: []domain.Column{
+			{Name: "id", Type: domain.ColumnTypeInt},
+			{Name: "ts", Type: domain.ColumnTypeTimestamp},
+		},
+	}
+	if err := tgt.validateExistingMapping(entity, "events", existing); err != nil {
+		t.Fatalf("expected compatible mapping, got %v", err)
+	}
+
+	entity.Columns = append(entity.Columns, domain.Column{Name: "tag", Type: domain.ColumnTypeString})
+	if err := tgt.validateExistingMapping(entity, "events", existing); err == nil || !strings.Contains(err.Error(), "type mismatch") {
+		t.Fatalf("expected keyword/text mismatch, got %v", err)
+	}
+	entity.Options = map[string]string{"mapping.tag": "text"}
+	if err := tgt.validateExistingMapping(entity, "events", existing); err != nil {
+		t.Fatalf("expected override to match existing text field, got %v", err)
+	}
+
+	entity.Columns = append(entity.Columns, domain.Column{Name: "missing", Type: domain.ColumnTypeBool})
+	if err := tgt.validateExistingMapping(entity, "events", existing); err == nil || !strings.Contains(err.Error(), "missing field") {
+		t.Fatalf("expected missing field error, got %v", err)
+	}
+}
+
+func TestElasticsearchTarget_BulkRetryAndRejections(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var calls int
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			_, _ = w.Write([]byte(`{"version":{"number":"8.12.0"}}`))
+			return
+		}
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		docs := strings.Count(string(body), "\n") / 2
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			if docs != 3 {
+				t.Errorf("expected full batch on request retry, got %d docs", docs)
+			}
+			_, _ = w.Write([]byte(`{"errors":true,"items":[
+				{"index":{"status":201}},
+				{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}},
+				{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [n]"}}}]}`))
+		case 3:
+			if docs != 1 {
+				t.Errorf("expected only the 429 item to be retried, got %d docs", docs)
+			}
+			_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`))
+		}
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	tgt := NewElasticsearchTarget(ts.URL, map[string]string{"bulk_retry_backoff_ms": "1"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	var logs []string
+	tgt.SetRejectPolicy(1, func(level, message string) { logs = append(logs, level+": "+message) })
+
+	rows := [][]interface{}{{1}, {2}, {"x"}}
+	if err := tgt.InsertBatch("events", []string{"n"}, rows); err != nil {
+		t.Fatalf("expected rejection within budget to pass, got %v", err)
+	}
+	if calls != 3 || tgt.Rejected() != 1 {
+		t.Fatalf("unexpected calls=%d rejected=%d", calls, tgt.Rejected())
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "failed to parse field [n]") {
+		t.Fatalf("expected rejection reason in logs, got %v", logs)
+	}
+
+	err = tgt.InsertBatch("events", []string{"n"}, [][]interface{}{{"y"}})
+	if err == nil || !strings.Contains(err.Error(), "rejected 2 documents (max_rejected_docs=1)") {
+		t.Fatalf("expected reject budget error, got %v", err)
+	}
+}
+
+func TestElasticsearchTarget_AuthTLSAndOpenSearch(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var gotAuth []string
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"version":{"distribution":"opensearch","number":"2.11.0"},"tagline":"The OpenSearch Project: https://opensearch.org/"}`))
+	}))
+	ts.Listener = ln
+	ts.StartTLS()
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dsn := strings.Replace(ts.URL, "https://", "https://elastic:changeme@", 1)
+
+	if _, err := GetServerVersion(dsn, nil); err == nil {
+		t.Fatal("expected untrusted certificate to fail")
+	}
+	ver, err := GetServerVersion(dsn, map[string]string{"ca_file": caFile})
+	if err != nil || ver != "opensearch 2.11.0" {
 		t.Fatalf("unexpected version result ver=%q err=%v", ver, err)
 	}
+	tgt := NewElasticsearchTarget(ts.URL, map[string]string{"insecure_skip_verify": "true", "api_key": "id:secret"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantBasic := "Basic " + base64.StdEncoding.EncodeToString([]byte("elastic:changeme"))
+	wantKey := "ApiKey " + base64.StdEncoding.EncodeToString([]byte("id:secret"))
+	if len(gotAuth) != 2 || gotAuth[0] != wantBasic || gotAuth[1] != wantKey {
+		t.Fatalf("unexpected Authorization headers %q", gotAuth)
+	}
+}
+
+func TestElasticsearchTarget_BulkTuningIDsAndWorkers(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	var (
+		mu       sync.Mutex
+		settings []string
+		bulks    []string
+		refresh  int
+	)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/readings/_settings":
+			_, _ = w.Write([]byte(`{"readings":{"settings":{"index":{"refresh_interval":"5s","number_of_shards":"1"}}}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/readings/_settings":
+			settings = append(settings, string(body))
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/readings/_refresh":
+			refresh++
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+			bulks = append(bulks, string(body))
+			_, _ = w.Write([]byte(`{"errors":false}`))
+		default:
+			_, _ = w.Write([]byte(`{"version":{"number":"8.12.0"}}`))
+		}
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	tgt := NewElasticsearchTarget(ts.URL, map[string]string{"bulk_workers": "3", "bulk_max_bytes": "200"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{
+		Name:        "readings",
+		TargetTable: "readings",
+		Columns:     []domain.Column{{Name: "reading_id", Type: domain.ColumnTypeBigInt}},
+		Options:     map[string]string{"id_column": "reading_id", "bulk_tuning": "true"},
+	}
+	if err := tgt.BindEntity(entity); err != nil {
+		t.Fatal(err)
+	}
+	rows := make([][]interface{}, 10)
+	for i := range rows {
+		rows[i] = []interface{}{int64(i + 1)}
+	}
+	if err := tgt.InsertBatch("readings", []string{"reading_id"}, rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bulks) < 2 {
+		t.Fatalf("expected byte-size batching to split into several requests, got %d", len(bulks))
+	}
+	docs := 0
+	for _, b := range bulks {
+		if len(b) > 200 {
+			t.Fatalf("bulk request exceeds bulk_max_bytes: %d bytes", len(b))
+		}
+		docs += strings.Count(b, "\n") / 2
+	}
+	if docs != 10 || !strings.Contains(strings.Join(bulks, ""), `"_id":"7"`) {
+		t.Fatalf("expected 10 documents with deterministic ids, got %d: %v", docs, bulks)
+	}
+	if len(settings) != 2 ||
+		settings[0] != `{"index":{"number_of_replicas":0,"refresh_interval":"-1"}}` ||
+		settings[1] != `{"index":{"number_of_replicas":null,"refresh_interval":"5s"}}` {
+		t.Fatalf("unexpected settings updates %v", settings)
+	}
+	if refresh != 1 {
+		t.Fatalf("expected a refresh after restoring settings, got %d", refresh)
+	}
+}
+
+func TestElasticsearchTarget_IndexPatternAndDataStream(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	requests := map[string]string{}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		key := r.Method + " " + r.URL.Path
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		requests[key] += string(body)
+		if r.URL.Path == "/" {
+			_, _ = w.Write([]byte(`{"version":{"number":"8.12.0"}}`))
+			return
+		}
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/_settings") {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		if r.URL.Path == "/_cat/indices/events-*.*.*" {
+			if _, loaded := requests["POST /_bulk"]; loaded {
+				_, _ = w.Write([]byte(`[{"index":"events-2026.10.16"},{"index":"events-2026.10.17"},{"index":"events-archive.v1.old"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"index":"events-2026.10.16"},{"index":"events-archive.v1.old"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"acknowledged":true}`))
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	tgt := NewElasticsearchTarget(ts.URL, nil)
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	daily := &domain.Entity{
+		Name:        "events",
+		TargetTable: "events",
+		Columns:     []domain.Column{{Name: "ts", Type: domain.ColumnTypeTimestamp}, {Name: "msg", Type: domain.ColumnTypeString}},
+		Options:     map[string]string{"index_pattern": "Events-{ts:yyyy.MM.dd}", "bulk_tuning": "true"},
+	}
+	if err := tgt.CreateTableIfNotExists(daily); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.BindEntity(daily); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.TruncateTable("events"); err != nil {
+		t.Fatal(err)
+	}
+	day1 := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+	rows := [][]interface{}{{day1, "a"}, {day1.Add(2 * time.Hour), "b"}}
+	if err := tgt.InsertBatch("events", []string{"ts", "msg"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := &domain.Entity{
+		Name:        "logs",
+		TargetTable: "logs-app-default",
+		Columns:     []domain.Column{{Name: "observed_at", Type: domain.ColumnTypeTimestamp}},
+		Options:     map[string]string{"data_stream": "true", "timestamp_column": "observed_at"},
+	}
+	if err := tgt.CreateTableIfNotExists(logs); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("logs-app-default", []string{"observed_at"}, [][]interface{}{{day1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if tpl := requests["PUT /_index_template/sdgen-events"]; !strings.Contains(tpl, `"index_patterns":["events-*.*.*"]`) || strings.Contains(tpl, "data_stream") {
+		t.Fatalf("unexpected events template %s", tpl)
+	}
+	if tpl := requests["PUT /_index_template/sdgen-events"]; !strings.Contains(tpl, `"settings":{"index":{"number_of_replicas":0,"refresh_interval":"-1"}}`) {
+		t.Fatalf("expected bulk tuning in the events template, got %s", tpl)
+	}
+	if tpl := requests["PUT /_index_template/sdgen-logs-app-default"]; !strings.Contains(tpl, `"data_stream":{}`) || !strings.Contains(tpl, `"@timestamp":{"type":"date"}`) {
+		t.Fatalf("unexpected data stream template %s", tpl)
+	}
+	for key := range requests {
+		if strings.HasPrefix(key, "PUT /") && strings.HasSuffix(key, "/_settings") && key != "PUT /events-2026.10.16/_settings" {
+			t.Fatalf("bulk tuning must only touch concrete pattern indices, got %s", key)
+		}
+	}
+	if _, ok := requests["PUT /events-2026.10.16/_settings"]; !ok {
+		t.Fatalf("expected bulk tuning of the existing pattern index, got %v", requests)
+	}
+	var truncates []string
+	for key := range requests {
+		if strings.Contains(key, "_delete_by_query") {
+			truncates = append(truncates, key)
+		}
+	}
+	if len(truncates) != 1 || truncates[0] != "POST /events-2026.10.16/_delete_by_query?allow_no_indices=true&ignore_unavailable=true" {
+		t.Fatalf("expected truncate of the concrete pattern index only, got %v", truncates)
+	}
+	bulk := requests["POST /_bulk"]
+	for _, want := range []string{
+		`{"index":{"_index":"events-2026.10.17"}}`,
+		`{"index":{"_index":"events-2026.10.18"}}`,
+		`{"create":{"_index":"logs-app-default"}}`,
+		`"@timestamp":"2026-10-17T23:00:00Z"`,
+	} {
+		if !strings.Contains(bulk, want) {
+			t.Fatalf("bulk payload missing %s: %s", want, bulk)
+		}
+	}
+
+	delete(requests, "PUT /_index_template/sdgen-events")
+	if err := tgt.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if tpl := requests["PUT /_index_template/sdgen-events"]; tpl == "" || strings.Contains(tpl, "refresh_interval") {
+		t.Fatalf("expected the events template to be restored without bulk tuning, got %q", tpl)
+	}
+	if got := requests["PUT /events-2026.10.17/_settings"]; got != `{"index":{"number_of_replicas":null,"refresh_interval":null}}` {
+		t.Fatalf("expected the index created during the load to be restored, got %q", got)
+	}
+	if _, ok := requests["POST /events-2026.10.17/_refresh"]; !ok {
+		t.Fatalf("expected the index created during the load to be refreshed, got %v", requests)
+	}
+
+	bad := &domain.Entity{Name: "bad", TargetTable: "bad", Columns: []domain.Column{{Name: "msg", Type: domain.ColumnTypeString}},
+		Options: map[string]string{"index_pattern": "bad-{msg}"}}
+	if err := tgt.CreateTableIfNotExists(bad); err == nil || !strings.Contains(err.Error(), "must be a timestamp or date column") {
+		t.Fatalf("expected non-time pattern column to be rejected, got %v", err)
+	}
 }
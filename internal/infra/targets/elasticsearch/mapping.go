@@ -0,0 +1,156 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// indexSettingKeys are the options copied into the index settings on create.
+var indexSettingKeys = []string{"number_of_shards", "number_of_replicas", "refresh_interval"}
+
+// buildIndexBody returns the create-index body with explicit mappings derived
+// from column types, per-column "mapping.<column>" overrides and settings.
+func (t *ElasticsearchTarget) buildIndexBody(entity *domain.Entity) (map[string]any, error) {
+	properties, err := t.buildProperties(entity)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]any{
+		"mappings": map[string]any{"properties": properties},
+	}
+	settings := map[string]any{}
+	for _, key := range indexSettingKeys {
+		if v := t.option(entity, key); v != "" {
+			settings[key] = v
+		}
+	}
+	if len(settings) > 0 {
+		body["settings"] = map[string]any{"index": settings}
+	}
+	return body, nil
+}
+
+func (t *ElasticsearchTarget) buildProperties(entity *domain.Entity) (map[string]map[string]any, error) {
+	properties := make(map[string]map[string]any, len(entity.Columns))
+	for _, col := range entity.Columns {
+		prop := map[string]any{"type": mapColumnType(col.Type)}
+		if override := t.option(entity, "mapping."+col.Name); override != "" {
+			p, err := parseMappingOverride(override)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mapping override for column %s: %w", col.Name, err)
+			}
+			prop = p
+		}
+		properties[col.Name] = prop
+	}
+	return properties, nil
+}
+
+// parseMappingOverride accepts either a bare field type ("text", "ip") or a
+// full JSON field mapping ({"type":"text","analyzer":"english"}).
+func parseMappingOverride(v string) (map[string]any, error) {
+	if !strings.HasPrefix(v, "{") {
+		return map[string]any{"type": v}, nil
+	}
+	var prop map[string]any
+	if err := json.Unmarshal([]byte(v), &prop); err != nil {
+		return nil, err
+	}
+	if _, ok := prop["type"].(string); !ok {
+		return nil, fmt.Errorf("mapping must set a string type")
+	}
+	return prop, nil
+}
+
+func mapColumnType(colType domain.ColumnType) string {
+	switch colType {
+	case domain.ColumnTypeInt:
+		return "integer"
+	case domain.ColumnTypeBigInt:
+		return "long"
+	case domain.ColumnTypeFloat:
+		return "float"
+	case domain.ColumnTypeDouble:
+		return "double"
+	case domain.ColumnTypeString, domain.ColumnTypeUUID:
+		return "keyword"
+	case domain.ColumnTypeText:
+		return "text"
+	case domain.ColumnTypeBool:
+		return "boolean"
+	case domain.ColumnTypeTimestamp, domain.ColumnTypeDate:
+		return "date"
+	default:
+		return "keyword"
+	}
+}
+
+// validateExistingMapping checks every column against the mapping of an
+// existing index, mirroring the postgres existing-table check.
+func (t *ElasticsearchTarget) validateExistingMapping(entity *domain.Entity, indexName string, existing map[string]string) error {
+	expected, err := t.buildProperties(entity)
+	if err != nil {
+		return err
+	}
+	for _, col := range entity.Columns {
+		got, ok := existing[col.Name]
+		if !ok {
+			return fmt.Errorf("existing index %s missing field %s", indexName, col.Name)
+		}
+		want := expected[col.Name]["type"].(string)
+		if !esTypeCompatible(want, got) {
+			return fmt.Errorf("existing index %s field %s type mismatch: expected %s, got %s", indexName, col.Name, want, got)
+		}
+	}
+	return nil
+}
+
+func esTypeCompatible(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	switch expected {
+	case "integer":
+		return actual == "long"
+	case "float":
+		return actual == "double" || actual == "half_float" || actual == "scaled_float"
+	case "keyword":
+		return actual == "constant_keyword" || actual == "wildcard"
+	case "text":
+		return actual == "match_only_text"
+	case "date":
+		return actual == "date_nanos"
+	default:
+		return false
+	}
+}
+
+// parseMappingResponse flattens GET /<index>/_mapping into field -> type for
+// top-level properties. Responses for aliases may hold several indices; they
+// are merged.
+func parseMappingResponse(body []byte) (map[string]string, error) {
+	var resp map[string]struct {
+		Mappings struct {
+			Properties map[string]struct {
+				Type string `json:"type"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	for _, idx := range resp {
+		for name, p := range idx.Mappings.Properties {
+			typ := p.Type
+			if typ == "" {
+				typ = "object"
+			}
+			fields[name] = typ
+		}
+	}
+	return fields, nil
+}
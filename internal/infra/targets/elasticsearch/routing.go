@@ -0,0 +1,337 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// indexRoute decides which index each row of an entity is written to.
+//
+// Entity options:
+//   - index_pattern: index name with {column:format} placeholders formatted
+//     from a timestamp/date column in UTC, e.g. "events-{ts:yyyy.MM.dd}";
+//     format tokens are yyyy, yy, MM, dd, HH and mm (default yyyy.MM.dd)
+//   - data_stream: "true" writes into a data stream named after the table
+//   - timestamp_column: column copied to @timestamp for data streams
+//     (default: a column named "@timestamp")
+//   - op_type: "index" (default) or "create"; data streams always use create
+type indexRoute struct {
+	name       string
+	parts      []routePart
+	dataStream bool
+	opType     string
+	timestamp  string
+}
+
+type routePart struct {
+	literal string
+	column  string
+	layout  string
+}
+
+func (t *ElasticsearchTarget) buildRoute(entity *domain.Entity) (*indexRoute, error) {
+	r := &indexRoute{
+		name:       toIndexName(entity.TargetTable),
+		dataStream: t.option(entity, "data_stream") == "true",
+		opType:     t.option(entity, "op_type"),
+	}
+	if r.opType == "" {
+		r.opType = "index"
+	}
+	if r.opType != "index" && r.opType != "create" {
+		return nil, fmt.Errorf("elasticsearch op_type must be index or create: %s", r.opType)
+	}
+	pattern := t.option(entity, "index_pattern")
+	if r.dataStream {
+		if pattern != "" {
+			return nil, fmt.Errorf("entity %s: index_pattern cannot be combined with data_stream", entity.Name)
+		}
+		r.opType = "create"
+		r.timestamp = t.option(entity, "timestamp_column")
+		if r.timestamp == "" {
+			r.timestamp = "@timestamp"
+		}
+		if !hasTimeColumn(entity, r.timestamp) {
+			return nil, fmt.Errorf("entity %s: data stream requires timestamp_column to name a timestamp or date column", entity.Name)
+		}
+	}
+	if pattern == "" {
+		return r, nil
+	}
+	parts, err := parseIndexPattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("entity %s: invalid index_pattern: %w", entity.Name, err)
+	}
+	for _, p := range parts {
+		if p.column != "" && !hasTimeColumn(entity, p.column) {
+			return nil, fmt.Errorf("entity %s: index_pattern column %s must be a timestamp or date column", entity.Name, p.column)
+		}
+	}
+	r.parts = parts
+	return r, nil
+}
+
+func hasTimeColumn(entity *domain.Entity, name string) bool {
+	for _, col := range entity.Columns {
+		if col.Name == name {
+			return col.Type == domain.ColumnTypeTimestamp || col.Type == domain.ColumnTypeDate
+		}
+	}
+	return false
+}
+
+// parseIndexPattern splits "events-{ts:yyyy.MM.dd}" into literal and column parts.
+func parseIndexPattern(pattern string) ([]routePart, error) {
+	var parts []routePart
+	rest := pattern
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if strings.ContainsRune(rest, '}') {
+				return nil, fmt.Errorf("unbalanced braces in %q", pattern)
+			}
+			parts = append(parts, routePart{literal: strings.ToLower(rest)})
+			break
+		}
+		if open > 0 {
+			if strings.ContainsRune(rest[:open], '}') {
+				return nil, fmt.Errorf("unbalanced braces in %q", pattern)
+			}
+			parts = append(parts, routePart{literal: strings.ToLower(rest[:open])})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unbalanced braces in %q", pattern)
+		}
+		column, format, _ := strings.Cut(rest[open+1:open+end], ":")
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return nil, fmt.Errorf("empty placeholder in %q", pattern)
+		}
+		if format == "" {
+			format = "yyyy.MM.dd"
+		}
+		layout, err := dateLayout(format)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, routePart{column: column, layout: layout})
+		rest = rest[open+end+1:]
+	}
+	hasColumn := false
+	for _, p := range parts {
+		if p.column != "" {
+			hasColumn = true
+		}
+	}
+	if !hasColumn {
+		return nil, fmt.Errorf("%q has no {column:format} placeholder", pattern)
+	}
+	return parts, nil
+}
+
+var dateTokens = []struct{ token, layout string }{
+	{"yyyy", "2006"}, {"yy", "06"}, {"MM", "01"}, {"dd", "02"}, {"HH", "15"}, {"mm", "04"},
+}
+
+// dateLayout converts a yyyy.MM.dd style format into a Go time layout.
+func dateLayout(format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		matched := false
+		for _, tok := range dateTokens {
+			if strings.HasPrefix(format[i:], tok.token) {
+				b.WriteString(tok.layout)
+				i += len(tok.token)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		c := format[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return "", fmt.Errorf("unsupported date format %q", format)
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), nil
+}
+
+// glob returns the wildcard expression matching every index of the route.
+// Date placeholders keep their separators (events-{ts:yyyy.MM.dd} gives
+// events-*.*.*) so the expression stays as narrow as index wildcards allow.
+func (r *indexRoute) glob() string {
+	if len(r.parts) == 0 {
+		return r.name
+	}
+	var b strings.Builder
+	for _, p := range r.parts {
+		if p.column == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		for _, c := range p.layout {
+			if c >= '0' && c <= '9' {
+				if !strings.HasSuffix(b.String(), "*") {
+					b.WriteByte('*')
+				}
+				continue
+			}
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// matcher matches the names of the indices the route writes to: the
+// literals verbatim and each date token as digits.
+func (r *indexRoute) matcher() *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, p := range r.parts {
+		if p.column == "" {
+			b.WriteString(regexp.QuoteMeta(p.literal))
+			continue
+		}
+		for _, c := range p.layout {
+			if c >= '0' && c <= '9' {
+				b.WriteString(`\d`)
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// concreteIndices lists the existing indices the route writes to. Wildcard
+// matches that do not fit the pattern, such as events-archive for
+// events-{ts:yyyy.MM.dd}, are left out.
+func (t *ElasticsearchTarget) concreteIndices(r *indexRoute) ([]string, error) {
+	if len(r.parts) == 0 {
+		return []string{r.name}, nil
+	}
+	status, body, err := t.do(http.MethodGet, "/_cat/indices/"+r.glob()+"?format=json&h=index", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status < 200 || status > 299 {
+		return nil, fmt.Errorf("elasticsearch list indices failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	var rows []struct {
+		Index string `json:"index"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("elasticsearch list indices response invalid: %w", err)
+	}
+	re := r.matcher()
+	var out []string
+	for _, row := range rows {
+		if re.MatchString(row.Index) {
+			out = append(out, row.Index)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// indexFor resolves the index of a row; colIdx maps column names to positions.
+func (r *indexRoute) indexFor(row []interface{}, colIdx map[string]int) (string, error) {
+	if len(r.parts) == 0 {
+		return r.name, nil
+	}
+	var b strings.Builder
+	for _, p := range r.parts {
+		if p.column == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		i, ok := colIdx[p.column]
+		if !ok {
+			return "", fmt.Errorf("index_pattern column %s not found", p.column)
+		}
+		ts, err := toTime(row[i])
+		if err != nil {
+			return "", fmt.Errorf("index_pattern column %s: %w", p.column, err)
+		}
+		b.WriteString(ts.UTC().Format(p.layout))
+	}
+	return b.String(), nil
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if ts, err := time.Parse(layout, x); err == nil {
+				return ts, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as time", x)
+	case nil:
+		return time.Time{}, fmt.Errorf("value is null")
+	default:
+		return time.Time{}, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// putIndexTemplate creates or replaces the template "sdgen-<table>" so every
+// index of a pattern, or the data stream, gets the entity's mappings and settings.
+// overrides replace index settings, e.g. the bulk_tuning ones during a load.
+func (t *ElasticsearchTarget) putIndexTemplate(entity *domain.Entity, r *indexRoute, overrides map[string]any) error {
+	body, err := t.buildIndexBody(entity)
+	if err != nil {
+		return err
+	}
+	if len(overrides) > 0 {
+		settings, _ := body["settings"].(map[string]any)
+		if settings == nil {
+			settings = map[string]any{"index": map[string]any{}}
+			body["settings"] = settings
+		}
+		maps.Copy(settings["index"].(map[string]any), overrides)
+	}
+	if r.dataStream {
+		props := body["mappings"].(map[string]any)["properties"].(map[string]map[string]any)
+		if _, ok := props["@timestamp"]; !ok {
+			props["@timestamp"] = map[string]any{"type": "date"}
+		}
+	}
+	template := map[string]any{
+		"index_patterns": []string{r.glob()},
+		"priority":       200,
+		"template":       body,
+	}
+	if r.dataStream {
+		template["data_stream"] = map[string]any{}
+	}
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	status, resp, err := t.do(http.MethodPut, "/_index_template/sdgen-"+r.name, "application/json", data)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("elasticsearch put index template failed: status=%d body=%s", status, strings.TrimSpace(string(resp)))
+	}
+	return nil
+}
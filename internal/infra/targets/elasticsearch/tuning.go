@@ -0,0 +1,205 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// tunedIndex remembers the settings bulk_tuning replaced. Nil values were not
+// set explicitly and are reset to the cluster default on restore.
+type tunedIndex struct {
+	name             string
+	refreshInterval  any
+	numberOfReplicas any
+}
+
+// tuneForLoad disables refresh and replicas on an existing index. Indices
+// that do not exist yet (append into a missing index) are left alone.
+func (t *ElasticsearchTarget) tuneForLoad(indexName string) error {
+	for _, ti := range t.tuned {
+		if ti.name == indexName {
+			return nil
+		}
+	}
+	status, body, err := t.do(http.MethodGet, "/"+indexName+"/_settings", "", nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return nil
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("elasticsearch get settings failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	prev, err := parseSettingsResponse(body)
+	if err != nil {
+		return err
+	}
+	if err := t.putSettings(indexName, loadSettings); err != nil {
+		return err
+	}
+	prev.name = indexName
+	t.tuned = append(t.tuned, prev)
+	return nil
+}
+
+// loadSettings are the index settings bulk_tuning applies during a load.
+var loadSettings = map[string]any{"refresh_interval": "-1", "number_of_replicas": 0}
+
+// tuneTemplate puts the load settings into the sdgen-<table> template of an
+// index pattern or data stream, so indices created during the load start tuned.
+func (t *ElasticsearchTarget) tuneTemplate(entity *domain.Entity, r *indexRoute) error {
+	if !r.dataStream && len(r.parts) == 0 {
+		return nil
+	}
+	if err := t.putIndexTemplate(entity, r, loadSettings); err != nil {
+		return err
+	}
+	t.tunedTemplates = append(t.tunedTemplates, r)
+	return nil
+}
+
+// restoreTemplates puts back the templates changed by tuneTemplate and gives
+// indices created from them during the load the entity's configured settings.
+func (t *ElasticsearchTarget) restoreTemplates() error {
+	tuned := make(map[string]bool, len(t.tuned))
+	for _, ti := range t.tuned {
+		tuned[ti.name] = true
+	}
+	var firstErr error
+	for _, r := range t.tunedTemplates {
+		entity := t.entities[r.name]
+		err := t.putIndexTemplate(entity, r, nil)
+		if err == nil {
+			var indices []string
+			indices, err = t.concreteIndices(r)
+			for _, name := range indices {
+				if err != nil {
+					break
+				}
+				if tuned[name] {
+					continue
+				}
+				err = t.restoreCreated(entity, name)
+			}
+		}
+		if err != nil {
+			if t.log != nil {
+				t.log("warn", fmt.Sprintf("elasticsearch failed to restore template sdgen-%s: %v", r.name, err))
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	t.tunedTemplates = nil
+	return firstErr
+}
+
+// restoreCreated sets an index created during the load to the configured
+// settings, or the cluster defaults, and refreshes it. A data stream that
+// never received a row does not exist and is skipped.
+func (t *ElasticsearchTarget) restoreCreated(entity *domain.Entity, indexName string) error {
+	settings := map[string]any{"refresh_interval": nil, "number_of_replicas": nil}
+	for key := range settings {
+		if v := t.option(entity, key); v != "" {
+			settings[key] = v
+		}
+	}
+	data, err := json.Marshal(map[string]any{"index": settings})
+	if err != nil {
+		return err
+	}
+	status, body, err := t.do(http.MethodPut, "/"+indexName+"/_settings", "application/json", data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return nil
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("elasticsearch update settings failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	return t.refresh(indexName)
+}
+
+// restoreSettings puts back the settings replaced by tuneForLoad and
+// tuneTemplate and refreshes each index so loaded documents are searchable.
+func (t *ElasticsearchTarget) restoreSettings() error {
+	firstErr := t.restoreTemplates()
+	for _, ti := range t.tuned {
+		err := t.putSettings(ti.name, map[string]any{
+			"refresh_interval":   ti.refreshInterval,
+			"number_of_replicas": ti.numberOfReplicas,
+		})
+		if err == nil {
+			err = t.refresh(ti.name)
+		}
+		if err != nil {
+			if t.log != nil {
+				t.log("warn", fmt.Sprintf("elasticsearch failed to restore settings of %s: %v", ti.name, err))
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	t.tuned = nil
+	return firstErr
+}
+
+func (t *ElasticsearchTarget) putSettings(indexName string, settings map[string]any) error {
+	data, err := json.Marshal(map[string]any{"index": settings})
+	if err != nil {
+		return err
+	}
+	status, body, err := t.do(http.MethodPut, "/"+indexName+"/_settings", "application/json", data)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("elasticsearch update settings failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (t *ElasticsearchTarget) refresh(indexName string) error {
+	status, body, err := t.do(http.MethodPost, "/"+indexName+"/_refresh", "", nil)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("elasticsearch refresh failed: status=%d body=%s", status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// parseSettingsResponse reads refresh_interval and number_of_replicas from
+// GET /<index>/_settings.
+func parseSettingsResponse(body []byte) (tunedIndex, error) {
+	var resp map[string]struct {
+		Settings struct {
+			Index struct {
+				RefreshInterval  *string `json:"refresh_interval"`
+				NumberOfReplicas *string `json:"number_of_replicas"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return tunedIndex{}, fmt.Errorf("elasticsearch settings response invalid: %w", err)
+	}
+	var ti tunedIndex
+	for _, idx := range resp {
+		if v := idx.Settings.Index.RefreshInterval; v != nil {
+			ti.refreshInterval = *v
+		}
+		if v := idx.Settings.Index.NumberOfReplicas; v != nil {
+			ti.numberOfReplicas = *v
+		}
+	}
+	return ti, nil
+}
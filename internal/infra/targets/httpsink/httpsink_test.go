@@ -0,0 +1,244 @@
+package httpsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPTarget_RowModeRetriesAndCapturesIDs(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		nextID   = 100
+		received []map[string]any
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/api/users" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "u" || pass != "p" {
+			t.Errorf("expected basic auth, got %q %q %v", user, pass, ok)
+		}
+		if r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("expected custom header, got %q", r.Header.Get("X-Tenant"))
+		}
+		attempts++
+		switch attempts {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var doc map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			t.Error(err)
+		}
+		received = append(received, doc)
+		nextID++
+		fmt.Fprintf(w, `{"data":{"id":%d}}`, nextID)
+	})
+
+	tgt := NewHTTPTarget("http://u:p@"+srv.Listener.Addr().String()+"/api", map[string]string{
+		"header.X-Tenant":  "acme",
+		"retry_backoff_ms": "1",
+		"concurrency":      "1",
+	})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{
+		Name:        "users",
+		TargetTable: "users",
+		Columns: []domain.Column{
+			{Name: "id", Type: domain.ColumnTypeBigInt},
+			{Name: "email", Type: domain.ColumnTypeString},
+		},
+		Options: map[string]string{"id_column": "id", "id_field": "data.id"},
+	}
+	if err := tgt.CreateTableIfNotExists(entity); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]interface{}{{int64(1), "a@x"}, {int64(2), "b@x"}}
+	if err := tgt.InsertBatch("users", []string{"id", "email"}, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 4 {
+		t.Fatalf("expected 4 attempts (2 retried), got %d", attempts)
+	}
+	for _, doc := range received {
+		if _, ok := doc["id"]; ok {
+			t.Fatalf("id column must not be sent, got %v", doc)
+		}
+	}
+	if rows[0][0] != int64(101) || rows[1][0] != int64(102) {
+		t.Fatalf("expected server-assigned ids in rows, got %v", rows)
+	}
+}
+
+func TestHTTPTarget_BatchModeAndClientErrors(t *testing.T) {
+	var calls int
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		var docs []map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&docs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte("invalid"))
+			return
+		}
+		out := make([]map[string]any, len(docs))
+		for i := range docs {
+			out[i] = map[string]any{"id": fmt.Sprintf("uuid-%d", i)}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+
+	tgt := NewHTTPTarget(srv.URL, map[string]string{"batch": "true", "bearer_token": "tok", "retry_backoff_ms": "1"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{
+		Name:        "orders",
+		TargetTable: "orders",
+		Columns:     []domain.Column{{Name: "id", Type: domain.ColumnTypeUUID}, {Name: "total", Type: domain.ColumnTypeDouble}},
+		Options:     map[string]string{"id_column": "id"},
+	}
+	if err := tgt.BindEntity(entity); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]interface{}{{"", 1.0}, {"", 2.0}}
+	if err := tgt.InsertBatch("orders", []string{"id", "total"}, rows); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || rows[1][0] != "uuid-1" {
+		t.Fatalf("expected one batch request with ids captured, got calls=%d rows=%v", calls, rows)
+	}
+
+	bad := &domain.Entity{Name: "bad", TargetTable: "bad", Options: map[string]string{"path": "/{entity}"}}
+	if err := tgt.BindEntity(bad); err != nil {
+		t.Fatal(err)
+	}
+	calls = 0
+	if err := tgt.InsertBatch("bad", []string{"x"}, [][]interface{}{{1}}); err == nil {
+		t.Fatal("expected 422 to fail the batch")
+	}
+	if calls != 1 {
+		t.Fatalf("4xx responses must not be retried, got %d calls", calls)
+	}
+}
+
+func TestHTTPTarget_TransportErrorsRetriedOnlyWhenEnabled(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// Accept the request, then drop the connection before responding.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	})
+
+	for _, tc := range []struct {
+		options map[string]string
+		want    int
+	}{
+		{map[string]string{"max_retries": "2", "retry_backoff_ms": "1"}, 1},
+		{map[string]string{"max_retries": "2", "retry_backoff_ms": "1", "retry_transport_errors": "true"}, 3},
+	} {
+		calls = 0
+		tgt := NewHTTPTarget(srv.URL, tc.options)
+		if err := tgt.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if err := tgt.InsertBatch("events", []string{"x"}, [][]interface{}{{1}}); err == nil {
+			t.Fatal("expected dropped connection to fail the insert")
+		}
+		if calls != tc.want {
+			t.Fatalf("options %v: expected %d requests, got %d", tc.options, tc.want, calls)
+		}
+	}
+
+	if err := NewHTTPTarget(srv.URL, nil).TruncateTable("events"); err == nil {
+		t.Fatal("expected truncate to be unsupported")
+	}
+}
+
+func TestHTTPTarget_RetryAfterIsCapped(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tgt := NewHTTPTarget(srv.URL, map[string]string{"max_backoff_ms": "10"})
+	var logs []string
+	tgt.SetRunLog(func(level, message string) { logs = append(logs, level+": "+message) })
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	entity := &domain.Entity{Name: "users", TargetTable: "users", Columns: []domain.Column{{Name: "email", Type: domain.ColumnTypeString}}}
+	if err := tgt.BindEntity(entity); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := tgt.InsertBatch("users", []string{"email"}, [][]interface{}{{"a@x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Retry-After was not capped, waited %s", elapsed)
+	}
+	if attempts != 2 || len(logs) != 1 || !strings.Contains(logs[0], "Retry-After 24h0m0s shortened to max_backoff_ms 10ms") {
+		t.Fatalf("expected one capped retry to be logged, got attempts=%d logs=%v", attempts, logs)
+	}
+}
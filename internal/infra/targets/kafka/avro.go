@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// avroSchema is a record schema derived from an entity's columns together
+// with a minimal binary encoder for the primitive/logical types sdgen emits.
+type avroSchema struct {
+	json   []byte
+	names  []string // field names in schema order
+	fields map[string]avroField
+}
+
+type avroField struct {
+	typ      string // int, long, float, double, boolean, string
+	logical  string // timestamp-millis, date, uuid
+	nullable bool
+}
+
+func newAvroSchema(entity *domain.Entity) *avroSchema {
+	s := &avroSchema{fields: make(map[string]avroField, len(entity.Columns))}
+	fields := make([]map[string]any, 0, len(entity.Columns))
+	for _, col := range entity.Columns {
+		f := mapAvroField(col.Type)
+		f.nullable = col.Nullable
+		s.fields[col.Name] = f
+		s.names = append(s.names, col.Name)
+
+		var typ any = f.typ
+		if f.logical != "" {
+			typ = map[string]string{"type": f.typ, "logicalType": f.logical}
+		}
+		field := map[string]any{"name": col.Name, "type": typ}
+		if f.nullable {
+			field["type"] = []any{"null", typ}
+			field["default"] = nil
+		}
+		fields = append(fields, field)
+	}
+	s.json, _ = json.Marshal(map[string]any{
+		"type":   "record",
+		"name":   entity.Name,
+		"fields": fields,
+	})
+	return s
+}
+
+func mapAvroField(colType domain.ColumnType) avroField {
+	switch colType {
+	case domain.ColumnTypeInt:
+		return avroField{typ: "int"}
+	case domain.ColumnTypeBigInt:
+		return avroField{typ: "long"}
+	case domain.ColumnTypeFloat:
+		return avroField{typ: "float"}
+	case domain.ColumnTypeDouble:
+		return avroField{typ: "double"}
+	case domain.ColumnTypeBool:
+		return avroField{typ: "boolean"}
+	case domain.ColumnTypeTimestamp:
+		return avroField{typ: "long", logical: "timestamp-millis"}
+	case domain.ColumnTypeDate:
+		return avroField{typ: "int", logical: "date"}
+	case domain.ColumnTypeUUID:
+		return avroField{typ: "string", logical: "uuid"}
+	default:
+		return avroField{typ: "string"}
+	}
+}
+
+// encode writes the row in schema field order, whatever the order of
+// columns; columns must cover every field and nothing else.
+func (s *avroSchema) encode(columns []string, row []interface{}) ([]byte, error) {
+	idx := make(map[string]int, len(columns))
+	for i, col := range columns {
+		if _, ok := s.fields[col]; !ok {
+			return nil, fmt.Errorf("column %s is not part of the avro schema", col)
+		}
+		idx[col] = i
+	}
+	var buf bytes.Buffer
+	for _, col := range s.names {
+		f := s.fields[col]
+		i, ok := idx[col]
+		if !ok {
+			return nil, fmt.Errorf("avro field %s has no column", col)
+		}
+		v := row[i]
+		if f.nullable {
+			if v == nil {
+				writeLong(&buf, 0)
+				continue
+			}
+			writeLong(&buf, 1)
+		} else if v == nil {
+			return nil, fmt.Errorf("column %s: nil value for non-nullable field", col)
+		}
+		if err := f.write(&buf, v); err != nil {
+			return nil, fmt.Errorf("column %s: %w", col, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (f avroField) write(buf *bytes.Buffer, v interface{}) error {
+	switch f.logical {
+	case "timestamp-millis":
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time value, got %T", v)
+		}
+		writeLong(buf, ts.UnixMilli())
+		return nil
+	case "date":
+		ts, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("expected time value, got %T", v)
+		}
+		writeLong(buf, ts.UTC().Unix()/86400)
+		return nil
+	}
+
+	switch f.typ {
+	case "int", "long":
+		n, ok := toInt64(v)
+		if !ok {
+			return fmt.Errorf("expected integer value, got %T", v)
+		}
+		writeLong(buf, n)
+	case "float":
+		x, ok := toFloat64(v)
+		if !ok {
+			return fmt.Errorf("expected numeric value, got %T", v)
+		}
+		return binary.Write(buf, binary.LittleEndian, math.Float32bits(float32(x)))
+	case "double":
+		x, ok := toFloat64(v)
+		if !ok {
+			return fmt.Errorf("expected numeric value, got %T", v)
+		}
+		return binary.Write(buf, binary.LittleEndian, math.Float64bits(x))
+	case "boolean":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool value, got %T", v)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	default:
+		s := fmt.Sprint(v)
+		writeLong(buf, int64(len(s)))
+		buf.WriteString(s)
+	}
+	return nil
+}
+
+// writeLong writes a zig-zag encoded variable-length integer.
+func writeLong(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	l := binary.PutVarint(tmp[:], n)
+	buf.Write(tmp[:l])
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case float64:
+		return int64(val), true
+	default:
+		return 0, false
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,350 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/connstring"
+)
+
+// MongoTarget writes each row as a document into a collection per entity.
+//
+// Supported options (collection, id_column, embed_in and embed_field are
+// per-entity options):
+//   - collection: collection name (default: target_table)
+//   - id_column: column stored as the document _id
+//   - truncate_mode: delete (default, keeps indexes) or drop
+//   - embed_in: parent entity name; rows are pushed into the parent document
+//     matching the child's fk column instead of a separate collection
+//   - embed_field: array field on the parent (default: entity name)
+//
+// The database is taken from the target database or the DSN path.
+type MongoTarget struct {
+	dsn      string
+	database string
+	options  map[string]string
+	client   *mongo.Client
+	db       *mongo.Database
+	bound    map[string]*boundEntity
+	byName   map[string]*boundEntity
+}
+
+type boundEntity struct {
+	collection string
+	idColumn   string
+	embed      *embedding
+}
+
+// embedding describes where rows of an embedded child entity go.
+type embedding struct {
+	parentCollection string
+	parentKey        string // field matched on the parent document
+	fkColumn         string // child column holding the parent key
+	field            string // array field on the parent document
+}
+
+func NewMongoTarget(dsn, database string, options map[string]string) *MongoTarget {
+	return &MongoTarget{
+		dsn:      dsn,
+		database: database,
+		options:  options,
+		bound:    map[string]*boundEntity{},
+		byName:   map[string]*boundEntity{},
+	}
+}
+
+func (t *MongoTarget) Connect() error {
+	database, err := resolveDatabase(t.dsn, t.database)
+	if err != nil {
+		return err
+	}
+	client, err := mongo.Connect(options.Client().ApplyURI(t.dsn))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return fmt.Errorf("mongodb ping failed: %w", err)
+	}
+	t.client = client
+	t.db = client.Database(database)
+	return nil
+}
+
+func (t *MongoTarget) Close() error {
+	if t.client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return t.client.Disconnect(ctx)
+}
+
+func (t *MongoTarget) CreateTableIfNotExists(entity *domain.Entity) error {
+	if err := t.BindEntity(entity); err != nil {
+		return err
+	}
+	b := t.byName[entity.Name]
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if b.embed != nil {
+		if b.embed.parentKey == "_id" {
+			return nil
+		}
+		_, err := t.db.Collection(b.embed.parentCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: b.embed.parentKey, Value: 1}},
+		})
+		if err != nil {
+			return fmt.Errorf("mongodb create index failed: %w", err)
+		}
+		return nil
+	}
+
+	names, err := t.db.ListCollectionNames(ctx, bson.D{{Key: "name", Value: b.collection}})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+	if err := t.db.CreateCollection(ctx, b.collection); err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == 48 { // NamespaceExists
+			return nil
+		}
+		return fmt.Errorf("mongodb create collection failed: %w", err)
+	}
+	return nil
+}
+
+func (t *MongoTarget) TruncateTable(tableName string) error {
+	b := t.lookup(tableName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if b.embed != nil {
+		_, err := t.db.Collection(b.embed.parentCollection).UpdateMany(ctx, bson.D{},
+			bson.D{{Key: "$unset", Value: bson.D{{Key: b.embed.field, Value: ""}}}})
+		if err != nil {
+			return fmt.Errorf("mongodb truncate failed: %w", err)
+		}
+		return nil
+	}
+
+	coll := t.db.Collection(b.collection)
+	switch strings.ToLower(t.options["truncate_mode"]) {
+	case "", "delete":
+		if _, err := coll.DeleteMany(ctx, bson.D{}); err != nil {
+			return fmt.Errorf("mongodb truncate failed: %w", err)
+		}
+	case "drop":
+		if err := coll.Drop(ctx); err != nil {
+			return fmt.Errorf("mongodb truncate failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported mongodb truncate_mode: %s", t.options["truncate_mode"])
+	}
+	return nil
+}
+
+// BindEntity records the collection, _id column and embedding for an entity.
+// Embedded entities must be bound after their parent.
+func (t *MongoTarget) BindEntity(entity *domain.Entity) error {
+	b := &boundEntity{
+		collection: option(entity, "collection"),
+		idColumn:   option(entity, "id_column"),
+	}
+	if b.collection == "" {
+		b.collection = entity.TargetTable
+	}
+	if b.idColumn != "" && !hasColumn(entity, b.idColumn) {
+		return fmt.Errorf("id_column %q is not a column of entity %s", b.idColumn, entity.Name)
+	}
+
+	if parentName := option(entity, "embed_in"); parentName != "" {
+		parent, ok := t.byName[parentName]
+		if !ok {
+			return fmt.Errorf("embed_in entity %s is not bound; it must be generated before %s", parentName, entity.Name)
+		}
+		if parent.embed != nil {
+			return fmt.Errorf("embed_in entity %s is itself embedded; nested embedding is not supported", parentName)
+		}
+		e := &embedding{parentCollection: parent.collection, field: option(entity, "embed_field")}
+		if e.field == "" {
+			e.field = entity.Name
+		}
+		for _, col := range entity.Columns {
+			if col.Generator.Type != "fk" {
+				continue
+			}
+			if ref, _ := col.Generator.Params["entity"].(string); ref == parentName {
+				e.fkColumn = col.Name
+				e.parentKey, _ = col.Generator.Params["column"].(string)
+				break
+			}
+		}
+		if e.fkColumn == "" {
+			return fmt.Errorf("entity %s is embedded in %s but has no fk column referencing it", entity.Name, parentName)
+		}
+		if e.parentKey == parent.idColumn {
+			e.parentKey = "_id"
+		}
+		b.embed = e
+	}
+
+	t.bound[entity.TargetTable] = b
+	t.byName[entity.Name] = b
+	return nil
+}
+
+func (t *MongoTarget) InsertBatch(tableName string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	b := t.lookup(tableName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if b.embed != nil {
+		groups, err := groupEmbedded(b.embed, columns, rows)
+		if err != nil {
+			return err
+		}
+		models := make([]mongo.WriteModel, 0, len(groups))
+		for _, g := range groups {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{Key: b.embed.parentKey, Value: g.key}}).
+				SetUpdate(bson.D{{Key: "$push", Value: bson.D{{Key: b.embed.field, Value: bson.D{{Key: "$each", Value: g.docs}}}}}}))
+		}
+		res, err := t.db.Collection(b.embed.parentCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("mongodb embed failed: %w", err)
+		}
+		if res.MatchedCount < int64(len(models)) {
+			return fmt.Errorf("mongodb embed failed: %d of %d parent documents not found in %s", int64(len(models))-res.MatchedCount, len(models), b.embed.parentCollection)
+		}
+		return nil
+	}
+
+	docs := make([]interface{}, len(rows))
+	for i, row := range rows {
+		docs[i] = toDocument(columns, row, b.idColumn, "")
+	}
+	if _, err := t.db.Collection(b.collection).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false)); err != nil {
+		return fmt.Errorf("mongodb insert failed: %w", err)
+	}
+	return nil
+}
+
+func (t *MongoTarget) lookup(tableName string) *boundEntity {
+	if b, ok := t.bound[tableName]; ok {
+		return b
+	}
+	return &boundEntity{collection: tableName}
+}
+
+type embedGroup struct {
+	key  interface{}
+	docs []bson.D
+}
+
+// groupEmbedded groups child rows by parent key, preserving first-seen order
+// so that updates are deterministic.
+func groupEmbedded(e *embedding, columns []string, rows [][]interface{}) ([]*embedGroup, error) {
+	fkIdx := -1
+	for i, col := range columns {
+		if col == e.fkColumn {
+			fkIdx = i
+		}
+	}
+	if fkIdx < 0 {
+		return nil, fmt.Errorf("fk column %s missing from batch", e.fkColumn)
+	}
+	var groups []*embedGroup
+	index := make(map[interface{}]*embedGroup)
+	for _, row := range rows {
+		key := row[fkIdx]
+		if key == nil {
+			return nil, fmt.Errorf("embedded row has null %s; it cannot be placed in a parent document", e.fkColumn)
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &embedGroup{key: key}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.docs = append(g.docs, toDocument(columns, row, "", e.fkColumn))
+	}
+	return groups, nil
+}
+
+// toDocument builds a document in column order, renaming idColumn to _id and
+// omitting skip.
+func toDocument(columns []string, row []interface{}, idColumn, skip string) bson.D {
+	doc := make(bson.D, 0, len(columns))
+	for i, col := range columns {
+		switch col {
+		case skip:
+			continue
+		case idColumn:
+			doc = append(bson.D{{Key: "_id", Value: row[i]}}, doc...)
+		default:
+			doc = append(doc, bson.E{Key: col, Value: row[i]})
+		}
+	}
+	return doc
+}
+
+func resolveDatabase(dsn, database string) (string, error) {
+	if database != "" {
+		return database, nil
+	}
+	cs, err := connstring.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid mongodb dsn: %w", err)
+	}
+	if cs.Database == "" {
+		return "", errors.New("mongodb database is required (target database or dsn path)")
+	}
+	return cs.Database, nil
+}
+
+func option(entity *domain.Entity, key string) string {
+	return strings.TrimSpace(entity.Options[key])
+}
+
+func hasColumn(entity *domain.Entity, name string) bool {
+	for _, col := range entity.Columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func GetServerVersion(dsn, database string) (string, error) {
+	t := NewMongoTarget(dsn, database, nil)
+	if err := t.Connect(); err != nil {
+		return "", err
+	}
+	defer t.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var info struct {
+		Version string `bson:"version"`
+	}
+	if err := t.db.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.Version, nil
+}
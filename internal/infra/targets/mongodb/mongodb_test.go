@@ -0,0 +1,134 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func ordersScenario() (*domain.Entity, *domain.Entity) {
+	orders := &domain.Entity{
+		Name:        "orders",
+		TargetTable: "orders",
+		Columns: []domain.Column{
+			{Name: "id", Type: domain.ColumnTypeBigInt},
+			{Name: "total", Type: domain.ColumnTypeDouble},
+		},
+		Options: map[string]string{"id_column": "id"},
+	}
+	items := &domain.Entity{
+		Name:        "line_items",
+		TargetTable: "line_items",
+		Columns: []domain.Column{
+			{Name: "order_id", Type: domain.ColumnTypeBigInt, Generator: domain.GeneratorSpec{Type: "fk", Params: map[string]interface{}{"entity": "orders", "column": "id"}}},
+			{Name: "sku", Type: domain.ColumnTypeString},
+		},
+		Options: map[string]string{"embed_in": "orders"},
+	}
+	return orders, items
+}
+
+func TestBindEntity_Embedding(t *testing.T) {
+	tgt := NewMongoTarget("mongodb://localhost/app", "", nil)
+	orders, items := ordersScenario()
+
+	if err := tgt.BindEntity(items); err == nil {
+		t.Fatal("expected error when parent is not bound yet")
+	}
+	if err := tgt.BindEntity(orders); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.BindEntity(items); err != nil {
+		t.Fatal(err)
+	}
+	e := tgt.byName["line_items"].embed
+	if e.parentCollection != "orders" || e.parentKey != "_id" || e.fkColumn != "order_id" || e.field != "line_items" {
+		t.Fatalf("unexpected embedding %+v", e)
+	}
+
+	items.Columns[0].Generator.Type = "uniform_int"
+	if err := tgt.BindEntity(items); err == nil {
+		t.Fatal("expected error for embedded entity without fk column")
+	}
+}
+
+func TestGroupEmbedded(t *testing.T) {
+	e := &embedding{fkColumn: "order_id"}
+	rows := [][]interface{}{{int64(2), "a"}, {int64(1), "b"}, {int64(2), "c"}}
+	groups, err := groupEmbedded(e, []string{"order_id", "sku"}, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].key != int64(2) || len(groups[0].docs) != 2 || groups[1].key != int64(1) {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+	want := bson.D{{Key: "sku", Value: "c"}}
+	if fmt.Sprint(groups[0].docs[1]) != fmt.Sprint(want) {
+		t.Fatalf("expected fk column omitted, got %v", groups[0].docs[1])
+	}
+
+	if _, err := groupEmbedded(e, []string{"order_id", "sku"}, [][]interface{}{{nil, "x"}}); err == nil {
+		t.Fatal("expected error for null parent key")
+	}
+}
+
+func TestResolveDatabase(t *testing.T) {
+	if db, err := resolveDatabase("mongodb://localhost:27017/shop?authSource=admin", ""); err != nil || db != "shop" {
+		t.Fatalf("expected shop, got %q %v", db, err)
+	}
+	if db, _ := resolveDatabase("mongodb://localhost/shop", "other"); db != "other" {
+		t.Fatalf("expected explicit database to win, got %q", db)
+	}
+	if _, err := resolveDatabase("mongodb://localhost", ""); err == nil {
+		t.Fatal("expected error without database")
+	}
+}
+
+func TestMongoTarget_Integration(t *testing.T) {
+	dsn := os.Getenv("SDGEN_TEST_MONGODB_DSN")
+	if dsn == "" {
+		t.Skip("set SDGEN_TEST_MONGODB_DSN to run mongodb integration tests")
+	}
+	tgt := NewMongoTarget(dsn, fmt.Sprintf("sdgen_test_%d", time.Now().UnixNano()), nil)
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Close()
+	defer tgt.db.Drop(context.Background())
+
+	orders, items := ordersScenario()
+	for _, e := range []*domain.Entity{orders, items} {
+		if err := tgt.CreateTableIfNotExists(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := tgt.TruncateTable(e.TargetTable); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tgt.InsertBatch("orders", []string{"id", "total"}, [][]interface{}{{int64(1), 9.5}, {int64(2), 3.0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("line_items", []string{"order_id", "sku"}, [][]interface{}{{int64(1), "a"}, {int64(1), "b"}, {int64(2), "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("line_items", []string{"order_id", "sku"}, [][]interface{}{{int64(3), "d"}}); err == nil {
+		t.Fatal("expected error for missing parent document")
+	}
+
+	var doc struct {
+		LineItems []struct {
+			SKU string `bson:"sku"`
+		} `bson:"line_items"`
+	}
+	if err := tgt.db.Collection("orders").FindOne(context.Background(), bson.D{{Key: "_id", Value: int64(1)}}).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.LineItems) != 2 || doc.LineItems[1].SKU != "b" {
+		t.Fatalf("unexpected embedded items %+v", doc.LineItems)
+	}
+}
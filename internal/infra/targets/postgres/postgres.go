@@ -2,11 +2,12 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/mmrzaf/sdgen/internal/domain"
 )
 
@@ -162,6 +163,24 @@ func (t *PostgresTarget) TruncateTable(tableName string) error {
 	return err
 }
 
+// MaxValue returns the largest value of a column, or nil for an empty table.
+// Columns are created unquoted, so the name is folded to lower case before
+// it is quoted.
+func (t *PostgresTarget) MaxValue(tableName, column string, byLength bool) (interface{}, error) {
+	col := pq.QuoteIdentifier(strings.ToLower(column))
+	query := fmt.Sprintf("SELECT MAX(%s) FROM %s.%s", col, t.schema, tableName)
+	if byLength {
+		query = fmt.Sprintf(`SELECT %s FROM %s.%s WHERE %s IS NOT NULL ORDER BY length(%s) DESC, %s COLLATE "C" DESC LIMIT 1`,
+			col, t.schema, tableName, col, col, col)
+	}
+	var v interface{}
+	err := t.db.QueryRow(query).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
 func (t *PostgresTarget) InsertBatch(tableName string, columns []string, rows [][]interface{}) error {
 	if len(rows) == 0 {
 		return nil
@@ -0,0 +1,360 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	FormatHash   = "hash"
+	FormatJSON   = "json"
+	FormatStream = "stream"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// RedisTarget writes each row under a key built from row columns, pipelining
+// every InsertBatch into one round trip.
+//
+// Supported options (target-level, overridable per entity via entity options):
+//   - format: hash (default), json (string value) or stream (XADD entry)
+//   - key_pattern: key template with {column} placeholders, e.g. session:{session_id}
+//     (default: <target_table>:{<first column>}; for streams: <target_table>)
+//   - ttl_column: column holding the TTL in seconds (> 0), or an absolute expiry timestamp
+//   - ttl_seconds: constant TTL applied when no ttl_column is set
+//   - stream_maxlen: approximate MAXLEN for stream entries
+//
+// Rows whose values are all null are skipped in hash and stream format.
+// Truncate deletes every key matching key_pattern with placeholders as
+// wildcards, so it never touches keys outside the entity's pattern.
+type RedisTarget struct {
+	dsn     string
+	options map[string]string
+	client  *goredis.Client
+	bound   map[string]*boundEntity
+}
+
+type boundEntity struct {
+	format       string
+	keyPattern   string
+	placeholders []string
+	ttlColumn    string
+	ttl          time.Duration
+	streamMaxLen int64
+}
+
+func NewRedisTarget(dsn string, options map[string]string) *RedisTarget {
+	return &RedisTarget{dsn: dsn, options: options, bound: map[string]*boundEntity{}}
+}
+
+func (t *RedisTarget) Connect() error {
+	opts, err := goredis.ParseURL(t.dsn)
+	if err != nil {
+		return fmt.Errorf("invalid redis dsn: %w", err)
+	}
+	client := goredis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	t.client = client
+	return nil
+}
+
+func (t *RedisTarget) Close() error {
+	if t.client == nil {
+		return nil
+	}
+	return t.client.Close()
+}
+
+// CreateTableIfNotExists only binds the entity: Redis has no schema.
+func (t *RedisTarget) CreateTableIfNotExists(entity *domain.Entity) error {
+	return t.BindEntity(entity)
+}
+
+func (t *RedisTarget) TruncateTable(tableName string) error {
+	b, ok := t.bound[tableName]
+	if !ok {
+		return fmt.Errorf("redis truncate failed: entity for %s is not bound", tableName)
+	}
+	match := keyMatchPattern(b.keyPattern)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var cursor uint64
+	for {
+		keys, next, err := t.client.Scan(ctx, cursor, match, 1000).Result()
+		if err != nil {
+			return fmt.Errorf("redis truncate failed: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := t.client.Unlink(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("redis truncate failed: %w", err)
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// BindEntity resolves the format, key pattern and TTL settings for an entity.
+func (t *RedisTarget) BindEntity(entity *domain.Entity) error {
+	b := &boundEntity{
+		format:     strings.ToLower(t.option(entity, "format")),
+		keyPattern: t.option(entity, "key_pattern"),
+		ttlColumn:  t.option(entity, "ttl_column"),
+	}
+	if b.format == "" {
+		b.format = FormatHash
+	}
+	switch b.format {
+	case FormatHash, FormatJSON, FormatStream:
+	default:
+		return fmt.Errorf("unsupported redis format: %s", b.format)
+	}
+	if b.keyPattern == "" {
+		switch {
+		case b.format == FormatStream:
+			b.keyPattern = entity.TargetTable
+		case len(entity.Columns) > 0:
+			b.keyPattern = entity.TargetTable + ":{" + entity.Columns[0].Name + "}"
+		default:
+			return fmt.Errorf("entity %s has no columns to build redis keys from", entity.Name)
+		}
+	}
+
+	columns := make(map[string]bool, len(entity.Columns))
+	for _, col := range entity.Columns {
+		columns[col.Name] = true
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(b.keyPattern, -1) {
+		if !columns[m[1]] {
+			return fmt.Errorf("key_pattern placeholder {%s} is not a column of entity %s", m[1], entity.Name)
+		}
+		b.placeholders = append(b.placeholders, m[1])
+	}
+	if b.format != FormatStream && len(b.placeholders) == 0 {
+		return fmt.Errorf("key_pattern %q must reference at least one column", b.keyPattern)
+	}
+	if b.ttlColumn != "" && !columns[b.ttlColumn] {
+		return fmt.Errorf("ttl_column %q is not a column of entity %s", b.ttlColumn, entity.Name)
+	}
+	if v := t.option(entity, "ttl_seconds"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid ttl_seconds option: %s", v)
+		}
+		b.ttl = time.Duration(n) * time.Second
+	}
+	if v := t.option(entity, "stream_maxlen"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid stream_maxlen option: %s", v)
+		}
+		b.streamMaxLen = n
+	}
+	t.bound[entity.TargetTable] = b
+	return nil
+}
+
+func (t *RedisTarget) InsertBatch(tableName string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	b, ok := t.bound[tableName]
+	if !ok {
+		return fmt.Errorf("redis insert failed: entity for %s is not bound", tableName)
+	}
+	index := make(map[string]int, len(columns))
+	for i, col := range columns {
+		index[col] = i
+	}
+	ttlIdx := -1
+	if b.ttlColumn != "" {
+		ttlIdx = index[b.ttlColumn]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	pipe := t.client.Pipeline()
+	for _, row := range rows {
+		key := b.renderKey(index, row)
+		switch b.format {
+		case FormatHash:
+			fields := make([]interface{}, 0, 2*len(columns))
+			for i, col := range columns {
+				if row[i] == nil {
+					continue
+				}
+				fields = append(fields, col, formatValue(row[i]))
+			}
+			if len(fields) == 0 {
+				continue // HSET needs at least one field
+			}
+			pipe.HSet(ctx, key, fields...)
+		case FormatJSON:
+			doc := make(map[string]interface{}, len(columns))
+			for i, col := range columns {
+				doc[col] = row[i]
+			}
+			value, err := json.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, key, value, 0)
+		case FormatStream:
+			values := make([]interface{}, 0, 2*len(columns))
+			for i, col := range columns {
+				if row[i] == nil {
+					continue
+				}
+				values = append(values, col, formatValue(row[i]))
+			}
+			if len(values) == 0 {
+				continue // XADD needs at least one field
+			}
+			args := &goredis.XAddArgs{Stream: key, Values: values}
+			if b.streamMaxLen > 0 {
+				args.MaxLen = b.streamMaxLen
+				args.Approx = true
+			}
+			pipe.XAdd(ctx, args)
+		}
+
+		if ttlIdx >= 0 && row[ttlIdx] != nil {
+			switch v := row[ttlIdx].(type) {
+			case time.Time:
+				pipe.ExpireAt(ctx, key, v)
+			default:
+				secs, ok := toInt64(v)
+				if !ok {
+					return fmt.Errorf("ttl_column %s: expected seconds or timestamp, got %T", b.ttlColumn, v)
+				}
+				if secs <= 0 {
+					return fmt.Errorf("ttl_column %s: ttl must be positive, got %d", b.ttlColumn, secs)
+				}
+				pipe.Expire(ctx, key, time.Duration(secs)*time.Second)
+			}
+		} else if b.ttl > 0 {
+			pipe.Expire(ctx, key, b.ttl)
+		}
+	}
+
+	cmds, err := pipe.Exec(ctx)
+	if err != nil {
+		for _, cmd := range cmds {
+			if cmd.Err() != nil {
+				return fmt.Errorf("redis insert failed: %s: %w", cmd.Name(), cmd.Err())
+			}
+		}
+		return fmt.Errorf("redis insert failed: %w", err)
+	}
+	return nil
+}
+
+func (b *boundEntity) renderKey(index map[string]int, row []interface{}) string {
+	if len(b.placeholders) == 0 {
+		return b.keyPattern
+	}
+	return placeholderPattern.ReplaceAllStringFunc(b.keyPattern, func(m string) string {
+		v := row[index[m[1:len(m)-1]]]
+		if v == nil {
+			return ""
+		}
+		return formatValue(v)
+	})
+}
+
+// keyMatchPattern turns a key pattern into a SCAN MATCH glob: literal parts are
+// escaped and every placeholder becomes *.
+func keyMatchPattern(keyPattern string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(keyPattern, -1) {
+		sb.WriteString(escapeGlob(keyPattern[last:loc[0]]))
+		sb.WriteString("*")
+		last = loc[1]
+	}
+	sb.WriteString(escapeGlob(keyPattern[last:]))
+	return sb.String()
+}
+
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		return val.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), true
+	case int32:
+		return int64(val), true
+	case int64:
+		return val, true
+	case float64:
+		return int64(val), true
+	default:
+		return 0, false
+	}
+}
+
+func (t *RedisTarget) option(entity *domain.Entity, key string) string {
+	if v, ok := entity.Options[key]; ok {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(t.options[key])
+}
+
+func GetServerVersion(dsn string) (string, error) {
+	t := NewRedisTarget(dsn, nil)
+	if err := t.Connect(); err != nil {
+		return "", err
+	}
+	defer t.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	info, err := t.client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(info, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+			return v, nil
+		}
+	}
+	return "", nil
+}
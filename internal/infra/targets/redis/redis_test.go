@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+func newTestServer(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping due to restricted socket sandbox: %v", err)
+	}
+	_ = ln.Close()
+	return miniredis.RunT(t)
+}
+
+func TestRedisTarget_HashWithTTLColumnAndTruncate(t *testing.T) {
+	srv := newTestServer(t)
+	tgt := NewRedisTarget("redis://"+srv.Addr()+"/0", nil)
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Close()
+
+	entity := &domain.Entity{
+		Name:        "sessions",
+		TargetTable: "sessions",
+		Columns: []domain.Column{
+			{Name: "session_id", Type: domain.ColumnTypeString},
+			{Name: "user_id", Type: domain.ColumnTypeBigInt},
+			{Name: "ttl", Type: domain.ColumnTypeInt},
+		},
+		Options: map[string]string{"key_pattern": "session:{session_id}", "ttl_column": "ttl"},
+	}
+	if err := tgt.CreateTableIfNotExists(entity); err != nil {
+		t.Fatal(err)
+	}
+	srv.Set("other:key", "keep")
+	rows := [][]interface{}{{"a", int64(1), 60}, {"b", int64(2), 120}}
+	if err := tgt.InsertBatch("sessions", []string{"session_id", "user_id", "ttl"}, rows); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.HGet("session:a", "user_id"); got != "1" {
+		t.Fatalf("expected user_id 1, got %q", got)
+	}
+	if ttl := srv.TTL("session:b"); ttl != 120*time.Second {
+		t.Fatalf("expected ttl 120s, got %v", ttl)
+	}
+
+	if err := tgt.TruncateTable("sessions"); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Exists("session:a") || srv.Exists("session:b") {
+		t.Fatal("expected session keys to be deleted")
+	}
+	if !srv.Exists("other:key") {
+		t.Fatal("truncate must not delete keys outside the pattern")
+	}
+}
+
+func TestRedisTarget_JSONAndStream(t *testing.T) {
+	srv := newTestServer(t)
+	tgt := NewRedisTarget("redis://"+srv.Addr(), map[string]string{"ttl_seconds": "30"})
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Close()
+
+	profiles := &domain.Entity{
+		Name:        "profiles",
+		TargetTable: "profiles",
+		Columns:     []domain.Column{{Name: "id", Type: domain.ColumnTypeBigInt}, {Name: "name", Type: domain.ColumnTypeString}},
+		Options:     map[string]string{"format": "json"},
+	}
+	if err := tgt.BindEntity(profiles); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("profiles", []string{"id", "name"}, [][]interface{}{{int64(7), "ann"}}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := srv.Get("profiles:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil || doc["name"] != "ann" {
+		t.Fatalf("unexpected json value %q (%v)", raw, err)
+	}
+	if ttl := srv.TTL("profiles:7"); ttl != 30*time.Second {
+		t.Fatalf("expected constant ttl, got %v", ttl)
+	}
+
+	events := &domain.Entity{
+		Name:        "events",
+		TargetTable: "events",
+		Columns:     []domain.Column{{Name: "kind", Type: domain.ColumnTypeString}},
+		Options:     map[string]string{"format": "stream", "key_pattern": "events:{kind}", "ttl_seconds": ""},
+	}
+	if err := tgt.BindEntity(events); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("events", []string{"kind"}, [][]interface{}{{"click"}, {"click"}, {"view"}}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := srv.Stream("events:click")
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 stream entries, got %d (%v)", len(entries), err)
+	}
+	if ttl := srv.TTL("events:click"); ttl != 0 {
+		t.Fatalf("expected entity override to disable ttl, got %v", ttl)
+	}
+}
+
+func TestRedisTarget_SkipsEmptyRowsAndRejectsNonPositiveTTL(t *testing.T) {
+	srv := newTestServer(t)
+	tgt := NewRedisTarget("redis://"+srv.Addr(), nil)
+	if err := tgt.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Close()
+
+	for _, format := range []string{"hash", "stream"} {
+		entity := &domain.Entity{
+			Name:        format,
+			TargetTable: format,
+			Columns:     []domain.Column{{Name: "id", Type: domain.ColumnTypeBigInt}, {Name: "note", Type: domain.ColumnTypeString}},
+			Options:     map[string]string{"format": format, "key_pattern": format + ":{id}"},
+		}
+		if err := tgt.BindEntity(entity); err != nil {
+			t.Fatal(err)
+		}
+		rows := [][]interface{}{{nil, nil}, {int64(1), "x"}}
+		if err := tgt.InsertBatch(format, []string{"id", "note"}, rows); err != nil {
+			t.Fatalf("%s: expected all-null row to be skipped, got %v", format, err)
+		}
+		if !srv.Exists(format + ":1") {
+			t.Fatalf("%s: expected the non-empty row to be written", format)
+		}
+	}
+
+	entity := &domain.Entity{
+		Name:        "sessions",
+		TargetTable: "sessions",
+		Columns:     []domain.Column{{Name: "id", Type: domain.ColumnTypeBigInt}, {Name: "ttl", Type: domain.ColumnTypeInt}},
+		Options:     map[string]string{"key_pattern": "session:{id}", "ttl_column": "ttl"},
+	}
+	if err := tgt.BindEntity(entity); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.InsertBatch("sessions", []string{"id", "ttl"}, [][]interface{}{{int64(1), 0}}); err == nil {
+		t.Fatal("expected a zero ttl_column value to be rejected")
+	}
+}
+
+func TestKeyMatchPattern(t *testing.T) {
+	cases := map[string]string{
+		"session:{session_id}":  "session:*",
+		"u:{a}:{b}":             "u:*:*",
+		"weird*[key]:{id}":      `weird\*\[key\]:*`,
+		"stream_without_fields": "stream_without_fields",
+	}
+	for in, want := range cases {
+		if got := keyMatchPattern(in); got != want {
+			t.Fatalf("keyMatchPattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
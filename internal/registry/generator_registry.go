@@ -55,7 +55,18 @@ func DefaultGeneratorRegistry() *GeneratorRegistry {
 	r.Register("faker_name", &generators.FakerNameGenerator{})
 	r.Register("faker_city", &generators.FakerCityGenerator{})
 	r.Register("faker_device_name", &generators.FakerDeviceNameGenerator{})
+	r.Register("faker_email", &generators.FakerEmailGenerator{})
 	r.Register("time_series", &generators.TimeSeriesGenerator{})
 	r.Register("fk", &generators.FKGenerator{})
+	r.Register("expr", &generators.ExprGenerator{})
+	r.Register("switch", &generators.SwitchGenerator{})
+	r.Register("mixture", &generators.MixtureGenerator{})
+	r.Register("dictionary", &generators.DictionaryGenerator{})
+	r.Register("histogram", &generators.HistogramGenerator{})
+	r.Register("pattern", &generators.PatternGenerator{})
+	r.Register("sequence", &generators.SequenceGenerator{})
+	for _, name := range generators.DistributionNames() {
+		r.Register(name, generators.NewDistributionGenerator(name))
+	}
 	return r
 }
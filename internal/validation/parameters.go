@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+)
+
+// paramRefRe matches ${name} references; $${name} is an escaped literal.
+var paramRefRe = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// ResolveParameters returns the scenario's parameter values with overrides
+// applied. Each parameter takes the type of its default (string, int, float
+// or bool); overrides are converted to that type, so string values from the
+// CLI or UI work for every parameter.
+func ResolveParameters(scenario *domain.Scenario, overrides map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(scenario.Parameters))
+	for name, def := range scenario.Parameters {
+		if !identRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid parameter name: %s", name)
+		}
+		v, err := normalizeParam(def)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", name, err)
+		}
+		resolved[name] = v
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def, ok := resolved[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter: %s", name)
+		}
+		v, err := convertParam(overrides[name], def)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", name, err)
+		}
+		resolved[name] = v
+	}
+	return resolved, nil
+}
+
+// ApplyParameters returns a copy of the scenario with ${name} references in
+// generator params replaced by resolved parameter values. A param that is
+// exactly ${name} takes the parameter's typed value; references inside longer
+// strings are formatted into the string. The copy's Parameters hold the
+// resolved values.
+func ApplyParameters(scenario *domain.Scenario, overrides map[string]interface{}) (*domain.Scenario, map[string]interface{}, error) {
+	params, err := ResolveParameters(scenario, overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := *scenario
+	out.Entities = make([]domain.Entity, len(scenario.Entities))
+	for i, e := range scenario.Entities {
+		e.Columns = append([]domain.Column(nil), e.Columns...)
+		for j, col := range e.Columns {
+			if len(col.Generator.Params) == 0 {
+				continue
+			}
+			v, err := substituteParams(col.Generator.Params, params)
+			if err != nil {
+				return nil, nil, fmt.Errorf("entity '%s', column '%s': %w", e.Name, col.Name, err)
+			}
+			e.Columns[j].Generator.Params = v.(map[string]interface{})
+		}
+		e.ColumnGroups = append([]domain.ColumnGroup(nil), e.ColumnGroups...)
+		for j, g := range e.ColumnGroups {
+			if len(g.Generator.Params) == 0 {
+				continue
+			}
+			v, err := substituteParams(g.Generator.Params, params)
+			if err != nil {
+				return nil, nil, fmt.Errorf("entity '%s', column group %d: %w", e.Name, j+1, err)
+			}
+			e.ColumnGroups[j].Generator.Params = v.(map[string]interface{})
+		}
+		out.Entities[i] = e
+	}
+	if len(params) > 0 {
+		out.Parameters = params
+	}
+	return &out, params, nil
+}
+
+func substituteParams(v interface{}, params map[string]interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, item := range x {
+			r, err := substituteParams(item, params)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, item := range x {
+			r, err := substituteParams(item, params)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case string:
+		return substituteString(x, params)
+	default:
+		return v, nil
+	}
+}
+
+func substituteString(s string, params map[string]interface{}) (interface{}, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if m := paramRefRe.FindStringSubmatch(s); m != nil && m[0] == s && !strings.HasPrefix(s, "$$") {
+		v, ok := params[m[1]]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter reference: %s", s)
+		}
+		return v, nil
+	}
+	var missing string
+	out := paramRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := ref[2 : len(ref)-1]
+		v, ok := params[name]
+		if !ok {
+			missing = ref
+			return ref
+		}
+		return fmt.Sprint(v)
+	})
+	if missing != "" {
+		return nil, fmt.Errorf("unknown parameter reference: %s", missing)
+	}
+	return out, nil
+}
+
+// normalizeParam checks a default's type. YAML integers become int64; JSON
+// numbers stay float64 and so type as float.
+func normalizeParam(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case string, bool:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	case float64:
+		return x, nil
+	case nil:
+		return nil, fmt.Errorf("default value is required")
+	default:
+		return nil, fmt.Errorf("default must be a string, number or bool, got %T", v)
+	}
+}
+
+// convertParam converts an override to the type of the parameter's default.
+func convertParam(v interface{}, def interface{}) (interface{}, error) {
+	s, isString := v.(string)
+	switch def.(type) {
+	case string:
+		if isString {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	case int64:
+		if isString {
+			n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected an integer, got %q", s)
+			}
+			return n, nil
+		}
+		switch n := v.(type) {
+		case int:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int64(n), nil
+			}
+		}
+		return nil, fmt.Errorf("expected an integer, got %v", v)
+	case float64:
+		if isString {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected a number, got %q", s)
+			}
+			return f, nil
+		}
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected a number, got %v", v)
+	case bool:
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("expected a bool, got %q", s)
+			}
+			return b, nil
+		}
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a bool, got %v", v)
+	}
+	return nil, fmt.Errorf("unsupported parameter type %T", def)
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/registry"
+)
+
+func parameterScenario() *domain.Scenario {
+	return &domain.Scenario{
+		Name:       "s",
+		Parameters: map[string]interface{}{"region": "eu", "min_age": 18, "ratio": 0.5, "active": true},
+		Entities: []domain.Entity{{
+			Name:        "e",
+			TargetTable: "e",
+			Rows:        1,
+			Columns: []domain.Column{
+				{Name: "region", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{
+					Type:   "choice",
+					Params: map[string]interface{}{"values": []interface{}{"${region}-1", "${region}-2", "$${literal}"}},
+				}},
+				{Name: "age", Type: domain.ColumnTypeInt, Generator: domain.GeneratorSpec{
+					Type:   "uniform_int",
+					Params: map[string]interface{}{"min": "${min_age}", "max": 99},
+				}},
+			},
+		}},
+	}
+}
+
+func TestApplyParameters(t *testing.T) {
+	sc := parameterScenario()
+	out, params, err := ApplyParameters(sc, map[string]interface{}{"region": "us", "min_age": "21", "ratio": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"region": "us", "min_age": int64(21), "ratio": 1.0, "active": true}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("params = %#v, want %#v", params, want)
+	}
+	if got := out.Entities[0].Columns[0].Generator.Params["values"]; !reflect.DeepEqual(got, []interface{}{"us-1", "us-2", "${literal}"}) {
+		t.Fatalf("unexpected substituted values: %#v", got)
+	}
+	if got := out.Entities[0].Columns[1].Generator.Params["min"]; got != int64(21) {
+		t.Fatalf("expected a typed int for a whole-value reference, got %#v", got)
+	}
+	if sc.Entities[0].Columns[1].Generator.Params["min"] != "${min_age}" {
+		t.Fatal("ApplyParameters must not mutate the source scenario")
+	}
+
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	if err := v.ValidateScenario(sc); err != nil {
+		t.Fatalf("expected scenario to validate with parameter defaults, got %v", err)
+	}
+}
+
+func TestApplyParameters_Errors(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"unknown parameter: zone":      {"zone": "x"},
+		"expected an integer":          {"min_age": "old"},
+		"expected a bool":              {"active": "maybe"},
+		"expected a number, got \"x\"": {"ratio": "x"},
+	}
+	for want, overrides := range cases {
+		_, _, err := ApplyParameters(parameterScenario(), overrides)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+
+	sc := parameterScenario()
+	sc.Entities[0].Columns[0].Generator.Params["values"] = []interface{}{"${nope}"}
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	if err := v.ValidateScenario(sc); err == nil || !strings.Contains(err.Error(), "unknown parameter reference: ${nope}") {
+		t.Fatalf("expected unknown reference error, got %v", err)
+	}
+}
@@ -0,0 +1,557 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/registry"
+)
+
+func singleColumnScenario(col domain.Column) *domain.Scenario {
+	return &domain.Scenario{
+		Name: "s",
+		Entities: []domain.Entity{{
+			Name:        "e",
+			TargetTable: "e",
+			Rows:        1,
+			Columns:     []domain.Column{col},
+		}},
+	}
+}
+
+func TestValidateScenario_NullRate(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	col := domain.Column{Name: "c", Type: domain.ColumnTypeString, Nullable: true, Generator: domain.GeneratorSpec{
+		Type:   "choice",
+		Params: map[string]interface{}{"values": []interface{}{"a"}, "null_rate": 0.2},
+	}}
+	if err := v.ValidateScenario(singleColumnScenario(col)); err != nil {
+		t.Fatalf("expected valid null_rate, got %v", err)
+	}
+
+	cases := map[string]func(c *domain.Column){
+		"requires a nullable column": func(c *domain.Column) { c.Nullable = false },
+		"between 0 and 1":            func(c *domain.Column) { c.Generator.Params["null_rate"] = 1.5 },
+		"must be a number":           func(c *domain.Column) { c.Generator.Params["null_rate"] = "half" },
+	}
+	for want, mutate := range cases {
+		c := col
+		c.Generator.Params = map[string]interface{}{"values": []interface{}{"a"}, "null_rate": 0.2}
+		mutate(&c)
+		if err := v.ValidateScenario(singleColumnScenario(c)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_Profiles(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	col := domain.Column{Name: "c", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{
+		Type:   "choice",
+		Params: map[string]interface{}{"values": []interface{}{"a"}},
+	}}
+	scale := 0.1
+	sc := singleColumnScenario(col)
+	sc.Profiles = map[string]domain.ScenarioProfile{
+		"ci": {Scale: &scale, EntityCounts: map[string]int64{"e": 5}, IncludeEntities: []string{"e"}},
+	}
+	if err := v.ValidateScenario(sc); err != nil {
+		t.Fatalf("expected valid profile, got %v", err)
+	}
+
+	zero := 0.0
+	cases := map[string]domain.ScenarioProfile{
+		"scale must be > 0":                   {Scale: &zero},
+		"entity_counts[e] must be > 0":        {EntityCounts: map[string]int64{"e": 0}},
+		"entity_scales references unknown":    {EntityScales: map[string]float64{"nope": 2}},
+		"exclude_entities references unknown": {ExcludeEntities: []string{"nope"}},
+	}
+	for want, p := range cases {
+		sc.Profiles = map[string]domain.ScenarioProfile{"ci": p}
+		if err := v.ValidateScenario(sc); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+	sc.Profiles = map[string]domain.ScenarioProfile{"bad name": {}}
+	if err := v.ValidateScenario(sc); err == nil || !strings.Contains(err.Error(), "invalid profile name") {
+		t.Errorf("expected invalid profile name error, got %v", err)
+	}
+}
+
+func TestValidateScenario_ExprColumns(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	exprCol := func(name string, typ domain.ColumnType, src string) domain.Column {
+		return domain.Column{Name: name, Type: typ, Generator: domain.GeneratorSpec{
+			Type: "expr", Params: map[string]interface{}{"expression": src},
+		}}
+	}
+	sc := func(cols ...domain.Column) *domain.Scenario {
+		base := []domain.Column{
+			{Name: "quantity", Type: domain.ColumnTypeInt, Generator: domain.GeneratorSpec{Type: "uniform_int", Params: map[string]interface{}{"min": 1, "max": 10}}},
+			{Name: "unit_price", Type: domain.ColumnTypeFloat, Generator: domain.GeneratorSpec{Type: "uniform_float", Params: map[string]interface{}{"min": 1, "max": 10}}},
+		}
+		return &domain.Scenario{Name: "s", Entities: []domain.Entity{{
+			Name: "e", TargetTable: "e", Rows: 1, Columns: append(cols, base...),
+		}}}
+	}
+
+	ok := sc(
+		exprCol("total", domain.ColumnTypeDouble, "quantity * unit_price"),
+		exprCol("label", domain.ColumnTypeString, "total > 50 ? 'big' : 'small'"),
+	)
+	if err := v.ValidateScenario(ok); err != nil {
+		t.Fatalf("expected valid expr columns, got %v", err)
+	}
+	order, err := ColumnOrder(&ok.Entities[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// total and label are declared first but read quantity and unit_price
+	if want := []int{2, 3, 0, 1}; !slices.Equal(order, want) {
+		t.Fatalf("ColumnOrder = %v, want %v", order, want)
+	}
+
+	cases := map[string]*domain.Scenario{
+		"requires an 'expression' string param":              sc(exprCol("x", domain.ColumnTypeInt, "")),
+		"references unknown column 'nope'":                   sc(exprCol("x", domain.ColumnTypeInt, "nope + 1")),
+		"column dependency cycle: a -> b -> a":               sc(exprCol("a", domain.ColumnTypeInt, "b + 1"), exprCol("b", domain.ColumnTypeInt, "a + 1")),
+		"expression type float does not fit column type int": sc(exprCol("x", domain.ColumnTypeInt, "quantity * unit_price")),
+		"operator + not defined on string and int":           sc(exprCol("x", domain.ColumnTypeString, "'a' + quantity")),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_SwitchColumns(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	segment := domain.Column{Name: "segment", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{
+		Type: "choice", Params: map[string]interface{}{"values": []interface{}{"enterprise", "retail"}},
+	}}
+	balance := func(nullable bool, params map[string]interface{}) *domain.Scenario {
+		col := domain.Column{Name: "balance", Type: domain.ColumnTypeDouble, Nullable: nullable,
+			Generator: domain.GeneratorSpec{Type: "switch", Params: params}}
+		return &domain.Scenario{Name: "s", Entities: []domain.Entity{{
+			Name: "e", TargetTable: "e", Rows: 1, Columns: []domain.Column{col, segment},
+		}}}
+	}
+	spec := func(typ string, params map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": typ, "params": params}
+	}
+
+	ok := balance(false, map[string]interface{}{
+		"column": "segment",
+		"cases": map[string]interface{}{
+			"enterprise": spec("uniform_float", map[string]interface{}{"min": 10000, "max": 1000000}),
+			"retail":     spec("expr", map[string]interface{}{"expression": "length(segment) * 10"}),
+		},
+		"default": spec("const", map[string]interface{}{"value": 0}),
+	})
+	if err := v.ValidateScenario(ok); err != nil {
+		t.Fatalf("expected valid switch, got %v", err)
+	}
+	if order, _ := ColumnOrder(&ok.Entities[0]); !slices.Equal(order, []int{1, 0}) {
+		t.Fatalf("switch column should be generated after segment, got %v", order)
+	}
+
+	cases := map[string]*domain.Scenario{
+		"switch requires a 'column' param": balance(true, map[string]interface{}{
+			"cases": map[string]interface{}{"a": spec("uuid4", nil)},
+		}),
+		"references unknown column 'tier'": balance(true, map[string]interface{}{
+			"column": "tier", "cases": map[string]interface{}{"a": spec("uuid4", nil)},
+		}),
+		"switch without a 'default' requires a nullable column": balance(false, map[string]interface{}{
+			"column": "segment", "cases": map[string]interface{}{"a": spec("uuid4", nil)},
+		}),
+		"case 'retail': generator validation failed: normal requires": balance(true, map[string]interface{}{
+			"column": "segment", "cases": map[string]interface{}{"retail": spec("normal", map[string]interface{}{"mean": 1})},
+		}),
+		"default: generator not found: nope": balance(true, map[string]interface{}{
+			"column": "segment", "cases": map[string]interface{}{"a": spec("uuid4", nil)}, "default": spec("nope", nil),
+		}),
+		"fk generators cannot be nested": balance(true, map[string]interface{}{
+			"column": "segment", "cases": map[string]interface{}{"a": spec("fk", map[string]interface{}{"entity": "e", "column": "segment"})},
+		}),
+		"case 'a': expression type string does not fit column type double": balance(true, map[string]interface{}{
+			"column": "segment", "cases": map[string]interface{}{"a": spec("expr", map[string]interface{}{"expression": "segment"})},
+		}),
+		"column dependency cycle: balance -> balance": balance(true, map[string]interface{}{
+			"column": "balance", "cases": map[string]interface{}{"a": spec("uuid4", nil)},
+		}),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_ColumnGroups(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	matrix := func(r float64) []interface{} {
+		return []interface{}{[]interface{}{1, r}, []interface{}{r, 1}}
+	}
+	build := func(g domain.ColumnGroup, mutate func(cols []domain.Column)) *domain.Scenario {
+		cols := []domain.Column{
+			{Name: "income", Type: domain.ColumnTypeDouble},
+			{Name: "credit_limit", Type: domain.ColumnTypeInt},
+			{Name: "ratio", Type: domain.ColumnTypeDouble, Generator: domain.GeneratorSpec{
+				Type: "expr", Params: map[string]interface{}{"expression": "credit_limit / income"},
+			}},
+		}
+		if mutate != nil {
+			mutate(cols)
+		}
+		return &domain.Scenario{Name: "s", Entities: []domain.Entity{{
+			Name: "e", TargetTable: "e", Rows: 1, Columns: cols, ColumnGroups: []domain.ColumnGroup{g},
+		}}}
+	}
+	copula := func(corr []interface{}) domain.ColumnGroup {
+		return domain.ColumnGroup{Columns: []string{"income", "credit_limit"}, Generator: domain.GeneratorSpec{
+			Type: "gaussian_copula", Params: map[string]interface{}{
+				"correlation": corr,
+				"marginals": []interface{}{
+					map[string]interface{}{"type": "lognormal", "mu": 10.5, "sigma": 0.6},
+					map[string]interface{}{"type": "empirical", "values": []interface{}{500, 1000, 5000, 20000}},
+				},
+			},
+		}}
+	}
+
+	if err := v.ValidateScenario(build(copula(matrix(0.8)), nil)); err != nil {
+		t.Fatalf("expected valid column group, got %v", err)
+	}
+	mvn := domain.ColumnGroup{Columns: []string{"income", "credit_limit"}, Generator: domain.GeneratorSpec{
+		Type: "multivariate_normal", Params: map[string]interface{}{
+			"correlation": matrix(-0.3), "mean": []interface{}{50000, 8000}, "std": []interface{}{15000, 3000},
+		},
+	}}
+	if err := v.ValidateScenario(build(mvn, nil)); err != nil {
+		t.Fatalf("expected valid multivariate_normal group, got %v", err)
+	}
+
+	threeWay := domain.ColumnGroup{Columns: []string{"income", "credit_limit", "ratio"}, Generator: domain.GeneratorSpec{
+		Type: "multivariate_normal", Params: map[string]interface{}{
+			"correlation": []interface{}{
+				[]interface{}{1, 0.9, 0.9},
+				[]interface{}{0.9, 1, -0.9},
+				[]interface{}{0.9, -0.9, 1},
+			},
+			"mean": []interface{}{0, 0, 0}, "std": []interface{}{1, 1, 1},
+		},
+	}}
+	cases := map[string]*domain.Scenario{
+		"not positive semi-definite": build(threeWay, func(cols []domain.Column) { cols[2].Generator = domain.GeneratorSpec{} }),
+		"must be symmetric":          build(copula([]interface{}{[]interface{}{1, 0.5}, []interface{}{0.4, 1}}), nil),
+		"must be a 2x2 matrix":       build(copula([]interface{}{[]interface{}{1, 0.5}}), nil),
+		"must not declare a generator": build(copula(matrix(0.5)), func(cols []domain.Column) {
+			cols[0].Generator = domain.GeneratorSpec{Type: "uuid4"}
+		}),
+		"must be numeric": build(copula(matrix(0.5)), func(cols []domain.Column) { cols[0].Type = domain.ColumnTypeString }),
+		"unknown marginal type": build(domain.ColumnGroup{Columns: []string{"income", "credit_limit"}, Generator: domain.GeneratorSpec{
+			Type: "gaussian_copula", Params: map[string]interface{}{
+				"correlation": matrix(0.5),
+				"marginals": []interface{}{
+					map[string]interface{}{"type": "normal", "mean": 1, "std": 1},
+					map[string]interface{}{"type": "cauchy"},
+				},
+			},
+		}}, nil),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_Distributions(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	column := func(typ domain.ColumnType, gen string, params map[string]interface{}) *domain.Scenario {
+		return singleColumnScenario(domain.Column{Name: "x", Type: typ, Generator: domain.GeneratorSpec{Type: gen, Params: params}})
+	}
+
+	valid := []*domain.Scenario{
+		column(domain.ColumnTypeDouble, "lognormal", map[string]interface{}{"mu": 3, "sigma": 0.5, "max": 1000, "decimals": 2}),
+		column(domain.ColumnTypeInt, "poisson", map[string]interface{}{"lambda": 4}),
+		column(domain.ColumnTypeBigInt, "binomial", map[string]interface{}{"n": 20, "p": 0.3}),
+		column(domain.ColumnTypeInt, "zipf", map[string]interface{}{"s": 1.2, "n": 1000}),
+		column(domain.ColumnTypeFloat, "truncated_normal", map[string]interface{}{"mean": 40, "std": 12, "min": 18, "max": 90}),
+		column(domain.ColumnTypeBool, "bernoulli", map[string]interface{}{"p": 0.1}),
+	}
+	for _, s := range valid {
+		if err := v.ValidateScenario(s); err != nil {
+			t.Errorf("%s: expected valid, got %v", s.Entities[0].Columns[0].Generator.Type, err)
+		}
+	}
+
+	cases := map[string]*domain.Scenario{
+		"lognormal requires 'sigma' param":               column(domain.ColumnTypeDouble, "lognormal", map[string]interface{}{"mu": 0}),
+		"gamma requires 'shape' and 'scale' params":      column(domain.ColumnTypeDouble, "gamma", nil),
+		"'rate' must be a number":                        column(domain.ColumnTypeDouble, "exponential", map[string]interface{}{"rate": "fast"}),
+		"'lambda' must be > 0":                           column(domain.ColumnTypeInt, "poisson", map[string]interface{}{"lambda": 0}),
+		"'p' must be between 0 and 1":                    column(domain.ColumnTypeInt, "binomial", map[string]interface{}{"n": 10, "p": 1.5}),
+		"'n' must be a whole number":                     column(domain.ColumnTypeInt, "binomial", map[string]interface{}{"n": 2.5, "p": 0.5}),
+		"'p' must be in (0, 1]":                          column(domain.ColumnTypeInt, "geometric", map[string]interface{}{"p": 0}),
+		"'s' must be > 1":                                column(domain.ColumnTypeInt, "zipf", map[string]interface{}{"s": 1, "n": 10}),
+		"min (5) must not be greater than max (1)":       column(domain.ColumnTypeDouble, "pareto", map[string]interface{}{"xm": 1, "alpha": 2, "min": 5, "max": 1}),
+		"truncated_normal requires min < max":            column(domain.ColumnTypeDouble, "truncated_normal", map[string]interface{}{"mean": 0, "std": 1, "min": 1, "max": 1}),
+		"'decimals' only applies to float columns":       column(domain.ColumnTypeInt, "beta", map[string]interface{}{"alpha": 2, "beta": 5, "decimals": 2}),
+		"'decimals' must be a non-negative whole number": column(domain.ColumnTypeDouble, "beta", map[string]interface{}{"alpha": 2, "beta": 5, "decimals": -1}),
+		"gamma requires a numeric column":                column(domain.ColumnTypeString, "gamma", map[string]interface{}{"shape": 2, "scale": 1}),
+		"exponential requires a numeric column":          column(domain.ColumnTypeBool, "exponential", map[string]interface{}{"rate": 1}),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_DictionaryAndHistogram(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	column := func(typ domain.ColumnType, gen string, params map[string]interface{}) *domain.Scenario {
+		return singleColumnScenario(domain.Column{Name: "x", Type: typ, Generator: domain.GeneratorSpec{Type: gen, Params: params}})
+	}
+	bins := func(b ...[]interface{}) []interface{} {
+		out := make([]interface{}, len(b))
+		for i, x := range b {
+			out[i] = map[string]interface{}{"min": x[0], "max": x[1], "weight": x[2]}
+		}
+		return out
+	}
+
+	valid := []*domain.Scenario{
+		column(domain.ColumnTypeString, "dictionary", map[string]interface{}{
+			"file": "data/surnames.csv", "values": []interface{}{"Smith", "Jones"}, "weights": []interface{}{2.0, 1.0},
+		}),
+		column(domain.ColumnTypeInt, "dictionary", map[string]interface{}{"file": "data/codes.csv", "values": []interface{}{"7", " 42"}}),
+		column(domain.ColumnTypeInt, "histogram", map[string]interface{}{"bins": bins([]interface{}{0, 18, 22}, []interface{}{18, 65, 61})}),
+		column(domain.ColumnTypeDouble, "histogram", map[string]interface{}{"bins": bins([]interface{}{0.5, 1, 1}), "decimals": 2}),
+	}
+	for _, s := range valid {
+		if err := v.ValidateScenario(s); err != nil {
+			t.Errorf("%s: expected valid, got %v", s.Entities[0].Columns[0].Generator.Type, err)
+		}
+	}
+
+	cases := map[string]*domain.Scenario{
+		"dictionary requires a 'file' param":         column(domain.ColumnTypeString, "dictionary", nil),
+		"dictionary file 'data/x.csv' is not loaded": column(domain.ColumnTypeString, "dictionary", map[string]interface{}{"file": "data/x.csv"}),
+		"entry 2: \"n/a\" is not an integer": column(domain.ColumnTypeInt, "dictionary", map[string]interface{}{
+			"file": "data/x.csv", "values": []interface{}{"1", "n/a"},
+		}),
+		"total weight is zero": column(domain.ColumnTypeString, "dictionary", map[string]interface{}{
+			"file": "data/x.csv", "values": []interface{}{"a"}, "weights": []interface{}{0.0},
+		}),
+		"histogram requires a non-empty 'bins' list": column(domain.ColumnTypeInt, "histogram", nil),
+		"bin 1 requires a numeric 'weight'": column(domain.ColumnTypeInt, "histogram", map[string]interface{}{
+			"bins": []interface{}{map[string]interface{}{"min": 0, "max": 1}},
+		}),
+		"bin 2: max (1) must be greater than min (5)": column(domain.ColumnTypeInt, "histogram", map[string]interface{}{
+			"bins": bins([]interface{}{0, 1, 1}, []interface{}{5, 1, 1}),
+		}),
+		"bin 1 holds no integer": column(domain.ColumnTypeInt, "histogram", map[string]interface{}{
+			"bins": bins([]interface{}{0.2, 0.8, 1}),
+		}),
+		"histogram total weight is zero": column(domain.ColumnTypeDouble, "histogram", map[string]interface{}{
+			"bins": bins([]interface{}{0, 1, 0}),
+		}),
+		"histogram requires a numeric column": column(domain.ColumnTypeString, "histogram", map[string]interface{}{
+			"bins": bins([]interface{}{0, 1, 1}),
+		}),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_MixtureColumns(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	component := func(weight interface{}, typ string, params map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"weight": weight, "generator": map[string]interface{}{"type": typ, "params": params}}
+	}
+	amount := func(components ...interface{}) *domain.Scenario {
+		return singleColumnScenario(domain.Column{Name: "amount", Type: domain.ColumnTypeDouble, Generator: domain.GeneratorSpec{
+			Type: "mixture", Params: map[string]interface{}{"components": components},
+		}})
+	}
+
+	ok := amount(
+		component(0.6, "lognormal", map[string]interface{}{"mu": 1.5, "sigma": 0.6}),
+		component(0.38, "normal", map[string]interface{}{"mean": 80, "std": 25}),
+		component(0.02, "uniform_float", map[string]interface{}{"min": 5000, "max": 45000}),
+	)
+	if err := v.ValidateScenario(ok); err != nil {
+		t.Fatalf("expected valid mixture, got %v", err)
+	}
+
+	cases := map[string]*domain.Scenario{
+		"mixture requires a non-empty 'components' list":      amount(),
+		"component 1: 'weight' must be a non-negative number": amount(component(-1, "const", map[string]interface{}{"value": 1})),
+		"mixture total weight is zero":                        amount(component(0, "const", map[string]interface{}{"value": 1})),
+		"component 2: generator type is required": amount(
+			component(1, "const", map[string]interface{}{"value": 1}),
+			map[string]interface{}{"weight": 1, "generator": map[string]interface{}{}},
+		),
+		"component 1: unknown field 'type'": amount(map[string]interface{}{"weight": 1, "type": "const"}),
+		"component 2: generator validation failed: normal requires": amount(
+			component(1, "const", map[string]interface{}{"value": 1}),
+			component(1, "normal", map[string]interface{}{"mean": 1}),
+		),
+		"fk generators cannot be nested":                                amount(component(1, "fk", map[string]interface{}{"entity": "e", "column": "amount"})),
+		"component 1: generator validation failed: 'sigma' must be > 0": amount(component(1, "lognormal", map[string]interface{}{"mu": 1, "sigma": 0})),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_PatternColumns(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	column := func(typ domain.ColumnType, rows int64, params map[string]interface{}) *domain.Scenario {
+		s := singleColumnScenario(domain.Column{Name: "code", Type: typ, Generator: domain.GeneratorSpec{Type: "pattern", Params: params}})
+		s.Entities[0].Rows = rows
+		return s
+	}
+
+	valid := []*domain.Scenario{
+		column(domain.ColumnTypeString, 1000000, map[string]interface{}{"pattern": `ACC-[A-Z]{3}-\d{6}`, "unique": true}),
+		column(domain.ColumnTypeText, 10, map[string]interface{}{"pattern": `^(SKU|ITM)-[0-9a-f]{4,8}$`}),
+		column(domain.ColumnTypeString, 50, map[string]interface{}{"pattern": `[A-J]\d`, "unique": true}),
+		column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `\é[a-z]{2}([0-9]{100}){99}`}),
+	}
+	for _, s := range valid {
+		if err := v.ValidateScenario(s); err != nil {
+			t.Errorf("%v: expected valid, got %v", s.Entities[0].Columns[0].Generator.Params["pattern"], err)
+		}
+	}
+
+	nested := column(domain.ColumnTypeString, 10, nil)
+	nested.Entities[0].Columns[0].Generator = domain.GeneratorSpec{Type: "mixture", Params: map[string]interface{}{
+		"components": []interface{}{map[string]interface{}{"weight": 1, "generator": map[string]interface{}{
+			"type": "pattern", "params": map[string]interface{}{"pattern": `\d{4}`, "unique": true},
+		}}},
+	}}
+	cases := map[string]*domain.Scenario{
+		"pattern requires a 'pattern' param":                    column(domain.ColumnTypeString, 10, nil),
+		"unbounded quantifier '+' is not supported":             column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `[A-Z]+`}),
+		"unbounded quantifier {2,} is not supported":            column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `\d{2,}`}),
+		"backreferences are not supported":                      column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `(a)\1`}),
+		"pattern requires a string or text column":              column(domain.ColumnTypeInt, 10, map[string]interface{}{"pattern": `\d{3}`}),
+		"'unique' must be a bool":                               column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `\d{3}`, "unique": "yes"}),
+		"unique pattern has about 100 possible values; 51 rows": column(domain.ColumnTypeString, 51, map[string]interface{}{"pattern": `\d{2}`, "unique": true}),
+		"component 1: unique patterns cannot be nested":         nested,
+		"strings can be longer than 10000 characters":           column(domain.ColumnTypeString, 10, map[string]interface{}{"pattern": `((\d{100}){100}){2}`}),
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_SequenceColumns(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	column := func(typ domain.ColumnType, params map[string]interface{}) *domain.Scenario {
+		return singleColumnScenario(domain.Column{Name: "id", Type: typ, Generator: domain.GeneratorSpec{Type: "sequence", Params: params}})
+	}
+
+	valid := []*domain.Scenario{
+		column(domain.ColumnTypeBigInt, nil),
+		column(domain.ColumnTypeInt, map[string]interface{}{"start": 1000, "step": -10}),
+		column(domain.ColumnTypeString, map[string]interface{}{"format": "INV-%06d", "offset_from": "existing_max"}),
+		column(domain.ColumnTypeText, map[string]interface{}{"format": "%x (100%%)"}),
+	}
+	for _, s := range valid {
+		if err := v.ValidateScenario(s); err != nil {
+			t.Errorf("%v: expected valid, got %v", s.Entities[0].Columns[0].Generator.Params, err)
+		}
+	}
+
+	nested := column(domain.ColumnTypeInt, nil)
+	nested.Entities[0].Columns[0].Generator = domain.GeneratorSpec{Type: "mixture", Params: map[string]interface{}{
+		"components": []interface{}{map[string]interface{}{"weight": 1, "generator": map[string]interface{}{
+			"type": "sequence", "params": map[string]interface{}{"offset_from": "existing_max"},
+		}}},
+	}}
+	cases := map[string]*domain.Scenario{
+		"'start' must be a whole number":                             column(domain.ColumnTypeInt, map[string]interface{}{"start": 1.5}),
+		"'step' must not be 0":                                       column(domain.ColumnTypeInt, map[string]interface{}{"step": 0}),
+		"'format' must contain exactly one integer verb":             column(domain.ColumnTypeString, map[string]interface{}{"format": "INV-%s"}),
+		"'format' requires a string or text column":                  column(domain.ColumnTypeInt, map[string]interface{}{"format": "%06d"}),
+		"'offset_from' must be \"existing_max\"":                     column(domain.ColumnTypeInt, map[string]interface{}{"offset_from": "max"}),
+		"'offset_from' requires a positive 'step'":                   column(domain.ColumnTypeInt, map[string]interface{}{"step": -1, "offset_from": "existing_max"}),
+		"requires a non-negative 'start'":                            column(domain.ColumnTypeString, map[string]interface{}{"start": -5, "offset_from": "existing_max"}),
+		"cannot be combined with a left-justified format":            column(domain.ColumnTypeText, map[string]interface{}{"format": "%-6d", "offset_from": "existing_max"}),
+		"sequence requires an int, bigint, string or text":           column(domain.ColumnTypeDouble, nil),
+		"component 1: sequences with 'offset_from' cannot be nested": nested,
+	}
+	for want, s := range cases {
+		if err := v.ValidateScenario(s); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_EmbedIn(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	build := func(parentOptions, childOptions map[string]string, fkEntity string) *domain.Scenario {
+		id := domain.Column{Name: "id", Type: domain.ColumnTypeBigInt, Generator: domain.GeneratorSpec{
+			Type: "uniform_int", Params: map[string]interface{}{"min": 1, "max": 100},
+		}}
+		fk := domain.Column{Name: "parent_id", Type: domain.ColumnTypeBigInt, Generator: domain.GeneratorSpec{
+			Type: "fk", Params: map[string]interface{}{"entity": fkEntity, "column": "id"},
+		}}
+		return &domain.Scenario{Name: "s", Entities: []domain.Entity{
+			{Name: "orders", TargetTable: "orders", Rows: 1, Columns: []domain.Column{id}, Options: parentOptions},
+			{Name: "shops", TargetTable: "shops", Rows: 1, Columns: []domain.Column{id}},
+			{Name: "items", TargetTable: "items", Rows: 1, Columns: []domain.Column{id, fk}, Options: childOptions},
+		}}
+	}
+	if err := v.ValidateScenario(build(nil, map[string]string{"embed_in": "orders"}, "orders")); err != nil {
+		t.Fatalf("expected valid embedding, got %v", err)
+	}
+
+	cases := map[string]*domain.Scenario{
+		"embed_in entity 'nope' not found":  build(nil, map[string]string{"embed_in": "nope"}, "orders"),
+		"must name another entity":          build(nil, map[string]string{"embed_in": "items"}, "orders"),
+		"nested embedding is not supported": build(map[string]string{"embed_in": "items"}, map[string]string{"embed_in": "orders"}, "orders"),
+		"has no fk column referencing it":   build(nil, map[string]string{"embed_in": "orders"}, "shops"),
+	}
+	for want, sc := range cases {
+		if err := v.ValidateScenario(sc); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateScenario_TTLSecondsOption(t *testing.T) {
+	v := NewValidator(registry.DefaultGeneratorRegistry())
+	col := domain.Column{Name: "c", Type: domain.ColumnTypeString, Generator: domain.GeneratorSpec{
+		Type:   "choice",
+		Params: map[string]interface{}{"values": []interface{}{"a"}},
+	}}
+	sc := singleColumnScenario(col)
+	for _, ok := range []string{"", "60"} {
+		sc.Entities[0].Options = map[string]string{"ttl_seconds": ok}
+		if err := v.ValidateScenario(sc); err != nil {
+			t.Fatalf("expected ttl_seconds %q valid, got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"0", "-5", "soon"} {
+		sc.Entities[0].Options = map[string]string{"ttl_seconds": bad}
+		if err := v.ValidateScenario(sc); err == nil || !strings.Contains(err.Error(), "ttl_seconds") {
+			t.Errorf("expected ttl_seconds %q to be rejected, got %v", bad, err)
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mmrzaf/sdgen/internal/domain"
+	"github.com/mmrzaf/sdgen/internal/expr"
+	"github.com/mmrzaf/sdgen/internal/generators"
 	"github.com/mmrzaf/sdgen/internal/registry"
 )
 
@@ -65,6 +69,13 @@ func (v *Validator) ValidateScenario(scenario *domain.Scenario) error {
 		return errors.New("scenario must have at least one entity")
 	}
 
+	// Parameter references are checked with the defaults substituted; a
+	// scenario without references is returned unchanged.
+	scenario, _, err := ApplyParameters(scenario, nil)
+	if err != nil {
+		return err
+	}
+
 	entityNames := make(map[string]bool)
 	for _, entity := range scenario.Entities {
 		if err := v.validateEntity(&entity, entityNames); err != nil {
@@ -75,7 +86,61 @@ func (v *Validator) ValidateScenario(scenario *domain.Scenario) error {
 	if err := v.validateDependencies(scenario); err != nil {
 		return fmt.Errorf("dependency validation failed: %w", err)
 	}
+	if err := validateEmbeddings(scenario); err != nil {
+		return err
+	}
+
+	profileNames := make([]string, 0, len(scenario.Profiles))
+	for name := range scenario.Profiles {
+		profileNames = append(profileNames, name)
+	}
+	sort.Strings(profileNames)
+	for _, name := range profileNames {
+		if !identRe.MatchString(name) {
+			return fmt.Errorf("invalid profile name: %s", name)
+		}
+		profile := scenario.Profiles[name]
+		if err := validateProfile(&profile, entityNames); err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
 
+// validateProfile checks a scenario profile. Unlike run request overrides,
+// profiles live next to the entities they size, so unknown entity names are
+// errors rather than plan warnings.
+func validateProfile(p *domain.ScenarioProfile, entityNames map[string]bool) error {
+	if p.Scale != nil && *p.Scale <= 0 {
+		return fmt.Errorf("scale must be > 0, got %v", *p.Scale)
+	}
+	for k, n := range p.EntityCounts {
+		if !entityNames[k] {
+			return fmt.Errorf("entity_counts references unknown entity: %s", k)
+		}
+		if n <= 0 {
+			return fmt.Errorf("entity_counts[%s] must be > 0, got %d", k, n)
+		}
+	}
+	for k, f := range p.EntityScales {
+		if !entityNames[k] {
+			return fmt.Errorf("entity_scales references unknown entity: %s", k)
+		}
+		if f <= 0 {
+			return fmt.Errorf("entity_scales[%s] must be > 0, got %v", k, f)
+		}
+	}
+	for _, name := range p.IncludeEntities {
+		if !entityNames[name] {
+			return fmt.Errorf("include_entities references unknown entity: %s", name)
+		}
+	}
+	for _, name := range p.ExcludeEntities {
+		if !entityNames[name] {
+			return fmt.Errorf("exclude_entities references unknown entity: %s", name)
+		}
+	}
 	return nil
 }
 
@@ -107,9 +172,41 @@ func (v *Validator) validateEntity(entity *domain.Entity, entityNames map[string
 		return errors.New("entity must have at least one column")
 	}
 
+	// ttl_seconds (Redis targets) must be positive, since EXPIRE with 0 or
+	// less deletes the key at once; empty disables a target-level TTL.
+	if v := strings.TrimSpace(entity.Options["ttl_seconds"]); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err != nil || n <= 0 {
+			return fmt.Errorf("ttl_seconds option must be a positive integer: %s", v)
+		}
+	}
+
+	grouped := make(map[string]bool)
+	for _, g := range entity.ColumnGroups {
+		for _, name := range g.Columns {
+			grouped[name] = true
+		}
+	}
+
 	columnNames := make(map[string]bool)
 	for _, col := range entity.Columns {
-		if err := v.validateColumn(&col, columnNames); err != nil {
+		if err := v.validateColumn(&col, columnNames, grouped[col.Name]); err != nil {
+			return fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+		if err := checkUniqueSpace(col.Generator, entity.Rows); err != nil {
+			return fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+	}
+
+	if err := validateColumnGroups(entity); err != nil {
+		return err
+	}
+
+	if _, err := ColumnOrder(entity); err != nil {
+		return err
+	}
+	env := expr.EntityEnv(entity)
+	for _, col := range entity.Columns {
+		if err := checkExprs(col.Generator, &col, env); err != nil {
 			return fmt.Errorf("column '%s': %w", col.Name, err)
 		}
 	}
@@ -117,7 +214,55 @@ func (v *Validator) validateEntity(entity *domain.Entity, entityNames map[string
 	return nil
 }
 
-func (v *Validator) validateColumn(col *domain.Column, columnNames map[string]bool) error {
+// checkUniqueSpace requires a unique pattern to have at least twice as many
+// possible values as the entity has rows, which keeps the executor's redraws
+// of repeated values cheap.
+func checkUniqueSpace(spec domain.GeneratorSpec, rows int64) error {
+	if spec.Type != "pattern" || !generators.PatternUnique(spec) {
+		return nil
+	}
+	p, err := generators.PatternOf(spec)
+	if err != nil {
+		return err
+	}
+	if space := p.Space(); space < 2*float64(rows) {
+		return fmt.Errorf("unique pattern has about %.0f possible values; %d rows need at least %d", space, rows, 2*rows)
+	}
+	return nil
+}
+
+// checkExprs type-checks the expr generators in spec and the specs nested in
+// it against the entity's columns and the type of the column they fill.
+func checkExprs(spec domain.GeneratorSpec, col *domain.Column, env map[string]expr.Type) error {
+	nested, err := generators.NestedSpecs(spec)
+	if err != nil {
+		return err
+	}
+	for _, n := range nested {
+		if err := checkExprs(n.Spec, col, env); err != nil {
+			return fmt.Errorf("%s: %w", n.Label, err)
+		}
+	}
+	if spec.Type != "expr" {
+		return nil
+	}
+	src, err := generators.ExprSource(spec)
+	if err != nil {
+		return err
+	}
+	prog, err := expr.Compile(src, env)
+	if err != nil {
+		return fmt.Errorf("invalid expression: %w", err)
+	}
+	if !expr.Assignable(prog.Type(), col.Type) {
+		return fmt.Errorf("expression type %s does not fit column type %s", prog.Type(), col.Type)
+	}
+	return nil
+}
+
+// validateColumn checks a column; grouped columns are generated by one of
+// the entity's column groups and declare no generator.
+func (v *Validator) validateColumn(col *domain.Column, columnNames map[string]bool, grouped bool) error {
 	if col.Name == "" {
 		return errors.New("column name is required")
 	}
@@ -138,17 +283,12 @@ func (v *Validator) validateColumn(col *domain.Column, columnNames map[string]bo
 		return fmt.Errorf("invalid column type: %s", col.Type)
 	}
 
-	if col.Generator.Type == "" {
-		return errors.New("generator type is required")
-	}
-
-	gen, err := v.genRegistry.Get(col.Generator.Type)
-	if err != nil {
-		return fmt.Errorf("generator not found: %s", col.Generator.Type)
-	}
-
-	if err := gen.Validate(col.Generator, col.Type); err != nil {
-		return fmt.Errorf("generator validation failed: %w", err)
+	if grouped {
+		if col.Generator.Type != "" {
+			return errors.New("column is generated by a column group and must not declare a generator")
+		}
+	} else if err := v.validateGenerator(col.Generator, col); err != nil {
+		return err
 	}
 
 	// Optional FK metadata should be safe identifiers if present.
@@ -167,6 +307,99 @@ func (v *Validator) validateColumn(col *domain.Column, columnNames map[string]bo
 	return nil
 }
 
+// validateColumnGroups checks that each group covers distinct numeric columns
+// of the entity and that its generator is valid for them.
+func validateColumnGroups(entity *domain.Entity) error {
+	columns := make(map[string]*domain.Column, len(entity.Columns))
+	for i := range entity.Columns {
+		columns[entity.Columns[i].Name] = &entity.Columns[i]
+	}
+	seen := make(map[string]bool)
+	for i, g := range entity.ColumnGroups {
+		if len(g.Columns) < 2 {
+			return fmt.Errorf("column group %d: at least 2 columns are required", i+1)
+		}
+		for _, name := range g.Columns {
+			col, ok := columns[name]
+			if !ok {
+				return fmt.Errorf("column group %d: unknown column '%s'", i+1, name)
+			}
+			if seen[name] {
+				return fmt.Errorf("column group %d: column '%s' is already in a column group", i+1, name)
+			}
+			seen[name] = true
+			switch col.Type {
+			case domain.ColumnTypeInt, domain.ColumnTypeBigInt, domain.ColumnTypeFloat, domain.ColumnTypeDouble:
+			default:
+				return fmt.Errorf("column group %d: column '%s' must be numeric, got %s", i+1, name, col.Type)
+			}
+		}
+		if _, err := generators.NewCorrelated(g.Generator, len(g.Columns)); err != nil {
+			return fmt.Errorf("column group %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validateGenerator validates spec as the generator of col, recursing into
+// the specs nested in it (e.g. the cases of a switch).
+func (v *Validator) validateGenerator(spec domain.GeneratorSpec, col *domain.Column) error {
+	if spec.Type == "" {
+		return errors.New("generator type is required")
+	}
+
+	gen, err := v.genRegistry.Get(spec.Type)
+	if err != nil {
+		return fmt.Errorf("generator not found: %s", spec.Type)
+	}
+
+	if err := gen.Validate(spec, col.Type); err != nil {
+		return fmt.Errorf("generator validation failed: %w", err)
+	}
+
+	if raw, ok := spec.Params["null_rate"]; ok {
+		switch raw.(type) {
+		case int, int64, float64:
+		default:
+			return errors.New("null_rate must be a number")
+		}
+		if rate := generators.NullRate(spec); rate < 0 || rate > 1 {
+			return fmt.Errorf("null_rate must be between 0 and 1, got %v", raw)
+		}
+		if !col.Nullable {
+			return errors.New("null_rate requires a nullable column")
+		}
+	}
+
+	if spec.Type == "switch" && !col.Nullable {
+		if _, hasDefault := spec.Params["default"]; !hasDefault {
+			return errors.New("switch without a 'default' requires a nullable column")
+		}
+	}
+
+	nested, err := generators.NestedSpecs(spec)
+	if err != nil {
+		return fmt.Errorf("generator validation failed: %w", err)
+	}
+	for _, n := range nested {
+		// fk dependencies are only tracked for top-level generators
+		if n.Spec.Type == "fk" {
+			return fmt.Errorf("%s: fk generators cannot be nested", n.Label)
+		}
+		// uniqueness is tracked per column, not per nested spec
+		if n.Spec.Type == "pattern" && generators.PatternUnique(n.Spec) {
+			return fmt.Errorf("%s: unique patterns cannot be nested", n.Label)
+		}
+		if _, ok := n.Spec.Params["offset_from"]; ok && n.Spec.Type == "sequence" {
+			return fmt.Errorf("%s: sequences with 'offset_from' cannot be nested", n.Label)
+		}
+		if err := v.validateGenerator(n.Spec, col); err != nil {
+			return fmt.Errorf("%s: %w", n.Label, err)
+		}
+	}
+	return nil
+}
+
 func isValidColumnType(t domain.ColumnType) bool {
 	switch t {
 	case domain.ColumnTypeInt, domain.ColumnTypeBigInt, domain.ColumnTypeFloat,
@@ -228,6 +461,41 @@ func (v *Validator) validateDependencies(scenario *domain.Scenario) error {
 	return nil
 }
 
+// validateEmbeddings checks the 'embed_in' entity option (MongoDB targets):
+// the parent must be another, non-embedded entity that the child references
+// through an fk column, which also makes the parent generate first.
+func validateEmbeddings(scenario *domain.Scenario) error {
+	embedded := make(map[string]string)
+	for _, entity := range scenario.Entities {
+		if parent := strings.TrimSpace(entity.Options["embed_in"]); parent != "" {
+			embedded[entity.Name] = parent
+		}
+	}
+	for _, entity := range scenario.Entities {
+		parent, ok := embedded[entity.Name]
+		if !ok {
+			continue
+		}
+		if parent == entity.Name {
+			return fmt.Errorf("entity '%s': embed_in must name another entity", entity.Name)
+		}
+		if !slices.ContainsFunc(scenario.Entities, func(e domain.Entity) bool { return e.Name == parent }) {
+			return fmt.Errorf("entity '%s': embed_in entity '%s' not found", entity.Name, parent)
+		}
+		if _, nested := embedded[parent]; nested {
+			return fmt.Errorf("entity '%s': embed_in entity '%s' is itself embedded; nested embedding is not supported", entity.Name, parent)
+		}
+		hasFK := slices.ContainsFunc(entity.Columns, func(c domain.Column) bool {
+			ref, _ := c.Generator.Params["entity"].(string)
+			return c.Generator.Type == "fk" && ref == parent
+		})
+		if !hasFK {
+			return fmt.Errorf("entity '%s': embedded in '%s' but has no fk column referencing it", entity.Name, parent)
+		}
+	}
+	return nil
+}
+
 func hasCycle(graph map[string][]string) bool {
 	visited := make(map[string]bool)
 	recStack := make(map[string]bool)
@@ -278,12 +546,93 @@ func (v *Validator) ValidateTarget(t *domain.TargetConfig) error {
 		if t.Schema != "" && !IsValidIdentifier(t.Schema) {
 			return fmt.Errorf("invalid target schema identifier: %s", t.Schema)
 		}
-	case "elasticsearch":
+	case "elasticsearch", "opensearch":
 		if t.Schema != "" {
 			return fmt.Errorf("%s targets must not set schema", t.Kind)
 		}
 		if t.Database != "" {
-			return errors.New("elasticsearch targets must not set database")
+			return fmt.Errorf("%s targets must not set database", t.Kind)
+		}
+		if v, ok := t.Options["op_type"]; ok && v != "index" && v != "create" {
+			return fmt.Errorf("%s op_type option must be index or create: %s", t.Kind, v)
+		}
+		for _, key := range []string{"insecure_skip_verify", "bulk_tuning", "data_stream"} {
+			if v, ok := t.Options[key]; ok && v != "true" && v != "false" {
+				return fmt.Errorf("%s %s option must be true or false: %s", t.Kind, key, v)
+			}
+		}
+		for _, key := range []string{"bulk_workers", "bulk_max_bytes"} {
+			if v, ok := t.Options[key]; ok {
+				if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+					return fmt.Errorf("%s %s option must be a positive integer: %s", t.Kind, key, v)
+				}
+			}
+		}
+		for _, key := range []string{"number_of_shards", "number_of_replicas"} {
+			if v, ok := t.Options[key]; ok {
+				if n, err := strconv.Atoi(v); err != nil || n < 0 {
+					return fmt.Errorf("%s %s option must be a non-negative integer: %s", t.Kind, key, v)
+				}
+			}
+		}
+	case "clickhouse":
+		if t.Schema != "" {
+			return fmt.Errorf("%s targets must not set schema", t.Kind)
+		}
+		if f := t.Options["format"]; f != "" && f != "JSONEachRow" && f != "RowBinary" {
+			return fmt.Errorf("unsupported clickhouse format option: %s", f)
+		}
+	case "kafka":
+		if t.Schema != "" || t.Database != "" {
+			return errors.New("kafka targets must not set schema or database")
+		}
+		allowed := map[string][]string{
+			"format":      {"json", "avro"},
+			"partitioner": {"key", "round_robin", "least_backup"},
+			"acks":        {"all", "-1", "leader", "1", "none", "0"},
+			"compression": {"none", "gzip", "snappy", "lz4", "zstd"},
+		}
+		for key, values := range allowed {
+			if v, ok := t.Options[key]; ok && !containsString(values, strings.ToLower(v)) {
+				return fmt.Errorf("unsupported kafka %s option: %s", key, v)
+			}
+		}
+	case "mongodb":
+		if t.Schema != "" {
+			return fmt.Errorf("%s targets must not set schema", t.Kind)
+		}
+		if !strings.HasPrefix(t.DSN, "mongodb://") && !strings.HasPrefix(t.DSN, "mongodb+srv://") {
+			return errors.New("mongodb target dsn must start with mongodb:// or mongodb+srv://")
+		}
+		if m := t.Options["truncate_mode"]; m != "" && m != "delete" && m != "drop" {
+			return fmt.Errorf("unsupported mongodb truncate_mode option: %s", m)
+		}
+	case "redis":
+		if t.Schema != "" || t.Database != "" {
+			return errors.New("redis targets must not set schema or database (use the DSN path for the db number)")
+		}
+		if !strings.HasPrefix(t.DSN, "redis://") && !strings.HasPrefix(t.DSN, "rediss://") && !strings.HasPrefix(t.DSN, "unix://") {
+			return errors.New("redis target dsn must start with redis://, rediss:// or unix://")
+		}
+		if f := t.Options["format"]; f != "" && !containsString([]string{"hash", "json", "stream"}, strings.ToLower(f)) {
+			return fmt.Errorf("unsupported redis format option: %s", f)
+		}
+		for _, key := range []string{"ttl_seconds", "stream_maxlen"} {
+			if v := t.Options[key]; v != "" {
+				if n, err := strconv.ParseInt(v, 10, 64); err != nil || n <= 0 {
+					return fmt.Errorf("redis %s option must be a positive integer: %s", key, v)
+				}
+			}
+		}
+	case "http":
+		if t.Schema != "" || t.Database != "" {
+			return errors.New("http targets must not set schema or database")
+		}
+		if !strings.HasPrefix(t.DSN, "http://") && !strings.HasPrefix(t.DSN, "https://") {
+			return errors.New("http target dsn must start with http:// or https://")
+		}
+		if v, ok := t.Options["batch"]; ok && v != "true" && v != "false" {
+			return fmt.Errorf("http batch option must be true or false: %s", v)
 		}
 	default:
 		return fmt.Errorf("unsupported target kind: %s", t.Kind)
@@ -292,6 +641,23 @@ func (v *Validator) ValidateTarget(t *domain.TargetConfig) error {
 	return nil
 }
 
+// ValidateTargetMode rejects table modes a target kind cannot carry out.
+func (v *Validator) ValidateTargetMode(t *domain.TargetConfig, mode string) error {
+	if t.Kind == "http" && mode == domain.TableModeTruncate {
+		return errors.New("http targets cannot truncate; use create or append mode")
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *Validator) ValidateRunRequest(req *domain.RunRequest) error {
 	hasScenarioID := req.ScenarioID != ""
 	hasScenario := req.Scenario != nil
@@ -339,6 +705,16 @@ func (v *Validator) ValidateRunRequest(req *domain.RunRequest) error {
 		}
 	}
 
+	if req.Profile != "" && !identRe.MatchString(req.Profile) {
+		return fmt.Errorf("invalid profile name: %s", req.Profile)
+	}
+
+	for k := range req.Params {
+		if !identRe.MatchString(k) {
+			return fmt.Errorf("invalid parameter name in params: %s", k)
+		}
+	}
+
 	// entity_counts: must be >0 and valid identifiers
 	if req.EntityCounts != nil {
 		for k, v := range req.EntityCounts {
@@ -360,6 +736,9 @@ func (v *Validator) ValidateRunRequest(req *domain.RunRequest) error {
 			return fmt.Errorf("invalid entity name in exclude_entities: %s", name)
 		}
 	}
+	if req.MaxRejectedDocs < 0 {
+		return fmt.Errorf("max_rejected_docs must be >= 0, got %d", req.MaxRejectedDocs)
+	}
 
 	if req.Scenario != nil {
 		if err := v.ValidateScenario(req.Scenario); err != nil {
@@ -376,6 +755,72 @@ func (v *Validator) ValidateRunRequest(req *domain.RunRequest) error {
 	return nil
 }
 
+// ColumnOrder returns the order in which an entity's columns are generated:
+// declaration order, except that a column reading other columns of the same
+// row (see generators.RowDependencies) comes after the columns it reads.
+func ColumnOrder(entity *domain.Entity) ([]int, error) {
+	index := make(map[string]int, len(entity.Columns))
+	for i, col := range entity.Columns {
+		index[col.Name] = i
+	}
+	deps := make([][]int, len(entity.Columns))
+	for i, col := range entity.Columns {
+		names, err := generators.RowDependencies(col.Generator)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("column '%s': references unknown column '%s'", col.Name, name)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(entity.Columns))
+	order := make([]int, 0, len(entity.Columns))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for k, name := range path {
+				if name == entity.Columns[i].Name {
+					start = k
+				}
+			}
+			cycle := append(append([]string(nil), path[start:]...), entity.Columns[i].Name)
+			return fmt.Errorf("column dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[i] = visiting
+		path = append(path, entity.Columns[i].Name)
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		order = append(order, i)
+		return nil
+	}
+	for i := range entity.Columns {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
 func TopologicalSort(scenario *domain.Scenario) ([]string, error) {
 	graph := make(map[string][]string) // dependency -> dependents
 	inDegree := make(map[string]int)